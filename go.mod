@@ -1,3 +1,12 @@
 module github.com/jaykbpark/wirepad
 
-go 1.22
+go 1.24
+
+require (
+	github.com/andybalholm/brotli v1.2.6
+	github.com/bufbuild/protocompile v0.14.1
+	github.com/klauspost/compress v1.19.2
+	google.golang.org/protobuf v1.36.12
+)
+
+require golang.org/x/sync v0.8.0 // indirect
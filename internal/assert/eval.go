@@ -0,0 +1,532 @@
+package assert
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Subject is the observed outcome of a run that expectations are checked against.
+type Subject struct {
+	Status     int
+	Protocol   string
+	Headers    map[string]string
+	Body       []byte
+	DurationMS int64
+	// Timing holds phase durations keyed by their expect name (dns_ms, ttfb_ms, ...).
+	Timing map[string]float64
+	// GraphQL marks a GraphQL response; unless expect.graphql says otherwise,
+	// any errors[] entries fail the run.
+	GraphQL bool
+}
+
+// Result is the outcome of one operator applied to one expectation path.
+type Result struct {
+	Path     string `json:"path"`
+	Operator string `json:"operator"`
+	Expected any    `json:"expected"`
+	Actual   any    `json:"actual"`
+	Passed   bool   `json:"passed"`
+	Message  string `json:"message,omitempty"`
+}
+
+func (r Result) String() string {
+	status := "ok"
+	if !r.Passed {
+		status = "FAIL"
+	}
+	out := fmt.Sprintf("%s %s %s: expected %s, got %s", status, r.Path, r.Operator, formatValue(r.Expected), formatValue(r.Actual))
+	if r.Message != "" {
+		out += " (" + r.Message + ")"
+	}
+	return out
+}
+
+var operators = []string{"equals", "not_equals", "exists", "contains", "matches", "gt", "gte", "lt", "lte", "in"}
+
+// Evaluate checks every expectation in expect against subject. Results are
+// ordered by expectation path so output is stable.
+func Evaluate(expect map[string]any, subject Subject) []Result {
+	if _, ok := expect["graphql"]; subject.GraphQL && !ok {
+		expect = maps.Clone(expect)
+		if expect == nil {
+			expect = make(map[string]any)
+		}
+		expect["graphql"] = map[string]any{"no_errors": true}
+	}
+
+	var results []Result
+	for _, key := range sortedMapKeys(expect) {
+		spec := expect[key]
+		switch key {
+		case "status":
+			results = append(results, evaluateStatus(spec, subject.Status)...)
+		case "protocol":
+			results = append(results, evaluateProtocol(spec, subject.Protocol)...)
+		case "headers":
+			results = append(results, evaluateHeaders(spec, subject.Headers)...)
+		case "body":
+			results = append(results, evaluateBody(spec, subject.Body)...)
+		case "max_duration_ms":
+			results = append(results, applyOperator("max_duration_ms", "lte", spec, subject.DurationMS, true))
+		case "timing":
+			results = append(results, evaluateTiming(spec, subject.Timing)...)
+		case "graphql":
+			results = append(results, evaluateGraphQL(spec, subject.Body)...)
+		default:
+			results = append(results, Result{
+				Path:     key,
+				Operator: "unsupported",
+				Expected: spec,
+				Message:  "unknown expectation",
+			})
+		}
+	}
+	return results
+}
+
+// Counts returns the number of passed and failed results.
+func Counts(results []Result) (int, int) {
+	passed := 0
+	for _, result := range results {
+		if result.Passed {
+			passed++
+		}
+	}
+	return passed, len(results) - passed
+}
+
+// Failures returns only the failed results.
+func Failures(results []Result) []Result {
+	var out []Result
+	for _, result := range results {
+		if !result.Passed {
+			out = append(out, result)
+		}
+	}
+	return out
+}
+
+func evaluateStatus(spec any, status int) []Result {
+	if list, ok := spec.([]any); ok {
+		return []Result{applyOperator("status", "in", list, status, true)}
+	}
+	return evaluateOperators("status", spec, status, true, "equals")
+}
+
+// evaluateProtocol compares against the negotiated protocol. A bare value may
+// use request.http_version spelling, so 2 and h2c both mean "HTTP/2.0".
+func evaluateProtocol(spec any, proto string) []Result {
+	if _, ok := spec.(map[string]any); ok {
+		return evaluateOperators("protocol", spec, proto, proto != "", "equals")
+	}
+	return []Result{applyOperator("protocol", "equals", protocolName(fmt.Sprint(spec)), proto, proto != "")}
+}
+
+func protocolName(version string) string {
+	switch strings.ToLower(strings.TrimSpace(version)) {
+	case "1.0", "http/1.0":
+		return "HTTP/1.0"
+	case "1.1", "http/1.1":
+		return "HTTP/1.1"
+	case "2", "2.0", "h2", "h2c", "http/2", "http/2.0":
+		return "HTTP/2.0"
+	case "3", "h3", "http/3", "http/3.0":
+		return "HTTP/3.0"
+	}
+	return version
+}
+
+func evaluateHeaders(spec any, headers map[string]string) []Result {
+	expected, ok := spec.(map[string]any)
+	if !ok {
+		return []Result{invalidSpec("headers", spec, "expected a map of header names")}
+	}
+
+	var results []Result
+	for _, name := range sortedMapKeys(expected) {
+		actual, exists := lookupHeader(headers, name)
+		// A bare value matches as a substring so "application/json" accepts
+		// "application/json; charset=utf-8".
+		results = append(results, evaluateOperators("headers."+strings.ToLower(name), expected[name], actual, exists, "contains")...)
+	}
+	return results
+}
+
+func evaluateBody(spec any, body []byte) []Result {
+	expected, ok := spec.(map[string]any)
+	if !ok {
+		return []Result{invalidSpec("body", spec, "expected a map")}
+	}
+
+	var results []Result
+	for _, key := range sortedMapKeys(expected) {
+		switch key {
+		case "jsonpath":
+			results = append(results, evaluateJSONPaths(expected[key], body)...)
+		case "contains":
+			results = append(results, applyOperator("body", "contains", expected[key], string(body), true))
+		default:
+			results = append(results, invalidSpec("body."+key, expected[key], "unknown body expectation"))
+		}
+	}
+	return results
+}
+
+func evaluateJSONPaths(spec any, body []byte) []Result {
+	paths, ok := spec.(map[string]any)
+	if !ok {
+		return []Result{invalidSpec("body.jsonpath", spec, "expected a map of JSONPath expressions")}
+	}
+
+	var doc any
+	decodeErr := json.Unmarshal(body, &doc)
+
+	var results []Result
+	for _, path := range sortedMapKeys(paths) {
+		field := "body.jsonpath." + path
+		if decodeErr != nil {
+			results = append(results, invalidSpec(field, paths[path], "response body is not JSON"))
+			continue
+		}
+		actual, exists, err := Lookup(doc, path)
+		if err != nil {
+			results = append(results, invalidSpec(field, paths[path], err.Error()))
+			continue
+		}
+		results = append(results, evaluateOperators(field, paths[path], actual, exists, "equals")...)
+	}
+	return results
+}
+
+// evaluateGraphQL reports each entry of a GraphQL response's errors[] as its
+// own failure so the server's messages show up in the output.
+func evaluateGraphQL(spec any, body []byte) []Result {
+	expected, ok := spec.(map[string]any)
+	if !ok {
+		return []Result{invalidSpec("graphql", spec, "expected a map")}
+	}
+
+	var results []Result
+	for _, key := range sortedMapKeys(expected) {
+		if key != "no_errors" {
+			results = append(results, invalidSpec("graphql."+key, expected[key], "unknown graphql expectation"))
+			continue
+		}
+		want, ok := expected[key].(bool)
+		if !ok {
+			results = append(results, invalidSpec("graphql.no_errors", expected[key], "expected true or false"))
+			continue
+		}
+		if !want {
+			continue
+		}
+
+		var response struct {
+			Errors []struct {
+				Message string `json:"message"`
+				Path    []any  `json:"path"`
+			} `json:"errors"`
+		}
+		if err := json.Unmarshal(body, &response); err != nil {
+			results = append(results, invalidSpec("graphql.no_errors", true, "response body is not JSON"))
+			continue
+		}
+		if len(response.Errors) == 0 {
+			results = append(results, Result{Path: "graphql.no_errors", Operator: "equals", Expected: true, Actual: true, Passed: true})
+			continue
+		}
+		for i, graphqlErr := range response.Errors {
+			result := Result{
+				Path:     fmt.Sprintf("graphql.errors[%d]", i),
+				Operator: "no_errors",
+				Expected: nil,
+				Actual:   graphqlErr.Message,
+			}
+			if len(graphqlErr.Path) > 0 {
+				result.Message = "at " + formatErrorPath(graphqlErr.Path)
+			}
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+func formatErrorPath(path []any) string {
+	var b strings.Builder
+	for i, part := range path {
+		if index, ok := part.(float64); ok {
+			fmt.Fprintf(&b, "[%d]", int(index))
+			continue
+		}
+		if i > 0 {
+			b.WriteByte('.')
+		}
+		fmt.Fprint(&b, part)
+	}
+	return b.String()
+}
+
+func evaluateTiming(spec any, timing map[string]float64) []Result {
+	expected, ok := spec.(map[string]any)
+	if !ok {
+		return []Result{invalidSpec("timing", spec, "expected a map of timing phases")}
+	}
+
+	var results []Result
+	for _, phase := range sortedMapKeys(expected) {
+		actual, exists := timing[phase]
+		// A bare number is a budget: the phase must not take longer.
+		results = append(results, evaluateOperators("timing."+phase, expected[phase], actual, exists, "lte")...)
+	}
+	return results
+}
+
+// EvaluateValue applies an operator map (or a bare value using defaultOp) to
+// an arbitrary actual value. Other packages use it for expectations they own.
+func EvaluateValue(path string, spec any, actual any, exists bool, defaultOp string) []Result {
+	return evaluateOperators(path, spec, actual, exists, defaultOp)
+}
+
+func evaluateOperators(path string, spec any, actual any, exists bool, defaultOp string) []Result {
+	ops, ok := spec.(map[string]any)
+	if !ok || !allOperators(ops) {
+		return []Result{applyOperator(path, defaultOp, spec, actual, exists)}
+	}
+
+	var results []Result
+	for _, op := range sortedMapKeys(ops) {
+		results = append(results, applyOperator(path, op, ops[op], actual, exists))
+	}
+	return results
+}
+
+func allOperators(ops map[string]any) bool {
+	if len(ops) == 0 {
+		return false
+	}
+	for key := range ops {
+		if !slices.Contains(operators, key) {
+			return false
+		}
+	}
+	return true
+}
+
+func applyOperator(path, op string, expected any, actual any, exists bool) Result {
+	result := Result{Path: path, Operator: op, Expected: expected, Actual: actual}
+	if !exists {
+		result.Actual = nil
+	}
+
+	if op == "exists" {
+		want, ok := expected.(bool)
+		if !ok {
+			result.Message = "exists expects true or false"
+			return result
+		}
+		result.Actual = exists
+		result.Passed = want == exists
+		return result
+	}
+
+	if !exists {
+		result.Message = "value not found"
+		return result
+	}
+
+	passed, err := compare(op, expected, actual)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+	result.Passed = passed
+	return result
+}
+
+func compare(op string, expected any, actual any) (bool, error) {
+	switch op {
+	case "equals":
+		return valuesEqual(expected, actual), nil
+	case "not_equals":
+		return !valuesEqual(expected, actual), nil
+	case "in":
+		list, ok := expected.([]any)
+		if !ok {
+			return false, fmt.Errorf("in expects a list")
+		}
+		for _, item := range list {
+			if valuesEqual(item, actual) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "contains":
+		switch typed := actual.(type) {
+		case string:
+			return strings.Contains(typed, fmt.Sprint(expected)), nil
+		case []any:
+			for _, item := range typed {
+				if valuesEqual(expected, item) {
+					return true, nil
+				}
+			}
+			return false, nil
+		case map[string]any:
+			_, ok := typed[fmt.Sprint(expected)]
+			return ok, nil
+		default:
+			return strings.Contains(fmt.Sprint(actual), fmt.Sprint(expected)), nil
+		}
+	case "matches":
+		pattern, ok := expected.(string)
+		if !ok {
+			return false, fmt.Errorf("matches expects a regex string")
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return false, fmt.Errorf("invalid regex: %w", err)
+		}
+		return re.MatchString(stringValue(actual)), nil
+	case "gt", "gte", "lt", "lte":
+		want, ok := toFloat(expected)
+		if !ok {
+			return false, fmt.Errorf("%s expects a number", op)
+		}
+		got, ok := toFloat(actual)
+		if !ok {
+			return false, fmt.Errorf("actual value is not numeric")
+		}
+		switch op {
+		case "gt":
+			return got > want, nil
+		case "gte":
+			return got >= want, nil
+		case "lt":
+			return got < want, nil
+		default:
+			return got <= want, nil
+		}
+	default:
+		return false, fmt.Errorf("unknown operator %q", op)
+	}
+}
+
+// valuesEqual compares two numbers numerically and anything involving a
+// string as exact text, so "1.0" does not equal "1" and "007" does not equal 7.
+func valuesEqual(expected, actual any) bool {
+	want, wantNumber := toNumber(expected)
+	got, gotNumber := toNumber(actual)
+	if wantNumber && gotNumber {
+		return want == got
+	}
+	_, wantString := expected.(string)
+	_, gotString := actual.(string)
+	if wantString || gotString {
+		return stringValue(expected) == stringValue(actual)
+	}
+	return reflect.DeepEqual(normalize(expected), normalize(actual))
+}
+
+// toNumber is toFloat without parsing strings.
+func toNumber(value any) (float64, bool) {
+	if _, ok := value.(string); ok {
+		return 0, false
+	}
+	return toFloat(value)
+}
+
+// normalize turns integer types into float64 so YAML and JSON numbers compare equal.
+func normalize(value any) any {
+	switch typed := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(typed))
+		for key, item := range typed {
+			out[key] = normalize(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(typed))
+		for i, item := range typed {
+			out[i] = normalize(item)
+		}
+		return out
+	default:
+		if f, ok := toFloat(value); ok {
+			if _, isString := value.(string); !isString {
+				return f
+			}
+		}
+		return value
+	}
+}
+
+func toFloat(value any) (float64, bool) {
+	switch typed := value.(type) {
+	case int:
+		return float64(typed), true
+	case int64:
+		return float64(typed), true
+	case float64:
+		return typed, true
+	case json.Number:
+		f, err := typed.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(typed), 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func stringValue(value any) string {
+	switch typed := value.(type) {
+	case string:
+		return typed
+	case nil:
+		return ""
+	default:
+		payload, err := json.Marshal(typed)
+		if err != nil {
+			return fmt.Sprint(typed)
+		}
+		return string(payload)
+	}
+}
+
+func formatValue(value any) string {
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(payload)
+}
+
+func invalidSpec(path string, spec any, message string) Result {
+	return Result{Path: path, Operator: "invalid", Expected: spec, Message: message}
+}
+
+func lookupHeader(headers map[string]string, name string) (string, bool) {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+func sortedMapKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
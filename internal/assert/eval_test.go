@@ -0,0 +1,175 @@
+package assert
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	doc := map[string]any{
+		"items": []any{
+			map[string]any{"id": "a", "tags": []any{"x"}},
+			map[string]any{"id": "b"},
+		},
+		"odd key": true,
+	}
+
+	cases := []struct {
+		path string
+		want any
+		ok   bool
+	}{
+		{"$.items[0].id", "a", true},
+		{"$.items[-1].id", "b", true},
+		{"$['odd key']", true, true},
+		{"$.items[5]", nil, false},
+		{"$.missing", nil, false},
+	}
+	for _, tc := range cases {
+		got, ok, err := Lookup(doc, tc.path)
+		if err != nil {
+			t.Fatalf("Lookup(%q) error: %v", tc.path, err)
+		}
+		if ok != tc.ok || got != tc.want {
+			t.Fatalf("Lookup(%q) = %v, %t; want %v, %t", tc.path, got, ok, tc.want, tc.ok)
+		}
+	}
+
+	ids, ok, err := Lookup(doc, "$.items[*].id")
+	if err != nil || !ok {
+		t.Fatalf("wildcard lookup failed: ok=%t err=%v", ok, err)
+	}
+	if list, _ := ids.([]any); len(list) != 2 || list[1] != "b" {
+		t.Fatalf("expected both ids, got %v", ids)
+	}
+
+	if _, _, err := Lookup(doc, "items"); err == nil {
+		t.Fatal("expected error for path without $")
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	subject := Subject{
+		Status:     201,
+		Headers:    map[string]string{"Content-Type": "application/json; charset=utf-8"},
+		Body:       []byte(`{"id":"u_1","count":3,"email":"a@example.com"}`),
+		DurationMS: 120,
+		Timing:     map[string]float64{"ttfb_ms": 80, "tls_ms": 0},
+	}
+	expect := map[string]any{
+		"status":  []any{200, 201},
+		"headers": map[string]any{"content-type": "application/json"},
+		"body": map[string]any{
+			"jsonpath": map[string]any{
+				"$.id":    map[string]any{"exists": true},
+				"$.count": map[string]any{"gte": 1, "lt": 10},
+				"$.email": map[string]any{"matches": "^[^@]+@[^@]+$"},
+			},
+		},
+		"max_duration_ms": 1000,
+		"timing":          map[string]any{"ttfb_ms": 100, "tls_ms": map[string]any{"lt": 5}},
+	}
+
+	results := Evaluate(expect, subject)
+	passed, failed := Counts(results)
+	if failed != 0 {
+		t.Fatalf("expected all assertions to pass, failures: %v", Failures(results))
+	}
+	if passed != 9 {
+		t.Fatalf("expected 9 results, got %d", passed)
+	}
+}
+
+func TestEvaluate_ReportsFailures(t *testing.T) {
+	subject := Subject{
+		Status: 500,
+		Body:   []byte(`{"count":0}`),
+		Timing: map[string]float64{"ttfb_ms": 450},
+	}
+	expect := map[string]any{
+		"status": 200,
+		"body": map[string]any{
+			"jsonpath": map[string]any{"$.count": map[string]any{"gt": 0}},
+		},
+		"timing":  map[string]any{"ttfb_ms": 300, "nope_ms": 1},
+		"surname": "x",
+	}
+
+	failures := Failures(Evaluate(expect, subject))
+	want := map[string]bool{
+		"status":                true,
+		"body.jsonpath.$.count": true,
+		"timing.ttfb_ms":        true,
+		"timing.nope_ms":        true,
+		"surname":               true,
+	}
+	if len(failures) != len(want) {
+		t.Fatalf("expected %d failures, got %v", len(want), failures)
+	}
+	for _, failure := range failures {
+		if !want[failure.Path] {
+			t.Fatalf("unexpected failure %v", failure)
+		}
+	}
+	if got := failures[len(failures)-1].String(); got == "" {
+		t.Fatal("expected failure to render")
+	}
+}
+
+func TestValuesEqual_ComparesStringsExactly(t *testing.T) {
+	for _, tc := range []struct {
+		expected, actual any
+		want             bool
+	}{
+		{1, 1.0, true},
+		{json.Number("3"), 3, true},
+		{"1", "1", true},
+		{"7", 7, true},
+		{"1.0", "1", false},
+		{"007", 7, false},
+		{7, "007", false},
+		{1.5, "1.50", false},
+		{[]any{1, "a"}, []any{1.0, "a"}, true},
+	} {
+		if got := valuesEqual(tc.expected, tc.actual); got != tc.want {
+			t.Fatalf("valuesEqual(%#v, %#v) = %v, want %v", tc.expected, tc.actual, got, tc.want)
+		}
+	}
+}
+
+func TestEvaluate_Protocol(t *testing.T) {
+	subject := Subject{Status: 200, Protocol: "HTTP/1.1"}
+
+	for _, expected := range []any{"1.1", "HTTP/1.1", map[string]any{"matches": "^HTTP/1"}} {
+		if failures := Failures(Evaluate(map[string]any{"protocol": expected}, subject)); len(failures) != 0 {
+			t.Fatalf("protocol %v: unexpected failures %v", expected, failures)
+		}
+	}
+
+	failures := Failures(Evaluate(map[string]any{"protocol": 2}, subject))
+	if len(failures) != 1 || failures[0].Expected != "HTTP/2.0" || failures[0].Actual != "HTTP/1.1" {
+		t.Fatalf("expected protocol mismatch failure, got %v", failures)
+	}
+}
+
+func TestEvaluate_GraphQLErrors(t *testing.T) {
+	body := []byte(`{"data":{"user":null},"errors":[{"message":"user not found","path":["user",0,"name"]},{"message":"rate limited"}]}`)
+
+	failures := Failures(Evaluate(nil, Subject{Status: 200, Body: body, GraphQL: true}))
+	if len(failures) != 2 {
+		t.Fatalf("expected one failure per GraphQL error by default, got %v", failures)
+	}
+	if failures[0].Path != "graphql.errors[0]" || failures[0].Actual != "user not found" || failures[0].Message != "at user[0].name" {
+		t.Fatalf("unexpected first failure %+v", failures[0])
+	}
+
+	results := Evaluate(map[string]any{"graphql": map[string]any{"no_errors": false}}, Subject{Body: body, GraphQL: true})
+	if len(results) != 0 {
+		t.Fatalf("expected no_errors: false to skip the check, got %v", results)
+	}
+
+	results = Evaluate(map[string]any{"graphql": map[string]any{"no_errors": true}}, Subject{Body: []byte(`{"data":{"ok":true}}`)})
+	if passed, failed := Counts(results); passed != 1 || failed != 0 {
+		t.Fatalf("expected passing no_errors check, got %v", results)
+	}
+}
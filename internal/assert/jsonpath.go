@@ -0,0 +1,138 @@
+package assert
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Lookup evaluates a JSONPath expression against a decoded JSON document.
+//
+// Supported syntax: $ (root), .key, ['key'] / ["key"], [index], negative
+// indexes, and the [*] / .* wildcards. A path containing a wildcard returns a
+// []any of every match; ok is false when nothing matched.
+func Lookup(doc any, path string) (any, bool, error) {
+	segments, err := parsePath(path)
+	if err != nil {
+		return nil, false, err
+	}
+
+	current := []any{doc}
+	wildcard := false
+	for _, seg := range segments {
+		var next []any
+		for _, node := range current {
+			next = append(next, seg.apply(node)...)
+		}
+		if seg.wildcard {
+			wildcard = true
+		}
+		current = next
+		if len(current) == 0 {
+			return nil, false, nil
+		}
+	}
+
+	if wildcard {
+		return current, true, nil
+	}
+	return current[0], true, nil
+}
+
+type pathSegment struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+func (s pathSegment) apply(node any) []any {
+	switch typed := node.(type) {
+	case map[string]any:
+		if s.wildcard {
+			out := make([]any, 0, len(typed))
+			for _, key := range sortedMapKeys(typed) {
+				out = append(out, typed[key])
+			}
+			return out
+		}
+		if s.isIndex {
+			return nil
+		}
+		value, ok := typed[s.key]
+		if !ok {
+			return nil
+		}
+		return []any{value}
+	case []any:
+		if s.wildcard {
+			return append([]any(nil), typed...)
+		}
+		if !s.isIndex {
+			return nil
+		}
+		idx := s.index
+		if idx < 0 {
+			idx += len(typed)
+		}
+		if idx < 0 || idx >= len(typed) {
+			return nil
+		}
+		return []any{typed[idx]}
+	default:
+		return nil
+	}
+}
+
+func parsePath(path string) ([]pathSegment, error) {
+	path = strings.TrimSpace(path)
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("jsonpath %q must start with $", path)
+	}
+
+	var segments []pathSegment
+	rest := path[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			key := rest[:end]
+			if key == "" {
+				return nil, fmt.Errorf("jsonpath %q: empty key", path)
+			}
+			if key == "*" {
+				segments = append(segments, pathSegment{wildcard: true})
+			} else {
+				segments = append(segments, pathSegment{key: key})
+			}
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("jsonpath %q: missing ]", path)
+			}
+			inner := strings.TrimSpace(rest[1:end])
+			rest = rest[end+1:]
+
+			switch {
+			case inner == "*":
+				segments = append(segments, pathSegment{wildcard: true})
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+				segments = append(segments, pathSegment{key: inner[1 : len(inner)-1]})
+			default:
+				idx, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("jsonpath %q: invalid index %q", path, inner)
+				}
+				segments = append(segments, pathSegment{index: idx, isIndex: true})
+			}
+		default:
+			return nil, fmt.Errorf("jsonpath %q: unexpected %q", path, rest[0])
+		}
+	}
+	return segments, nil
+}
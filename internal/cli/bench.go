@@ -0,0 +1,418 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jaykbpark/wirepad/internal/config"
+	"github.com/jaykbpark/wirepad/internal/history"
+	"github.com/jaykbpark/wirepad/internal/httpclient"
+	"github.com/jaykbpark/wirepad/internal/requestspec"
+)
+
+func runBench(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		printBenchUsage(stderr)
+		return 2
+	}
+	if wantsHelp(args) {
+		printBenchUsage(stdout)
+		return 0
+	}
+
+	opts, err := parseBenchOptions(args)
+	if err != nil {
+		fmt.Fprintf(stderr, "bench argument error: %v\n", err)
+		printBenchUsage(stderr)
+		return 2
+	}
+
+	requestPath, err := requestspec.ResolvePath(opts.Send.RequestRef)
+	if err != nil {
+		fmt.Fprintf(stderr, "resolve request: %v\n", err)
+		return 1
+	}
+
+	spec, _, err := loadRequest(requestPath, opts.Send, stderr)
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+	if spec.Kind != requestspec.KindHTTP {
+		fmt.Fprintf(stderr, "wirepad bench supports kind=http, got %q\n", spec.Kind)
+		return 1
+	}
+
+	transport, err := httpclient.NewTransport(spec.Request, filepath.Dir(requestPath))
+	if err != nil {
+		fmt.Fprintf(stderr, "build transport: %v\n", err)
+		return 1
+	}
+	transport.MaxIdleConnsPerHost = opts.Concurrency
+	defer transport.CloseIdleConnections()
+
+	// Every request shares one OAuth2 token, fetched before the load starts so
+	// token requests are not part of the measurement.
+	oauth := httpclient.NewOAuthSource(spec.Request, transport, oauthCachePath(opts.Send.EnvName))
+	if oauth != nil {
+		if _, err := oauth.Token(); err != nil {
+			fmt.Fprintf(stderr, "fetch oauth2 token: %v\n", config.RedactString(err.Error()))
+			return 1
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if opts.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Duration)
+		defer cancel()
+	}
+
+	if !opts.Send.JSONOutput {
+		fmt.Fprintf(stdout, "Bench %s  %s %s  (%s)\n", spec.Name, strings.ToUpper(spec.Request.Method), config.RedactString(spec.Request.URL), describeBenchLimits(opts))
+	}
+
+	started := time.Now()
+	samples := runBenchLoad(ctx, opts, func() benchSample {
+		// Latency runs until the body is read, not just until the headers.
+		sent := time.Now()
+		resp, err := httpclient.ExecuteHTTPWithOptions(spec, requestPath, httpclient.Options{
+			Transport: transport,
+			OAuth:     oauth,
+			// Bodies are read to count their size but not kept.
+			MaxBodyBytes: 1,
+		})
+		if err != nil {
+			return benchSample{err: config.RedactString(err.Error())}
+		}
+		return benchSample{status: resp.StatusCode, duration: time.Since(sent), bytes: resp.BodySize}
+	})
+	elapsed := time.Since(started)
+
+	record := summarizeBench(samples, elapsed)
+	record.RunID = history.NewRunID(started.UTC())
+	record.RequestName = spec.Name
+	record.RequestPath = requestPath
+	record.Env = opts.Send.EnvName
+	record.Method = strings.ToUpper(spec.Request.Method)
+	record.URL = config.RedactString(spec.Request.URL)
+	record.StartedAt = started.UTC().Format(time.RFC3339)
+	record.Concurrency = opts.Concurrency
+	record.Limit = opts.Requests
+	record.DurationLimitMS = opts.Duration.Milliseconds()
+	record.Rate = opts.Rate
+
+	historyPath, err := history.SaveBenchRun(record)
+	if err != nil {
+		fmt.Fprintf(stderr, "save bench history: %v\n", err)
+		return 1
+	}
+
+	if opts.Send.JSONOutput {
+		payload, err := json.MarshalIndent(map[string]any{
+			"bench":        record,
+			"history_path": historyPath,
+		}, "", "  ")
+		if err != nil {
+			fmt.Fprintf(stderr, "encode json output: %v\n", err)
+			return 1
+		}
+		fmt.Fprintln(stdout, string(payload))
+	} else {
+		printBenchHuman(stdout, record, historyPath)
+	}
+
+	if record.Requests == 0 || record.Errors == record.Requests {
+		return 1
+	}
+	return 0
+}
+
+func printBenchUsage(out io.Writer) {
+	writeSimpleUsage(out, "wirepad bench <request> [-c <concurrency>] [-n <requests>] [--duration <d>] [--rate <n>/s] [--env <name>] [--var key=value] [--proxy <url>] [--strict] [--json]")
+}
+
+type benchOptions struct {
+	Send        sendOptions
+	Concurrency int
+	// Requests and Duration bound the run; whichever is reached first ends
+	// it. Requests defaults to 100 when neither is set.
+	Requests int
+	Duration time.Duration
+	// Rate caps requests started per second across all workers; zero is
+	// unlimited.
+	Rate float64
+}
+
+func parseBenchOptions(args []string) (benchOptions, error) {
+	opts := benchOptions{Concurrency: 10}
+	var rest []string
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		switch {
+		case arg == "-c", strings.HasPrefix(arg, "-c="), arg == "--concurrency", strings.HasPrefix(arg, "--concurrency="):
+			value, next, err := flagValue(args, i, strings.SplitN(arg, "=", 2)[0])
+			if err != nil {
+				return opts, err
+			}
+			i = next
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return opts, fmt.Errorf("-c must be a positive integer")
+			}
+			opts.Concurrency = n
+		case arg == "-n", strings.HasPrefix(arg, "-n="), arg == "--requests", strings.HasPrefix(arg, "--requests="):
+			value, next, err := flagValue(args, i, strings.SplitN(arg, "=", 2)[0])
+			if err != nil {
+				return opts, err
+			}
+			i = next
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return opts, fmt.Errorf("-n must be a positive integer")
+			}
+			opts.Requests = n
+		case arg == "--duration", strings.HasPrefix(arg, "--duration="):
+			value, next, err := flagValue(args, i, "--duration")
+			if err != nil {
+				return opts, err
+			}
+			i = next
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				return opts, fmt.Errorf("--duration must be a positive duration such as 30s")
+			}
+			opts.Duration = d
+		case arg == "--rate", strings.HasPrefix(arg, "--rate="):
+			value, next, err := flagValue(args, i, "--rate")
+			if err != nil {
+				return opts, err
+			}
+			i = next
+			rate, err := parseRate(value)
+			if err != nil {
+				return opts, err
+			}
+			opts.Rate = rate
+		case arg == "--timing", arg == "--stream", arg == "--cookies",
+			arg == "--output", strings.HasPrefix(arg, "--output="),
+			arg == "--data", strings.HasPrefix(arg, "--data="),
+			arg == "--max-events", strings.HasPrefix(arg, "--max-events="),
+			arg == "--until", strings.HasPrefix(arg, "--until="):
+			return opts, fmt.Errorf("%s is not supported by bench", strings.SplitN(arg, "=", 2)[0])
+		default:
+			rest = append(rest, arg)
+		}
+	}
+
+	send, err := parseSendOptions(rest)
+	if err != nil {
+		return opts, err
+	}
+	opts.Send = send
+	if opts.Requests == 0 && opts.Duration == 0 {
+		opts.Requests = 100
+	}
+	return opts, nil
+}
+
+// maxBenchRate is the fastest --rate accepted, one start per microsecond.
+const maxBenchRate = 1_000_000
+
+// parseRate reads "100", "100/s" or "600/m" as requests per second.
+func parseRate(value string) (float64, error) {
+	count, unit, _ := strings.Cut(value, "/")
+	per := time.Second
+	switch unit {
+	case "", "s":
+	case "m":
+		per = time.Minute
+	default:
+		return 0, fmt.Errorf("--rate unit must be /s or /m, got %q", value)
+	}
+	n, err := strconv.ParseFloat(count, 64)
+	if err != nil || n <= 0 || math.IsInf(n, 0) {
+		return 0, fmt.Errorf("--rate must be a positive number such as 100/s")
+	}
+	if rate := n / per.Seconds(); rate <= maxBenchRate {
+		return rate, nil
+	}
+	return 0, fmt.Errorf("--rate must be at most %d/s, got %q", maxBenchRate, value)
+}
+
+func describeBenchLimits(opts benchOptions) string {
+	parts := []string{fmt.Sprintf("c=%d", opts.Concurrency)}
+	if opts.Requests > 0 {
+		parts = append(parts, fmt.Sprintf("n=%d", opts.Requests))
+	}
+	if opts.Duration > 0 {
+		parts = append(parts, "duration="+opts.Duration.String())
+	}
+	if opts.Rate > 0 {
+		parts = append(parts, fmt.Sprintf("rate=%g/s", opts.Rate))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// benchSample is one request; err is set when no response arrived.
+type benchSample struct {
+	status   int
+	duration time.Duration
+	bytes    int64
+	err      string
+}
+
+// runBenchLoad calls send from opts.Concurrency workers until opts.Requests
+// have started or ctx is done, pacing starts to opts.Rate when set. Requests
+// in flight when ctx ends are allowed to finish.
+func runBenchLoad(ctx context.Context, opts benchOptions, send func() benchSample) []benchSample {
+	jobs := make(chan struct{})
+	go func() {
+		defer close(jobs)
+		var tick <-chan time.Time
+		if opts.Rate > 0 {
+			ticker := time.NewTicker(time.Duration(float64(time.Second) / opts.Rate))
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+		for i := 0; opts.Requests == 0 || i < opts.Requests; i++ {
+			// The first request starts at once; later ones wait for a tick.
+			if tick != nil && i > 0 {
+				select {
+				case <-tick:
+				case <-ctx.Done():
+					return
+				}
+			}
+			select {
+			case jobs <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	workers := opts.Concurrency
+	if opts.Requests > 0 {
+		workers = min(workers, opts.Requests)
+	}
+	results := make([][]benchSample, workers)
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range jobs {
+				results[w] = append(results[w], send())
+			}
+		}()
+	}
+	wg.Wait()
+	return slices.Concat(results...)
+}
+
+func summarizeBench(samples []benchSample, elapsed time.Duration) history.BenchRecord {
+	record := history.BenchRecord{
+		DurationMS: elapsed.Milliseconds(),
+		Requests:   len(samples),
+		Statuses:   make(map[int]int),
+	}
+
+	var durations []time.Duration
+	var total time.Duration
+	for _, sample := range samples {
+		if sample.err != "" {
+			record.Errors++
+			if record.ErrorCounts == nil {
+				record.ErrorCounts = make(map[string]int)
+			}
+			record.ErrorCounts[sample.err]++
+			continue
+		}
+		record.Statuses[sample.status]++
+		record.BytesReceived += sample.bytes
+		durations = append(durations, sample.duration)
+		total += sample.duration
+	}
+	if elapsed > 0 {
+		record.ThroughputRPS = round2(float64(len(samples)) / elapsed.Seconds())
+	}
+	if len(durations) == 0 {
+		return record
+	}
+
+	slices.Sort(durations)
+	record.Latency = history.Latency{
+		MinMS:  millis(durations[0]),
+		MeanMS: millis(total / time.Duration(len(durations))),
+		P50MS:  millis(percentile(durations, 50)),
+		P90MS:  millis(percentile(durations, 90)),
+		P99MS:  millis(percentile(durations, 99)),
+		MaxMS:  millis(durations[len(durations)-1]),
+	}
+	return record
+}
+
+// percentile uses the nearest-rank method on sorted durations.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
+
+func millis(d time.Duration) float64 {
+	return round2(float64(d) / float64(time.Millisecond))
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+func printBenchHuman(out io.Writer, record history.BenchRecord, historyPath string) {
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "Requests:    %d (%d responses, %d errors)\n", record.Requests, record.Requests-record.Errors, record.Errors)
+	fmt.Fprintf(out, "Duration:    %dms\n", record.DurationMS)
+	fmt.Fprintf(out, "Throughput:  %.2f req/s\n", record.ThroughputRPS)
+	fmt.Fprintf(out, "Received:    %d bytes\n", record.BytesReceived)
+	if record.Requests > record.Errors {
+		l := record.Latency
+		fmt.Fprintf(out, "Latency:     min %.2fms  p50 %.2fms  p90 %.2fms  p99 %.2fms  max %.2fms  mean %.2fms\n", l.MinMS, l.P50MS, l.P90MS, l.P99MS, l.MaxMS, l.MeanMS)
+	}
+
+	if len(record.Statuses) > 0 {
+		fmt.Fprintln(out, "Status codes:")
+		for _, code := range slices.Sorted(maps.Keys(record.Statuses)) {
+			fmt.Fprintf(out, "  %d %-22s %d\n", code, http.StatusText(code), record.Statuses[code])
+		}
+	}
+	if len(record.ErrorCounts) > 0 {
+		fmt.Fprintln(out, "Errors:")
+		messages := slices.Collect(maps.Keys(record.ErrorCounts))
+		slices.SortFunc(messages, func(a, b string) int {
+			if n := record.ErrorCounts[b] - record.ErrorCounts[a]; n != 0 {
+				return n
+			}
+			return strings.Compare(a, b)
+		})
+		for _, message := range messages {
+			fmt.Fprintf(out, "  %d  %s\n", record.ErrorCounts[message], message)
+		}
+	}
+	fmt.Fprintf(out, "Run ID: %s\n", record.RunID)
+	fmt.Fprintf(out, "History: %s\n", historyPath)
+}
@@ -0,0 +1,238 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jaykbpark/wirepad/internal/history"
+)
+
+func TestExecute_BenchAggregatesRun(t *testing.T) {
+	withTempWorkingDir(t, func(root string) {
+		var count atomic.Int64
+		var mu sync.Mutex
+		conns := make(map[string]bool)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			conns[r.RemoteAddr] = true
+			mu.Unlock()
+			if count.Add(1)%10 == 0 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = w.Write([]byte(`{"ok":true}`))
+		}))
+		defer server.Close()
+
+		writeFile(t, filepath.Join(root, "env", "dev.env"), "base_url="+server.URL+"\n")
+		writeFile(t, filepath.Join(root, "requests", "users", "get.req.yaml"), `
+version: 1
+kind: http
+name: users.get
+request:
+  method: GET
+  url: "{{base_url}}/users/1"
+`)
+
+		var out bytes.Buffer
+		var errOut bytes.Buffer
+		code := Execute([]string{"bench", "users/get", "--env", "dev", "-c", "5", "-n", "50"}, &out, &errOut)
+		if code != 0 {
+			t.Fatalf("expected exit code 0, got %d; stdout=%q stderr=%q", code, out.String(), errOut.String())
+		}
+		stdout := out.String()
+		for _, want := range []string{
+			"Bench users.get  GET " + server.URL + "/users/1  (c=5, n=50)",
+			"Requests:    50 (50 responses, 0 errors)",
+			"Received:    495 bytes",
+			"  200 OK                     45",
+			"  503 Service Unavailable    5",
+			"Latency:     min ",
+		} {
+			if !strings.Contains(stdout, want) {
+				t.Fatalf("expected %q in stdout, got %q", want, stdout)
+			}
+		}
+		if len(conns) > 5 {
+			t.Fatalf("expected pooled connections (at most 5), got %d", len(conns))
+		}
+
+		if _, err := os.Stat(filepath.Join(root, ".wirepad", "history", "runs")); !os.IsNotExist(err) {
+			t.Fatalf("expected no per-request run records, got err=%v", err)
+		}
+		entries, err := os.ReadDir(filepath.Join(root, ".wirepad", "history", "bench"))
+		if err != nil || len(entries) != 1 {
+			t.Fatalf("expected one bench record, got %d (err=%v)", len(entries), err)
+		}
+		data, err := os.ReadFile(filepath.Join(root, ".wirepad", "history", "bench", entries[0].Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var record history.BenchRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			t.Fatalf("decode bench record: %v", err)
+		}
+		if record.Requests != 50 || record.Statuses[200] != 45 || record.Statuses[503] != 5 || record.Latency.P99MS < record.Latency.P50MS {
+			t.Fatalf("unexpected bench record %+v", record)
+		}
+	})
+}
+
+func TestExecute_BenchDurationAndErrors(t *testing.T) {
+	withTempWorkingDir(t, func(root string) {
+		writeFile(t, filepath.Join(root, "requests", "down.req.yaml"), `
+version: 1
+kind: http
+name: down
+request:
+  method: GET
+  url: "http://127.0.0.1:1/down"
+`)
+
+		var out bytes.Buffer
+		var errOut bytes.Buffer
+		started := time.Now()
+		code := Execute([]string{"bench", "down", "--duration", "200ms", "--rate", "50/s", "-c", "2", "--json"}, &out, &errOut)
+		if code != 1 {
+			t.Fatalf("expected exit code 1 when every request errors, got %d; stderr=%q", code, errOut.String())
+		}
+		if elapsed := time.Since(started); elapsed > 5*time.Second {
+			t.Fatalf("expected the run to stop after --duration, took %s", elapsed)
+		}
+		var payload struct {
+			Bench history.BenchRecord `json:"bench"`
+		}
+		if err := json.Unmarshal(out.Bytes(), &payload); err != nil {
+			t.Fatalf("decode json output %q: %v", out.String(), err)
+		}
+		record := payload.Bench
+		if record.Requests == 0 || record.Requests > 15 || record.Errors != record.Requests || len(record.ErrorCounts) != 1 {
+			t.Fatalf("unexpected bench record %+v", record)
+		}
+	})
+}
+
+func TestExecute_BenchSharesOneOAuthToken(t *testing.T) {
+	withTempWorkingDir(t, func(root string) {
+		var tokenCalls atomic.Int64
+		tokens := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tokenCalls.Add(1)
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"access_token":"bench-token","token_type":"Bearer","expires_in":3600}`))
+		}))
+		defer tokens.Close()
+		api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer bench-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+		}))
+		defer api.Close()
+
+		writeFile(t, filepath.Join(root, "requests", "orders.req.yaml"), `
+version: 1
+kind: http
+name: orders
+request:
+  method: GET
+  url: "`+api.URL+`/orders"
+  auth:
+    type: oauth2
+    grant: client_credentials
+    token_url: "`+tokens.URL+`"
+    client_id: app
+    client_secret: shh
+`)
+
+		var out bytes.Buffer
+		var errOut bytes.Buffer
+		code := Execute([]string{"bench", "orders", "-c", "8", "-n", "40"}, &out, &errOut)
+		if code != 0 {
+			t.Fatalf("expected exit code 0, got %d; stdout=%q stderr=%q", code, out.String(), errOut.String())
+		}
+		if !strings.Contains(out.String(), "  200 OK                     40") {
+			t.Fatalf("expected every request to be authorized, got %q", out.String())
+		}
+		if tokenCalls.Load() != 1 {
+			t.Fatalf("expected one token request for the whole run, got %d", tokenCalls.Load())
+		}
+	})
+}
+
+func TestPercentileAndRate(t *testing.T) {
+	sorted := make([]time.Duration, 100)
+	for i := range sorted {
+		sorted[i] = time.Duration(i+1) * time.Millisecond
+	}
+	if got := percentile(sorted, 50); got != 50*time.Millisecond {
+		t.Fatalf("p50 = %s", got)
+	}
+	if got := percentile(sorted, 99); got != 99*time.Millisecond {
+		t.Fatalf("p99 = %s", got)
+	}
+	if got := percentile(sorted[:1], 90); got != time.Millisecond {
+		t.Fatalf("p90 of one sample = %s", got)
+	}
+
+	for value, want := range map[string]float64{"100": 100, "100/s": 100, "600/m": 10, "1000000/s": 1e6, "60000000/m": 1e6} {
+		got, err := parseRate(value)
+		if err != nil || got != want {
+			t.Fatalf("parseRate(%q) = %v, %v; want %v", value, got, err, want)
+		}
+	}
+	for value, want := range map[string]string{
+		"10/h":      "unit must be /s or /m",
+		"0":         "positive number",
+		"-5/s":      "positive number",
+		"1000001/s": "at most 1000000/s",
+		"2e9":       "at most 1000000/s",
+	} {
+		if _, err := parseRate(value); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("parseRate(%q) error = %v, want %q", value, err, want)
+		}
+	}
+}
+
+func TestExecute_BenchLatencyIncludesBody(t *testing.T) {
+	withTempWorkingDir(t, func(root string) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			time.Sleep(50 * time.Millisecond)
+			_, _ = w.Write([]byte("done"))
+		}))
+		defer server.Close()
+
+		writeFile(t, filepath.Join(root, "requests", "slow.req.yaml"), `
+version: 1
+kind: http
+name: slow
+request:
+  method: GET
+  url: "`+server.URL+`"
+`)
+
+		var out bytes.Buffer
+		var errOut bytes.Buffer
+		if code := Execute([]string{"bench", "slow", "-c", "1", "-n", "2", "--json"}, &out, &errOut); code != 0 {
+			t.Fatalf("expected exit code 0, got %d; stdout=%q stderr=%q", code, out.String(), errOut.String())
+		}
+		var result struct {
+			Bench history.BenchRecord `json:"bench"`
+		}
+		if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+			t.Fatalf("decode bench output %q: %v", out.String(), err)
+		}
+		if result.Bench.Latency.MinMS < 50 {
+			t.Fatalf("expected latency to include the 50ms body, got %+v", result.Bench.Latency)
+		}
+	})
+}
@@ -0,0 +1,180 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jaykbpark/wirepad/internal/config"
+	"github.com/jaykbpark/wirepad/internal/cookies"
+)
+
+func runCookies(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		printCookiesUsage(stderr)
+		return 2
+	}
+	if wantsHelp(args) {
+		printCookiesUsage(stdout)
+		return 0
+	}
+
+	switch args[0] {
+	case "help":
+		printCookiesUsage(stdout)
+		return 0
+	case "list":
+		return runCookiesList(args[1:], stdout, stderr)
+	case "clear":
+		return runCookiesClear(args[1:], stdout, stderr)
+	default:
+		fmt.Fprintf(stderr, "unknown cookies subcommand %q\n\n", args[0])
+		printCookiesUsage(stderr)
+		return 2
+	}
+}
+
+func printCookiesUsage(out io.Writer) {
+	fmt.Fprintln(out, "Usage:")
+	fmt.Fprintln(out, "  wirepad cookies <subcommand> [--env <name>]")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Subcommands:")
+	fmt.Fprintln(out, "  list   List stored cookies (values redacted unless --show-values; --json for JSON)")
+	fmt.Fprintln(out, "  clear  Remove stored cookies, optionally only those for --domain <domain>")
+}
+
+type cookiesOptions struct {
+	EnvName    string
+	Domain     string
+	ShowValues bool
+	JSONOutput bool
+}
+
+func parseCookiesOptions(args []string) (cookiesOptions, error) {
+	var opts cookiesOptions
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		switch {
+		case arg == "--env", strings.HasPrefix(arg, "--env="):
+			value, next, err := flagValue(args, i, "--env")
+			if err != nil {
+				return opts, err
+			}
+			i = next
+			opts.EnvName = value
+		case arg == "--domain", strings.HasPrefix(arg, "--domain="):
+			value, next, err := flagValue(args, i, "--domain")
+			if err != nil {
+				return opts, err
+			}
+			i = next
+			opts.Domain = value
+		case arg == "--show-values":
+			opts.ShowValues = true
+		case arg == "--json":
+			opts.JSONOutput = true
+		default:
+			return opts, fmt.Errorf("unexpected argument %q", arg)
+		}
+	}
+	return opts, nil
+}
+
+func runCookiesList(args []string, stdout io.Writer, stderr io.Writer) int {
+	opts, err := parseCookiesOptions(args)
+	if err != nil {
+		fmt.Fprintf(stderr, "cookies list argument error: %v\n", err)
+		return 2
+	}
+
+	jar, err := cookies.Load(cookies.Path(opts.EnvName))
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+
+	entries := jar.Entries()
+	if !opts.ShowValues {
+		for i := range entries {
+			entries[i].Value = config.Redacted
+		}
+	}
+
+	if opts.JSONOutput {
+		payload, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return 1
+		}
+		fmt.Fprintln(stdout, string(payload))
+		return 0
+	}
+
+	if len(entries) == 0 {
+		fmt.Fprintf(stdout, "No cookies stored in %s\n", cookies.Path(opts.EnvName))
+		return 0
+	}
+
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DOMAIN\tPATH\tNAME\tVALUE\tEXPIRES\tFLAGS")
+	for _, entry := range entries {
+		expires := "session"
+		if !entry.Expires.IsZero() {
+			expires = entry.Expires.Local().Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", cookieDomain(entry), entry.Path, entry.Name, entry.Value, expires, cookieFlags(entry))
+	}
+	_ = w.Flush()
+	return 0
+}
+
+func runCookiesClear(args []string, stdout io.Writer, stderr io.Writer) int {
+	opts, err := parseCookiesOptions(args)
+	if err != nil {
+		fmt.Fprintf(stderr, "cookies clear argument error: %v\n", err)
+		return 2
+	}
+
+	path := cookies.Path(opts.EnvName)
+	jar, err := cookies.Load(path)
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+
+	removed := jar.Clear(opts.Domain)
+	if err := jar.Save(); err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+	fmt.Fprintf(stdout, "Removed %d cookie(s) from %s\n", removed, path)
+	return 0
+}
+
+// cookieDomain shows domain cookies with the conventional leading dot.
+func cookieDomain(entry cookies.Entry) string {
+	if entry.HostOnly {
+		return entry.Domain
+	}
+	return "." + entry.Domain
+}
+
+func cookieFlags(entry cookies.Entry) string {
+	var flags []string
+	if entry.Secure {
+		flags = append(flags, "Secure")
+	}
+	if entry.HTTPOnly {
+		flags = append(flags, "HttpOnly")
+	}
+	if entry.SameSite != "" {
+		flags = append(flags, "SameSite="+entry.SameSite)
+	}
+	if len(flags) == 0 {
+		return "-"
+	}
+	return strings.Join(flags, ",")
+}
@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExecute_SendCookiesPersistAcrossRuns(t *testing.T) {
+	withTempWorkingDir(t, func(root string) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/login" {
+				http.SetCookie(w, &http.Cookie{Name: "session", Value: "s3ss10n", Path: "/", HttpOnly: true})
+				return
+			}
+			if cookie, err := r.Cookie("session"); err != nil || cookie.Value != "s3ss10n" {
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+		}))
+		defer server.Close()
+
+		writeFile(t, filepath.Join(root, "requests", "login.req.yaml"), `
+version: 1
+kind: http
+name: login
+request:
+  method: POST
+  url: "`+server.URL+`/login"
+`)
+		writeFile(t, filepath.Join(root, "requests", "me.req.yaml"), `
+version: 1
+kind: http
+name: me
+request:
+  method: GET
+  url: "`+server.URL+`/me"
+  cookies: true
+expect:
+  status: 200
+`)
+
+		var out, errOut bytes.Buffer
+		if code := Execute([]string{"send", "login", "--env", "dev", "--cookies"}, &out, &errOut); code != 0 {
+			t.Fatalf("login: exit %d; stderr=%q", code, errOut.String())
+		}
+		if !strings.Contains(out.String(), "Cookies: 0 sent, 1 received") {
+			t.Fatalf("expected cookie summary, got %q", out.String())
+		}
+
+		out.Reset()
+		if code := Execute([]string{"send", "me", "--env", "dev", "--json"}, &out, &errOut); code != 0 {
+			t.Fatalf("me: exit %d; stdout=%q stderr=%q", code, out.String(), errOut.String())
+		}
+		var envelope map[string]any
+		if err := json.Unmarshal(out.Bytes(), &envelope); err != nil {
+			t.Fatalf("decode json output: %v", err)
+		}
+		record, err := os.ReadFile(asString(envelope["history_path"]))
+		if err != nil {
+			t.Fatalf("read run history: %v", err)
+		}
+		if !strings.Contains(string(record), `"sent": [`) || strings.Contains(string(record), "s3ss10n") {
+			t.Fatalf("expected redacted sent cookie in history, got %s", record)
+		}
+
+		out.Reset()
+		if code := Execute([]string{"cookies", "list", "--env", "dev"}, &out, &errOut); code != 0 {
+			t.Fatalf("cookies list: exit %d; stderr=%q", code, errOut.String())
+		}
+		if !strings.Contains(out.String(), "session") || strings.Contains(out.String(), "s3ss10n") {
+			t.Fatalf("expected redacted session cookie in list, got %q", out.String())
+		}
+
+		out.Reset()
+		if code := Execute([]string{"cookies", "clear", "--env", "dev"}, &out, &errOut); code != 0 {
+			t.Fatalf("cookies clear: exit %d; stderr=%q", code, errOut.String())
+		}
+		if code := Execute([]string{"send", "me", "--env", "dev"}, &out, &errOut); code != 1 {
+			t.Fatalf("expected 401 assertion failure after clearing cookies, got exit %d", code)
+		}
+	})
+}
@@ -0,0 +1,163 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jaykbpark/wirepad/internal/assert"
+	"github.com/jaykbpark/wirepad/internal/config"
+	"github.com/jaykbpark/wirepad/internal/history"
+	"github.com/jaykbpark/wirepad/internal/requestspec"
+)
+
+// resolveDataPath returns the dataset for a send: --data as given, else the
+// spec's data: relative to the request file, else "".
+func resolveDataPath(requestPath, flagPath string) (string, error) {
+	if flagPath != "" {
+		return flagPath, nil
+	}
+	data, err := os.ReadFile(requestPath)
+	if err != nil {
+		return "", fmt.Errorf("read request file: %w", err)
+	}
+	spec, _, err := requestspec.Parse(data)
+	if err != nil {
+		// Parse errors are reported by the full load in executeRequest.
+		return "", nil
+	}
+	return specDataPath(requestPath, spec), nil
+}
+
+// specDataPath returns the spec's data: relative to the request file, or "".
+func specDataPath(requestPath string, spec *requestspec.Spec) string {
+	if spec.Data == "" || filepath.IsAbs(spec.Data) {
+		return spec.Data
+	}
+	return filepath.Join(filepath.Dir(requestPath), spec.Data)
+}
+
+// dataRowOptions returns opts for sending one dataset row. Row columns join
+// the --var layer, with explicit --var values taking precedence, so a cell
+// holding ${secret:...} is sent as written and never run.
+func dataRowOptions(opts sendOptions, row map[string]string, index int) sendOptions {
+	rowOpts := opts
+	rowOpts.Vars = maps.Clone(row)
+	maps.Copy(rowOpts.Vars, opts.Vars)
+	rowOpts.DataRow = &index
+	return rowOpts
+}
+
+// dataRowRun is the outcome of one dataset row.
+type dataRowRun struct {
+	Row         int                        `json:"row"`
+	OK          bool                       `json:"ok"`
+	Status      int                        `json:"status,omitempty"`
+	DurationMS  int64                      `json:"duration_ms"`
+	RunID       string                     `json:"run_id,omitempty"`
+	HistoryPath string                     `json:"history_path,omitempty"`
+	Failures    []history.AssertionFailure `json:"failures,omitempty"`
+	Error       string                     `json:"error,omitempty"`
+}
+
+// runSendData sends the request once per dataset row and saves every row as
+// its own run record.
+func runSendData(requestPath, dataPath string, opts sendOptions, stdout io.Writer, stderr io.Writer) int {
+	if opts.Stream || opts.OutputPath != "" {
+		fmt.Fprintln(stderr, "send argument error: --stream and --output cannot be used with a dataset")
+		return 2
+	}
+
+	rows, err := config.LoadDataRows(dataPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "load data: %v\n", err)
+		return 1
+	}
+
+	if !opts.JSONOutput {
+		fmt.Fprintf(stdout, "Data: %s (%d rows)\n", dataPath, len(rows))
+	}
+
+	started := time.Now()
+	var results []dataRowRun
+	for i, row := range rows {
+		entry := dataRowRun{Row: i}
+		run, err := executeRequest(requestPath, dataRowOptions(opts, row, i), io.Discard, stderr)
+		if err != nil {
+			var validationErr *requestspec.ValidationError
+			if errors.As(err, &validationErr) {
+				fmt.Fprintln(stderr, validationErr.Error())
+				return 1
+			}
+			entry.Error = config.RedactString(err.Error())
+			results = append(results, entry)
+			if !opts.JSONOutput {
+				fmt.Fprintf(stdout, "  FAIL  [%d]: %s\n", i, entry.Error)
+			}
+			continue
+		}
+
+		entry.OK = run.record.OK
+		entry.Status = run.record.Status
+		entry.DurationMS = run.record.DurationMS
+		entry.RunID = run.record.RunID
+		entry.HistoryPath = run.historyPath
+		if run.record.Assertions != nil {
+			entry.Failures = run.record.Assertions.Failures
+		}
+		results = append(results, entry)
+		if !opts.JSONOutput {
+			status := "ok  "
+			if !entry.OK {
+				status = "FAIL"
+			}
+			fmt.Fprintf(stdout, "  %s  [%d]  %s (%dms)\n", status, i, describeRun(run), entry.DurationMS)
+			for _, result := range assert.Failures(run.results) {
+				fmt.Fprintf(stdout, "          %s\n", config.RedactString(result.String()))
+			}
+		}
+	}
+
+	var failedRows []string
+	for _, entry := range results {
+		if !entry.OK {
+			failedRows = append(failedRows, strconv.Itoa(entry.Row))
+		}
+	}
+	passed := len(results) - len(failedRows)
+
+	if opts.JSONOutput {
+		payload, err := json.MarshalIndent(map[string]any{
+			"ok":          len(failedRows) == 0,
+			"data":        dataPath,
+			"passed":      passed,
+			"failed":      len(failedRows),
+			"duration_ms": time.Since(started).Milliseconds(),
+			"rows":        results,
+		}, "", "  ")
+		if err != nil {
+			fmt.Fprintf(stderr, "encode json output: %v\n", err)
+			return 1
+		}
+		fmt.Fprintln(stdout, string(payload))
+	} else {
+		fmt.Fprintln(stdout)
+		fmt.Fprintf(stdout, "Rows: %d passed, %d failed\n", passed, len(failedRows))
+		if len(failedRows) > 0 {
+			fmt.Fprintf(stdout, "Failed rows: %s\n", strings.Join(failedRows, ", "))
+		}
+		fmt.Fprintf(stdout, "Duration: %dms\n", time.Since(started).Milliseconds())
+	}
+
+	if len(failedRows) > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,244 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExecute_SendIteratesDataRows(t *testing.T) {
+	withTempWorkingDir(t, func(root string) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var payload map[string]any
+			_ = json.NewDecoder(r.Body).Decode(&payload)
+			if payload["email"] == "" {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = io.WriteString(w, `{"name":"`+payload["name"].(string)+`","region":"`+r.URL.Query().Get("region")+`"}`)
+		}))
+		defer server.Close()
+
+		writeFile(t, filepath.Join(root, "requests", "users", "create.req.yaml"), `
+version: 1
+kind: http
+name: users.create
+request:
+  method: POST
+  url: "`+server.URL+`/users?region={{region}}"
+  body:
+    mode: json
+    json:
+      name: "{{name}}"
+      email: "{{email}}"
+expect:
+  status: 201
+  body:
+    jsonpath:
+      "$.region": eu
+`)
+		writeFile(t, filepath.Join(root, "cases.csv"), "name,email,region\nAda,ada@example.com,us\nBob,,us\nCy,cy@example.com,us\n")
+
+		var out bytes.Buffer
+		var errOut bytes.Buffer
+		code := Execute([]string{"send", "users/create", "--data", "cases.csv", "--var", "region=eu"}, &out, &errOut)
+		if code != 1 {
+			t.Fatalf("expected exit code 1, got %d; stdout=%q stderr=%q", code, out.String(), errOut.String())
+		}
+		stdout := out.String()
+		for _, want := range []string{
+			"Data: cases.csv (3 rows)",
+			"  ok    [0]  POST 201 Created",
+			"  FAIL  [1]  POST 422 Unprocessable Entity",
+			"FAIL status equals: expected 201, got 422",
+			"  ok    [2]  POST 201 Created",
+			"Rows: 2 passed, 1 failed",
+			"Failed rows: 1",
+		} {
+			if !strings.Contains(stdout, want) {
+				t.Fatalf("expected %q in stdout, got %q", want, stdout)
+			}
+		}
+
+		entries, err := os.ReadDir(filepath.Join(root, ".wirepad", "history", "runs"))
+		if err != nil || len(entries) != 3 {
+			t.Fatalf("expected 3 run records, got %d (err=%v)", len(entries), err)
+		}
+		rows := make(map[int]bool)
+		for _, entry := range entries {
+			data, err := os.ReadFile(filepath.Join(root, ".wirepad", "history", "runs", entry.Name()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var record struct {
+				DataRow *int `json:"data_row"`
+			}
+			if err := json.Unmarshal(data, &record); err != nil || record.DataRow == nil {
+				t.Fatalf("expected data_row in %s, got %s", entry.Name(), data)
+			}
+			rows[*record.DataRow] = true
+		}
+		if !rows[0] || !rows[1] || !rows[2] {
+			t.Fatalf("expected rows 0-2 in history, got %v", rows)
+		}
+	})
+}
+
+func TestExecute_SendSendsSecretRefsInRowsAsText(t *testing.T) {
+	withTempWorkingDir(t, func(root string) {
+		marker := filepath.Join(root, "ran")
+		hostile := "${secret:cmd:touch " + marker + "}"
+		var got string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = r.Header.Get("X-Token")
+		}))
+		defer server.Close()
+
+		writeFile(t, filepath.Join(root, "requests", "ping.req.yaml"), `
+version: 1
+kind: http
+name: ping
+request:
+  method: GET
+  url: "`+server.URL+`"
+  headers:
+    X-Token: "{{token}}"
+`)
+		payload, _ := json.Marshal([]map[string]string{{"token": hostile}})
+		writeFile(t, filepath.Join(root, "rows.json"), string(payload))
+
+		var out bytes.Buffer
+		var errOut bytes.Buffer
+		if code := Execute([]string{"send", "ping", "--data", "rows.json"}, &out, &errOut); code != 0 {
+			t.Fatalf("expected exit code 0, got %d; stdout=%q stderr=%q", code, out.String(), errOut.String())
+		}
+		if got != hostile {
+			t.Fatalf("expected the row's reference to be sent as text, got %q", got)
+		}
+		if _, err := os.Stat(marker); !os.IsNotExist(err) {
+			t.Fatalf("a secret reference in a data row ran a command (stat: %v)", err)
+		}
+	})
+}
+
+func TestExecute_SendUsesSpecDataFile(t *testing.T) {
+	withTempWorkingDir(t, func(root string) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		writeFile(t, filepath.Join(root, "requests", "users", "get.req.yaml"), `
+version: 1
+kind: http
+name: users.get
+data: get.cases.json
+request:
+  method: GET
+  url: "`+server.URL+`/users/{{id}}"
+`)
+		writeFile(t, filepath.Join(root, "requests", "users", "get.cases.json"), `[{"id": 1}, {"id": 2}]`)
+
+		var out bytes.Buffer
+		var errOut bytes.Buffer
+		code := Execute([]string{"send", "users/get", "--json"}, &out, &errOut)
+		if code != 0 {
+			t.Fatalf("expected exit code 0, got %d; stderr=%q", code, errOut.String())
+		}
+		var payload struct {
+			OK     bool `json:"ok"`
+			Passed int  `json:"passed"`
+			Rows   []struct {
+				Row    int `json:"row"`
+				Status int `json:"status"`
+			} `json:"rows"`
+		}
+		if err := json.Unmarshal(out.Bytes(), &payload); err != nil {
+			t.Fatalf("decode json output %q: %v", out.String(), err)
+		}
+		if !payload.OK || payload.Passed != 2 || len(payload.Rows) != 2 || payload.Rows[1].Row != 1 || payload.Rows[1].Status != 200 {
+			t.Fatalf("unexpected payload %+v", payload)
+		}
+	})
+}
+
+func TestExecute_TestExpandsDataRows(t *testing.T) {
+	withTempWorkingDir(t, func(root string) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/users/404" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		writeFile(t, filepath.Join(root, "requests", "users", "get.req.yaml"), `
+version: 1
+kind: http
+name: users.get
+data: get.cases.csv
+request:
+  method: GET
+  url: "`+server.URL+`/users/{{id}}"
+expect:
+  status: 200
+`)
+		writeFile(t, filepath.Join(root, "requests", "users", "get.cases.csv"), "id\n1\n404\n3\n")
+
+		var out bytes.Buffer
+		var errOut bytes.Buffer
+		code := Execute([]string{"test"}, &out, &errOut)
+		if code != 1 {
+			t.Fatalf("expected exit code 1, got %d; stdout=%q stderr=%q", code, out.String(), errOut.String())
+		}
+		stdout := out.String()
+		for _, want := range []string{
+			"  ok    users.get[0]  GET 200 OK",
+			"  FAIL  users.get[1]  GET 404 Not Found",
+			"  ok    users.get[2]  GET 200 OK",
+			"Tests: 2 passed, 1 failed, 0 skipped",
+		} {
+			if !strings.Contains(stdout, want) {
+				t.Fatalf("expected %q in stdout, got %q", want, stdout)
+			}
+		}
+	})
+}
+
+func TestExecute_RunRejectsDataDrivenSteps(t *testing.T) {
+	withTempWorkingDir(t, func(root string) {
+		writeFile(t, filepath.Join(root, "requests", "users", "get.req.yaml"), `
+version: 1
+kind: http
+name: users.get
+data: get.cases.json
+request:
+  method: GET
+  url: "http://127.0.0.1:1/users/{{id}}"
+`)
+		writeFile(t, filepath.Join(root, "flows", "users.flow.yaml"), `
+version: 1
+steps:
+  - request: users/get
+`)
+
+		var out bytes.Buffer
+		var errOut bytes.Buffer
+		code := Execute([]string{"run", "users"}, &out, &errOut)
+		if code != 1 {
+			t.Fatalf("expected exit code 1, got %d; stdout=%q stderr=%q", code, out.String(), errOut.String())
+		}
+		if !strings.Contains(errOut.String(), `flow step "get"`) || !strings.Contains(errOut.String(), "for_each") || out.Len() != 0 {
+			t.Fatalf("expected the flow to be refused before any step, got stdout=%q stderr=%q", out.String(), errOut.String())
+		}
+	})
+}
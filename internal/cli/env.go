@@ -0,0 +1,457 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jaykbpark/wirepad/internal/config"
+	"github.com/jaykbpark/wirepad/internal/requestspec"
+)
+
+const (
+	sharedEnvDir  = "env"
+	privateEnvDir = ".wirepad/env"
+)
+
+func runEnv(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		printEnvUsage(stderr)
+		return 2
+	}
+	if wantsHelp(args) {
+		printEnvUsage(stdout)
+		return 0
+	}
+
+	switch args[0] {
+	case "help":
+		printEnvUsage(stdout)
+		return 0
+	case "list":
+		return runEnvList(args[1:], stdout, stderr)
+	case "show":
+		return runEnvShow(args[1:], stdout, stderr)
+	case "diff":
+		return runEnvDiff(args[1:], stdout, stderr)
+	case "lint":
+		return runEnvLint(args[1:], stdout, stderr)
+	case "encrypt":
+		return runEnvEncrypt(args[1:], stdout, stderr)
+	case "decrypt":
+		return runEnvDecrypt(args[1:], stdout, stderr)
+	default:
+		fmt.Fprintf(stderr, "unknown env subcommand %q\n\n", args[0])
+		printEnvUsage(stderr)
+		return 2
+	}
+}
+
+func printEnvUsage(out io.Writer) {
+	fmt.Fprintln(out, "Usage:")
+	fmt.Fprintln(out, "  wirepad env <subcommand> [args]")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Subcommands:")
+	fmt.Fprintln(out, "  list            List environments found in env/ and .wirepad/env/")
+	fmt.Fprintln(out, "  show <name>     Show merged variables (redacted) and the layer each comes from")
+	fmt.Fprintln(out, "  diff <a> <b>    Compare the variables of two environments (redacted)")
+	fmt.Fprintln(out, "  lint [name...]  Report keys used in requests/ but missing, and keys never used")
+	fmt.Fprintln(out, "  encrypt <name>  Encrypt .wirepad/env/<name>.env into env/<name>.env.enc")
+	fmt.Fprintln(out, "  decrypt <name>  Decrypt env/<name>.env.enc into .wirepad/env/<name>.env")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Show and diff flags:")
+	fmt.Fprintln(out, "  --reveal           Print values from private and encrypted layers and sensitive keys")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Encryption flags:")
+	fmt.Fprintln(out, "  --key-file <path>  Key file (default: $WIREPAD_ENV_PASSPHRASE, $WIREPAD_ENV_KEY_FILE, .wirepad/env.key)")
+	fmt.Fprintln(out, "  --force            Overwrite an existing decrypted file")
+}
+
+type envCryptOptions struct {
+	EnvName string
+	KeyFile string
+	Force   bool
+}
+
+func parseEnvCryptOptions(args []string) (envCryptOptions, error) {
+	var opts envCryptOptions
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		switch {
+		case arg == "--key-file":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("--key-file requires a value")
+			}
+			i++
+			opts.KeyFile = args[i]
+		case strings.HasPrefix(arg, "--key-file="):
+			opts.KeyFile = strings.TrimPrefix(arg, "--key-file=")
+		case arg == "--force":
+			opts.Force = true
+		case strings.HasPrefix(arg, "-"):
+			return opts, fmt.Errorf("unknown flag %q", arg)
+		default:
+			if opts.EnvName != "" {
+				return opts, fmt.Errorf("unexpected extra argument %q", arg)
+			}
+			opts.EnvName = strings.TrimSpace(arg)
+		}
+	}
+
+	if opts.EnvName == "" {
+		return opts, fmt.Errorf("missing <name>")
+	}
+	if err := config.ValidateEnvName(opts.EnvName); err != nil {
+		return opts, err
+	}
+	return opts, nil
+}
+
+func (o envCryptOptions) key() config.EnvKey {
+	if o.KeyFile != "" {
+		return config.EnvKey{KeyFile: o.KeyFile}
+	}
+	return config.EnvKeyFromEnvironment()
+}
+
+func runEnvEncrypt(args []string, stdout io.Writer, stderr io.Writer) int {
+	opts, err := parseEnvCryptOptions(args)
+	if err != nil {
+		fmt.Fprintf(stderr, "env encrypt argument error: %v\n", err)
+		printEnvUsage(stderr)
+		return 2
+	}
+
+	src := filepath.Join(privateEnvDir, opts.EnvName+".env")
+	dst := filepath.Join(sharedEnvDir, opts.EnvName+".env"+config.EncryptedEnvSuffix)
+
+	plaintext, err := os.ReadFile(src)
+	if err != nil {
+		fmt.Fprintf(stderr, "read env file: %v\n", err)
+		return 1
+	}
+
+	sealed, err := config.EncryptEnv(plaintext, opts.key())
+	if err != nil {
+		fmt.Fprintf(stderr, "encrypt %s: %v\n", src, err)
+		return 1
+	}
+
+	if err := os.MkdirAll(sharedEnvDir, 0o755); err != nil {
+		fmt.Fprintf(stderr, "create env directory: %v\n", err)
+		return 1
+	}
+	if err := os.WriteFile(dst, sealed, 0o644); err != nil {
+		fmt.Fprintf(stderr, "write encrypted env file: %v\n", err)
+		return 1
+	}
+
+	fmt.Fprintf(stdout, "Encrypted %s -> %s\n", src, dst)
+	return 0
+}
+
+func runEnvDecrypt(args []string, stdout io.Writer, stderr io.Writer) int {
+	opts, err := parseEnvCryptOptions(args)
+	if err != nil {
+		fmt.Fprintf(stderr, "env decrypt argument error: %v\n", err)
+		printEnvUsage(stderr)
+		return 2
+	}
+
+	src := filepath.Join(sharedEnvDir, opts.EnvName+".env"+config.EncryptedEnvSuffix)
+	dst := filepath.Join(privateEnvDir, opts.EnvName+".env")
+
+	sealed, err := os.ReadFile(src)
+	if err != nil {
+		fmt.Fprintf(stderr, "read encrypted env file: %v\n", err)
+		return 1
+	}
+
+	plaintext, err := config.DecryptEnv(sealed, opts.key())
+	if err != nil {
+		fmt.Fprintf(stderr, "decrypt %s: %v\n", src, err)
+		return 1
+	}
+
+	if _, err := os.Stat(dst); err == nil && !opts.Force {
+		fmt.Fprintf(stderr, "%s already exists (use --force to overwrite)\n", dst)
+		return 1
+	}
+	if err := os.MkdirAll(privateEnvDir, 0o755); err != nil {
+		fmt.Fprintf(stderr, "create private env directory: %v\n", err)
+		return 1
+	}
+	if err := os.WriteFile(dst, plaintext, 0o600); err != nil {
+		fmt.Fprintf(stderr, "write env file: %v\n", err)
+		return 1
+	}
+
+	fmt.Fprintf(stdout, "Decrypted %s -> %s\n", src, dst)
+	return 0
+}
+
+func runEnvList(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) > 0 {
+		fmt.Fprintf(stderr, "env list argument error: unexpected argument %q\n", args[0])
+		return 2
+	}
+
+	envs, err := discoverEnvs()
+	if err != nil {
+		fmt.Fprintf(stderr, "list environments: %v\n", err)
+		return 1
+	}
+	if len(envs) == 0 {
+		fmt.Fprintln(stdout, "No environments found in env/ or .wirepad/env/")
+		return 0
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	for _, name := range sortedKeys(envs) {
+		fmt.Fprintf(tw, "%s\t%s\n", name, strings.Join(envs[name], ", "))
+	}
+	tw.Flush()
+	return 0
+}
+
+func runEnvShow(args []string, stdout io.Writer, stderr io.Writer) int {
+	args, reveal := cutRevealFlag(args)
+	if len(args) != 1 || strings.HasPrefix(args[0], "-") {
+		fmt.Fprintln(stderr, "env show argument error: expected exactly one <name>")
+		printEnvUsage(stderr)
+		return 2
+	}
+
+	layers, err := config.ResolveLayers(config.ResolveOptions{EnvName: args[0]})
+	if err != nil {
+		fmt.Fprintf(stderr, "resolve variables: %v\n", err)
+		return 1
+	}
+
+	merged := config.MergeLayers(layers)
+	sources := config.LayerSources(layers)
+
+	tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	for _, key := range sortedKeys(merged) {
+		fmt.Fprintf(tw, "%s\t%s\t(%s)\n", key, displayEnvValue(key, merged[key], sources[key], reveal), sources[key])
+	}
+	tw.Flush()
+	return 0
+}
+
+func runEnvDiff(args []string, stdout io.Writer, stderr io.Writer) int {
+	args, reveal := cutRevealFlag(args)
+	if len(args) != 2 || strings.HasPrefix(args[0], "-") || strings.HasPrefix(args[1], "-") {
+		fmt.Fprintln(stderr, "env diff argument error: expected <a> <b>")
+		printEnvUsage(stderr)
+		return 2
+	}
+
+	left, leftSources, err := envFileVars(args[0])
+	if err != nil {
+		fmt.Fprintf(stderr, "resolve %s variables: %v\n", args[0], err)
+		return 1
+	}
+	right, rightSources, err := envFileVars(args[1])
+	if err != nil {
+		fmt.Fprintf(stderr, "resolve %s variables: %v\n", args[1], err)
+		return 1
+	}
+
+	keys := make(map[string]struct{}, len(left)+len(right))
+	for key := range left {
+		keys[key] = struct{}{}
+	}
+	for key := range right {
+		keys[key] = struct{}{}
+	}
+
+	changed := 0
+	for _, key := range sortedKeys(keys) {
+		lv, inLeft := left[key]
+		rv, inRight := right[key]
+		shownLeft := displayEnvValue(key, lv, leftSources[key], reveal)
+		shownRight := displayEnvValue(key, rv, rightSources[key], reveal)
+		switch {
+		case inLeft && !inRight:
+			fmt.Fprintf(stdout, "- %s = %s\n", key, shownLeft)
+		case !inLeft && inRight:
+			fmt.Fprintf(stdout, "+ %s = %s\n", key, shownRight)
+		case lv != rv:
+			fmt.Fprintf(stdout, "~ %s: %s -> %s\n", key, shownLeft, shownRight)
+		default:
+			continue
+		}
+		changed++
+	}
+
+	if changed == 0 {
+		fmt.Fprintf(stdout, "No differences between %s and %s\n", args[0], args[1])
+	}
+	return 0
+}
+
+func runEnvLint(args []string, stdout io.Writer, stderr io.Writer) int {
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			fmt.Fprintf(stderr, "env lint argument error: unknown flag %q\n", arg)
+			return 2
+		}
+	}
+
+	names := args
+	if len(names) == 0 {
+		envs, err := discoverEnvs()
+		if err != nil {
+			fmt.Fprintf(stderr, "list environments: %v\n", err)
+			return 1
+		}
+		names = sortedKeys(envs)
+	}
+
+	usage, err := collectVariableUsage(requestspec.RequestsDir)
+	if err != nil {
+		fmt.Fprintf(stderr, "scan requests: %v\n", err)
+		return 1
+	}
+
+	missingCount := 0
+	unusedCount := 0
+	for _, name := range names {
+		layers, err := config.ResolveLayers(config.ResolveOptions{EnvName: name})
+		if err != nil {
+			fmt.Fprintf(stderr, "resolve %s variables: %v\n", name, err)
+			return 1
+		}
+		defined := config.MergeLayers(layers)
+		sources := config.LayerSources(layers)
+
+		for _, key := range sortedKeys(usage) {
+			if _, ok := defined[key]; ok {
+				continue
+			}
+			fmt.Fprintf(stdout, "%s: missing %s (used in %s)\n", name, key, strings.Join(usage[key], ", "))
+			missingCount++
+		}
+
+		for _, key := range sortedKeys(defined) {
+			if _, ok := usage[key]; ok || sources[key] == config.LayerGenerated {
+				continue
+			}
+			fmt.Fprintf(stdout, "%s: unused %s (defined in %s)\n", name, key, sources[key])
+			unusedCount++
+		}
+	}
+
+	if missingCount == 0 && unusedCount == 0 {
+		fmt.Fprintln(stdout, "No env issues found")
+		return 0
+	}
+	fmt.Fprintf(stdout, "%d missing, %d unused\n", missingCount, unusedCount)
+	if missingCount > 0 {
+		return 1
+	}
+	return 0
+}
+
+// discoverEnvs maps each environment name to the files that define it.
+func discoverEnvs() (map[string][]string, error) {
+	envs := make(map[string][]string)
+	for _, dir := range []string{sharedEnvDir, privateEnvDir} {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			fileName := entry.Name()
+			name := strings.TrimSuffix(strings.TrimSuffix(fileName, config.EncryptedEnvSuffix), ".env")
+			if name == "" || name+".env" != strings.TrimSuffix(fileName, config.EncryptedEnvSuffix) {
+				continue
+			}
+			envs[name] = append(envs[name], filepath.Join(dir, fileName))
+		}
+	}
+	return envs, nil
+}
+
+// envFileVars merges the file layers of an environment, leaving out
+// generated runtime values that differ on every call, and reports the layer
+// each value comes from.
+func envFileVars(name string) (map[string]string, map[string]string, error) {
+	layers, err := config.ResolveLayers(config.ResolveOptions{EnvName: name})
+	if err != nil {
+		return nil, nil, err
+	}
+	layers = slices.DeleteFunc(layers, func(layer config.Layer) bool {
+		return layer.Source == config.LayerGenerated
+	})
+	return config.MergeLayers(layers), config.LayerSources(layers), nil
+}
+
+// collectVariableUsage maps every {{var}} referenced under root to the files using it.
+func collectVariableUsage(root string) (map[string][]string, error) {
+	files, err := requestspec.FindFiles(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string][]string{}, nil
+		}
+		return nil, err
+	}
+
+	usage := make(map[string][]string)
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range config.ReferencedVariables(string(data)) {
+			usage[name] = append(usage[name], path)
+		}
+	}
+	return usage, nil
+}
+
+// displayEnvValue hides values from the private and encrypted layers and
+// values of sensitive keys unless reveal is set. Secret references are shown
+// as written since they hold no secret themselves.
+func displayEnvValue(key, value, source string, reveal bool) string {
+	if reveal || config.IsSecretRef(value) {
+		return value
+	}
+	if privateLayer(source) || config.IsSensitiveKey(key) {
+		return config.Redacted
+	}
+	return config.RedactString(value)
+}
+
+func privateLayer(source string) bool {
+	return strings.HasSuffix(source, config.EncryptedEnvSuffix) ||
+		strings.HasPrefix(filepath.ToSlash(source), privateEnvDir+"/")
+}
+
+// cutRevealFlag removes --reveal from args.
+func cutRevealFlag(args []string) ([]string, bool) {
+	rest := slices.DeleteFunc(slices.Clone(args), func(arg string) bool { return arg == "--reveal" })
+	return rest, len(rest) != len(args)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExecute_EnvEncryptDecryptRoundTrip(t *testing.T) {
+	withTempWorkingDir(t, func(root string) {
+		t.Setenv("WIREPAD_ENV_PASSPHRASE", "")
+		t.Setenv("WIREPAD_ENV_KEY_FILE", "")
+		writeFile(t, filepath.Join(root, ".wirepad", "env.key"), "0123456789abcdef0123456789abcdef\n")
+		writeFile(t, filepath.Join(root, ".wirepad", "env", "dev.env"), "token=abc123\n")
+
+		var out bytes.Buffer
+		var errOut bytes.Buffer
+		if code := Execute([]string{"env", "encrypt", "dev"}, &out, &errOut); code != 0 {
+			t.Fatalf("expected encrypt exit code 0, got %d; stderr=%q", code, errOut.String())
+		}
+
+		sealed, err := os.ReadFile(filepath.Join(root, "env", "dev.env.enc"))
+		if err != nil {
+			t.Fatalf("read encrypted env: %v", err)
+		}
+		if strings.Contains(string(sealed), "abc123") {
+			t.Fatalf("expected encrypted env not to contain plaintext secret")
+		}
+
+		errOut.Reset()
+		if code := Execute([]string{"env", "decrypt", "dev"}, &out, &errOut); code != 1 {
+			t.Fatalf("expected decrypt to refuse overwrite, got %d", code)
+		}
+
+		if err := os.Remove(filepath.Join(root, ".wirepad", "env", "dev.env")); err != nil {
+			t.Fatalf("remove private env: %v", err)
+		}
+		errOut.Reset()
+		if code := Execute([]string{"env", "decrypt", "dev"}, &out, &errOut); code != 0 {
+			t.Fatalf("expected decrypt exit code 0, got %d; stderr=%q", code, errOut.String())
+		}
+
+		plaintext, err := os.ReadFile(filepath.Join(root, ".wirepad", "env", "dev.env"))
+		if err != nil {
+			t.Fatalf("read decrypted env: %v", err)
+		}
+		if string(plaintext) != "token=abc123\n" {
+			t.Fatalf("unexpected decrypted env %q", plaintext)
+		}
+	})
+}
+
+func TestExecute_EnvEncryptRejectsPathNames(t *testing.T) {
+	withTempWorkingDir(t, func(root string) {
+		writeFile(t, filepath.Join(root, ".wirepad", "env.key"), "0123456789abcdef0123456789abcdef\n")
+		writeFile(t, filepath.Join(root, "outside.env"), "token=abc123\n")
+
+		for _, name := range []string{"../../outside", "../outside", "team/dev", `team\dev`, ".."} {
+			for _, command := range []string{"encrypt", "decrypt"} {
+				var out bytes.Buffer
+				var errOut bytes.Buffer
+				if code := Execute([]string{"env", command, name}, &out, &errOut); code != 2 || !strings.Contains(errOut.String(), "invalid env name") {
+					t.Fatalf("env %s %q: expected exit code 2 with an invalid name error, got %d; stderr=%q", command, name, code, errOut.String())
+				}
+			}
+		}
+		if _, err := os.Stat(filepath.Join(root, "outside.env.enc")); !os.IsNotExist(err) {
+			t.Fatalf("expected nothing written outside env/, got err=%v", err)
+		}
+	})
+}
+
+func TestExecute_EnvShowDiffAndLint(t *testing.T) {
+	withTempWorkingDir(t, func(root string) {
+		writeFile(t, filepath.Join(root, "requests", "users", "create.req.yaml"), `
+version: 1
+kind: http
+name: users.create
+request:
+  method: POST
+  url: "{{base_url}}/users"
+  headers:
+    Authorization: "Bearer {{token}}"
+    X-Request-Id: "{{uuid}}"
+`)
+		writeFile(t, filepath.Join(root, "env", "dev.env"), "base_url=https://dev.example.com\nlegacy=1\n")
+		writeFile(t, filepath.Join(root, ".wirepad", "env", "dev.env"), "token=dev-secret\ndb_host=db.internal.example\n")
+		writeFile(t, filepath.Join(root, "env", "stage.env"), "base_url=https://stage.example.com\ntoken=${secret:env:STAGE_TOKEN}\n")
+
+		var out bytes.Buffer
+		var errOut bytes.Buffer
+		if code := Execute([]string{"env", "list"}, &out, &errOut); code != 0 {
+			t.Fatalf("expected list exit code 0, got %d; stderr=%q", code, errOut.String())
+		}
+		if !strings.Contains(out.String(), "dev") || !strings.Contains(out.String(), "stage") {
+			t.Fatalf("expected dev and stage in env list, got %q", out.String())
+		}
+
+		out.Reset()
+		if code := Execute([]string{"env", "show", "dev"}, &out, &errOut); code != 0 {
+			t.Fatalf("expected show exit code 0, got %d; stderr=%q", code, errOut.String())
+		}
+		shown := out.String()
+		if strings.Contains(shown, "dev-secret") {
+			t.Fatalf("expected token to be redacted, got %q", shown)
+		}
+		if strings.Contains(shown, "db.internal.example") {
+			t.Fatalf("expected private layer values to be redacted, got %q", shown)
+		}
+		if !strings.Contains(shown, filepath.Join(".wirepad", "env", "dev.env")) {
+			t.Fatalf("expected source layer for token, got %q", shown)
+		}
+
+		out.Reset()
+		if code := Execute([]string{"env", "show", "dev", "--reveal"}, &out, &errOut); code != 0 {
+			t.Fatalf("expected show --reveal exit code 0, got %d; stderr=%q", code, errOut.String())
+		}
+		if !strings.Contains(out.String(), "db.internal.example") {
+			t.Fatalf("expected --reveal to print private values, got %q", out.String())
+		}
+
+		out.Reset()
+		if code := Execute([]string{"env", "diff", "dev", "stage"}, &out, &errOut); code != 0 {
+			t.Fatalf("expected diff exit code 0, got %d; stderr=%q", code, errOut.String())
+		}
+		if !strings.Contains(out.String(), "~ base_url: https://dev.example.com -> https://stage.example.com") {
+			t.Fatalf("expected changed base_url in diff, got %q", out.String())
+		}
+		if !strings.Contains(out.String(), "- legacy = 1") {
+			t.Fatalf("expected removed legacy key in diff, got %q", out.String())
+		}
+		if !strings.Contains(out.String(), "- db_host = [REDACTED]") {
+			t.Fatalf("expected private value to be redacted in diff, got %q", out.String())
+		}
+
+		writeFile(t, filepath.Join(root, "env", "prod.env"), "base_url=https://api.example.com\n")
+		out.Reset()
+		code := Execute([]string{"env", "lint", "dev", "prod"}, &out, &errOut)
+		if code != 1 {
+			t.Fatalf("expected lint exit code 1 for missing keys, got %d; stdout=%q", code, out.String())
+		}
+		linted := out.String()
+		if !strings.Contains(linted, "prod: missing token") {
+			t.Fatalf("expected missing token for prod, got %q", linted)
+		}
+		if !strings.Contains(linted, "dev: unused legacy") {
+			t.Fatalf("expected unused legacy for dev, got %q", linted)
+		}
+		if strings.Contains(linted, "uuid") {
+			t.Fatalf("expected generated variables to satisfy references, got %q", linted)
+		}
+	})
+}
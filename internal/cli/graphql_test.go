@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExecute_SendGraphQLFromOperationFile(t *testing.T) {
+	withTempWorkingDir(t, func(root string) {
+		var got struct {
+			Query         string         `json:"query"`
+			Variables     map[string]any `json:"variables"`
+			OperationName string         `json:"operationName"`
+		}
+		var gotMethod, gotAccept string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotMethod, gotAccept = r.Method, r.Header.Get("Accept")
+			payload, _ := io.ReadAll(r.Body)
+			if err := json.Unmarshal(payload, &got); err != nil {
+				t.Errorf("decode graphql request: %v", err)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			if got.Variables["id"] == "missing" {
+				_, _ = io.WriteString(w, `{"data":{"user":null},"errors":[{"message":"user not found","path":["user"]}]}`)
+				return
+			}
+			_, _ = io.WriteString(w, `{"data":{"user":{"name":"Ada"}}}`)
+		}))
+		defer server.Close()
+
+		query := "query GetUser($id: ID!) {\n  user(id: $id) { name }\n}\n"
+		writeFile(t, filepath.Join(root, "requests", "queries", "user.graphql"), query)
+		writeFile(t, filepath.Join(root, "requests", "user.req.yaml"), `
+version: 1
+kind: http
+name: user
+request:
+  url: "`+server.URL+`/graphql"
+  body:
+    mode: graphql
+    query_path: queries/user.graphql
+    operation_name: GetUser
+    variables:
+      id: "{{user_id}}"
+      limit: 5
+expect:
+  body:
+    jsonpath:
+      "$.data.user.name": Ada
+`)
+
+		var out bytes.Buffer
+		var errOut bytes.Buffer
+		code := Execute([]string{"send", "user", "--var", "user_id=u_1"}, &out, &errOut)
+		if code != 0 {
+			t.Fatalf("expected exit code 0, got %d; stdout=%q stderr=%q", code, out.String(), errOut.String())
+		}
+		if gotMethod != http.MethodPost || !strings.HasPrefix(gotAccept, "application/graphql-response+json") {
+			t.Fatalf("expected POST with graphql Accept, got %s %q", gotMethod, gotAccept)
+		}
+		if got.Query != query || got.OperationName != "GetUser" || got.Variables["id"] != "u_1" || got.Variables["limit"] != float64(5) {
+			t.Fatalf("unexpected graphql request %+v", got)
+		}
+		if !strings.Contains(out.String(), "Assertions: 2 passed, 0 failed") {
+			t.Fatalf("expected the default no_errors check to pass, got %q", out.String())
+		}
+
+		out.Reset()
+		errOut.Reset()
+		code = Execute([]string{"send", "user", "--var", "user_id=missing"}, &out, &errOut)
+		if code != 1 {
+			t.Fatalf("expected GraphQL errors to fail the run, got %d; stdout=%q", code, out.String())
+		}
+		if !strings.Contains(out.String(), `FAIL graphql.errors[0] no_errors: expected null, got "user not found" (at user)`) {
+			t.Fatalf("expected GraphQL error in stdout, got %q", out.String())
+		}
+	})
+}
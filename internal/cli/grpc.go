@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/jaykbpark/wirepad/internal/assert"
+	"github.com/jaykbpark/wirepad/internal/config"
+	"github.com/jaykbpark/wirepad/internal/grpcclient"
+	"github.com/jaykbpark/wirepad/internal/history"
+	"github.com/jaykbpark/wirepad/internal/httpclient"
+	"github.com/jaykbpark/wirepad/internal/requestspec"
+)
+
+// executeGRPC runs a kind=grpc spec. Assertions see the gRPC status code as
+// status, response headers and trailers as headers, and the response message
+// (or the array of streamed messages) as the body.
+func executeGRPC(spec *requestspec.Spec, requestPath string, opts sendOptions, maxBodyBytes int64, live io.Writer) (*requestRun, error) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	runID := history.NewRunID(time.Now().UTC())
+	streamed := 0
+	resp, err := grpcclient.Execute(spec, requestPath, grpcclient.Options{
+		Context: ctx,
+		OnMessage: func(index int, message json.RawMessage) bool {
+			if opts.JSONOutput {
+				return opts.MaxEvents <= 0 || index+1 < opts.MaxEvents
+			}
+			streamed++
+			fmt.Fprintf(live, "[%d]\n", index+1)
+			fmt.Fprintln(live, config.RedactString(prettyBody(message)))
+			return opts.MaxEvents <= 0 || streamed < opts.MaxEvents
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("send request: %s", config.RedactString(err.Error()))
+	}
+
+	headers := flattenHeaders(resp.Headers)
+	for key, value := range flattenHeaders(resp.Trailers) {
+		headers[key] = value
+	}
+	results := assert.Evaluate(spec.Expect, assert.Subject{
+		Status:     resp.Code,
+		Protocol:   resp.Proto,
+		Headers:    headers,
+		Body:       resp.Body,
+		DurationMS: resp.Duration.Milliseconds(),
+	})
+	_, failed := assert.Counts(results)
+
+	historyBody, truncated := history.TruncateBody(resp.Body, maxBodyBytes)
+	record := history.RunRecord{
+		RunID:           runID,
+		RequestName:     spec.Name,
+		RequestPath:     requestPath,
+		Env:             opts.EnvName,
+		StartedAt:       resp.StartedAt.Format("2006-01-02T15:04:05Z07:00"),
+		DurationMS:      resp.Duration.Milliseconds(),
+		OK:              failed == 0,
+		Status:          resp.Code,
+		Protocol:        resp.Proto,
+		ResponseHeaders: config.RedactHeaders(flattenHeaders(resp.Headers)),
+		ResponseBody:    config.RedactString(historyBody),
+		Proxy:           httpclient.RedactProxy(spec.Request.Proxy),
+		Assertions:      historyAssertions(results),
+		DataRow:         opts.DataRow,
+		GRPC: &history.GRPCCall{
+			Service:  resp.Service,
+			Method:   resp.Method,
+			Code:     resp.Code,
+			CodeName: grpcclient.CodeName(resp.Code),
+			Message:  config.RedactString(resp.Message),
+			Trailers: config.RedactHeaders(flattenHeaders(resp.Trailers)),
+			Messages: len(resp.Messages),
+		},
+	}
+	if truncated {
+		record.ResponseBodyBytes = int64(len(resp.Body))
+		record.ResponseBodyTruncated = true
+	}
+
+	historyPath, err := history.SaveRun(record)
+	if err != nil {
+		return nil, fmt.Errorf("save run history: %w", err)
+	}
+
+	return &requestRun{
+		spec:        spec,
+		record:      record,
+		historyPath: historyPath,
+		results:     results,
+		body:        resp.Body,
+		headers:     headers,
+		grpc:        resp,
+		streamed:    streamed > 0,
+	}, nil
+}
+
+func printGRPCHuman(out io.Writer, resp *grpcclient.Response, record history.RunRecord, historyPath string, streamed bool) {
+	if streamed {
+		fmt.Fprintln(out)
+	}
+	fmt.Fprintf(out, "GRPC %s/%s %s (%d)\n", resp.Service, resp.Method, record.GRPC.CodeName, resp.Code)
+	if resp.Message != "" {
+		fmt.Fprintf(out, "Message: %s\n", record.GRPC.Message)
+	}
+	fmt.Fprintf(out, "Duration: %dms\n", resp.Duration.Milliseconds())
+	if resp.Proto != "" {
+		fmt.Fprintf(out, "Protocol: %s\n", resp.Proto)
+	}
+	if resp.ServerStreaming {
+		fmt.Fprintf(out, "Messages: %d\n", len(resp.Messages))
+	}
+	if record.ResponseBodyTruncated {
+		fmt.Fprintf(out, "History body: first %s of %s kept\n", formatBytes(int64(len(record.ResponseBody))), formatBytes(record.ResponseBodyBytes))
+	}
+	fmt.Fprintf(out, "Run ID: %s\n", record.RunID)
+	fmt.Fprintf(out, "History: %s\n", historyPath)
+
+	if len(resp.Body) == 0 || streamed {
+		return
+	}
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, config.RedactString(prettyBody(resp.Body)))
+}
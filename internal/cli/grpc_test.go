@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExecute_SendGRPCUnaryRecordsStatusAndTrailers(t *testing.T) {
+	withTempWorkingDir(t, func(root string) {
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/demo.Greeter/SayHello" || r.Header.Get("X-Tenant") != "acme" {
+				w.Header().Set("Grpc-Status", "12")
+				return
+			}
+			frame, _ := io.ReadAll(r.Body)
+			// HelloRequest{name: 1} is a single length-delimited field.
+			name := string(frame[7:])
+			reply := append([]byte{0x0a, byte(len("hi " + name))}, "hi "+name...)
+			reply = append(reply, 0x10, 0x02)
+
+			header := make([]byte, 5)
+			binary.BigEndian.PutUint32(header[1:], uint32(len(reply)))
+			w.Header().Set("Content-Type", "application/grpc")
+			_, _ = w.Write(append(header, reply...))
+			w.Header().Set(http.TrailerPrefix+"Grpc-Status", "0")
+			w.Header().Set(http.TrailerPrefix+"X-Request-Cost", "3")
+		}))
+		server.Config.Protocols = new(http.Protocols)
+		server.Config.Protocols.SetUnencryptedHTTP2(true)
+		server.Start()
+		defer server.Close()
+
+		writeFile(t, filepath.Join(root, "protos", "greeter.proto"), `
+syntax = "proto3";
+package demo;
+
+service Greeter {
+  rpc SayHello (HelloRequest) returns (HelloReply);
+}
+
+message HelloRequest { string name = 1; }
+message HelloReply {
+  string message = 1;
+  int32 count = 2;
+}
+`)
+		writeFile(t, filepath.Join(root, "requests", "hello.req.yaml"), `
+version: 1
+kind: grpc
+name: hello
+request:
+  url: "`+server.URL+`"
+  service: Greeter
+  method: SayHello
+  proto: ../protos/greeter.proto
+  metadata:
+    x-tenant: acme
+  message:
+    name: "{{who}}"
+expect:
+  status: 0
+  headers:
+    x-request-cost: "3"
+  body:
+    jsonpath:
+      "$.message": hi ada
+      "$.count": 2
+`)
+
+		var out bytes.Buffer
+		var errOut bytes.Buffer
+		code := Execute([]string{"send", "hello", "--var", "who=ada"}, &out, &errOut)
+		if code != 0 {
+			t.Fatalf("expected exit code 0, got %d; stdout=%q stderr=%q", code, out.String(), errOut.String())
+		}
+		for _, want := range []string{"GRPC demo.Greeter/SayHello OK (0)", "Protocol: HTTP/2.0", `"message": "hi ada"`, "Assertions: 4 passed, 0 failed"} {
+			if !strings.Contains(out.String(), want) {
+				t.Fatalf("expected %q in stdout, got %q", want, out.String())
+			}
+		}
+
+		entries, err := os.ReadDir(filepath.Join(root, ".wirepad", "history", "runs"))
+		if err != nil || len(entries) != 1 {
+			t.Fatalf("expected 1 run history file, got %d (err=%v)", len(entries), err)
+		}
+		payload, err := os.ReadFile(filepath.Join(root, ".wirepad", "history", "runs", entries[0].Name()))
+		if err != nil {
+			t.Fatalf("read run history: %v", err)
+		}
+		var record struct {
+			Status int `json:"status"`
+			GRPC   struct {
+				Service  string            `json:"service"`
+				CodeName string            `json:"code_name"`
+				Trailers map[string]string `json:"trailers"`
+				Messages int               `json:"messages"`
+			} `json:"grpc"`
+		}
+		if err := json.Unmarshal(payload, &record); err != nil {
+			t.Fatalf("decode run history: %v", err)
+		}
+		if record.Status != 0 || record.GRPC.Service != "demo.Greeter" || record.GRPC.CodeName != "OK" || record.GRPC.Trailers["x-request-cost"] != "3" || record.GRPC.Messages != 1 {
+			t.Fatalf("unexpected grpc record %+v", record)
+		}
+	})
+}
@@ -0,0 +1,223 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jaykbpark/wirepad/internal/config"
+	"github.com/jaykbpark/wirepad/internal/mock"
+	"github.com/jaykbpark/wirepad/internal/requestspec"
+)
+
+func runMock(args []string, stdout io.Writer, stderr io.Writer) int {
+	if wantsHelp(args) {
+		printMockUsage(stdout)
+		return 0
+	}
+
+	opts, err := parseMockOptions(args)
+	if err != nil {
+		fmt.Fprintf(stderr, "mock argument error: %v\n", err)
+		printMockUsage(stderr)
+		return 2
+	}
+
+	server, err := newMockServer(opts, stdout, stderr)
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(opts.Host, strconv.Itoa(opts.Port)))
+	if err != nil {
+		fmt.Fprintf(stderr, "listen: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "Mock server listening on http://%s (Ctrl-C to stop)\n", listener.Addr())
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	httpServer := &http.Server{Handler: server, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = httpServer.Shutdown(shutdown)
+	}()
+	if err := httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintf(stderr, "serve: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func printMockUsage(out io.Writer) {
+	writeSimpleUsage(out, "wirepad mock [<dir|file>...] [--port <n>] [--host <addr>] [--env <name>] [--var key=value] [--latency <d>[-<d>]] [--error-rate <0-1>] [--error-status <code>]")
+}
+
+type mockOptions struct {
+	// Paths are directories or .req.yaml files; requests/ when empty.
+	Paths   []string
+	Host    string
+	Port    int
+	EnvName string
+	Vars    map[string]string
+	Server  mock.Options
+}
+
+func parseMockOptions(args []string) (mockOptions, error) {
+	opts := mockOptions{Host: "127.0.0.1", Port: 8080, Vars: make(map[string]string)}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		switch {
+		case arg == "--port", strings.HasPrefix(arg, "--port="):
+			value, next, err := flagValue(args, i, "--port")
+			if err != nil {
+				return opts, err
+			}
+			i = next
+			port, err := strconv.Atoi(value)
+			if err != nil || port < 0 || port > 65535 {
+				return opts, fmt.Errorf("--port must be a number between 0 and 65535")
+			}
+			opts.Port = port
+		case arg == "--host", strings.HasPrefix(arg, "--host="):
+			value, next, err := flagValue(args, i, "--host")
+			if err != nil {
+				return opts, err
+			}
+			i = next
+			opts.Host = value
+		case arg == "--env", strings.HasPrefix(arg, "--env="):
+			value, next, err := flagValue(args, i, "--env")
+			if err != nil {
+				return opts, err
+			}
+			i = next
+			if value == "" {
+				return opts, fmt.Errorf("--env value cannot be empty")
+			}
+			opts.EnvName = value
+		case arg == "--var", strings.HasPrefix(arg, "--var="):
+			value, next, err := flagValue(args, i, "--var")
+			if err != nil {
+				return opts, err
+			}
+			i = next
+			key, val, err := parseVarPair(value)
+			if err != nil {
+				return opts, err
+			}
+			opts.Vars[key] = val
+		case arg == "--latency", strings.HasPrefix(arg, "--latency="):
+			value, next, err := flagValue(args, i, "--latency")
+			if err != nil {
+				return opts, err
+			}
+			i = next
+			low, high, err := parseLatency(value)
+			if err != nil {
+				return opts, err
+			}
+			opts.Server.Latency, opts.Server.LatencyMax = low, high
+		case arg == "--error-rate", strings.HasPrefix(arg, "--error-rate="):
+			value, next, err := flagValue(args, i, "--error-rate")
+			if err != nil {
+				return opts, err
+			}
+			i = next
+			rate, err := strconv.ParseFloat(value, 64)
+			if err != nil || rate < 0 || rate > 1 {
+				return opts, fmt.Errorf("--error-rate must be a fraction between 0 and 1")
+			}
+			opts.Server.ErrorRate = rate
+		case arg == "--error-status", strings.HasPrefix(arg, "--error-status="):
+			value, next, err := flagValue(args, i, "--error-status")
+			if err != nil {
+				return opts, err
+			}
+			i = next
+			status, err := strconv.Atoi(value)
+			if err != nil || status < 100 || status > 599 {
+				return opts, fmt.Errorf("--error-status must be an HTTP status between 100 and 599")
+			}
+			opts.Server.ErrorStatus = status
+		case strings.HasPrefix(arg, "-"):
+			return opts, fmt.Errorf("unknown flag %q", arg)
+		default:
+			opts.Paths = append(opts.Paths, arg)
+		}
+	}
+
+	if len(opts.Paths) == 0 {
+		opts.Paths = []string{requestspec.RequestsDir}
+	}
+	return opts, nil
+}
+
+// parseLatency reads "100ms" or a "50ms-200ms" range.
+func parseLatency(value string) (time.Duration, time.Duration, error) {
+	lowText, highText, isRange := strings.Cut(value, "-")
+	low, err := time.ParseDuration(lowText)
+	if err != nil || low < 0 {
+		return 0, 0, fmt.Errorf("--latency must be a duration such as 100ms or a range such as 50ms-200ms")
+	}
+	if !isRange {
+		return low, 0, nil
+	}
+	high, err := time.ParseDuration(highText)
+	if err != nil || high < low {
+		return 0, 0, fmt.Errorf("--latency range must be <low>-<high>, e.g. 50ms-200ms")
+	}
+	return low, high, nil
+}
+
+// newMockServer builds the mock routes and prints them with any warnings.
+func newMockServer(opts mockOptions, stdout io.Writer, stderr io.Writer) (*mock.Server, error) {
+	files, err := requestFiles(opts.Paths)
+	if err != nil {
+		return nil, fmt.Errorf("collect requests: %w", err)
+	}
+
+	vars, err := config.ResolveVariables(config.ResolveOptions{EnvName: opts.EnvName, CLI: opts.Vars})
+	if err != nil {
+		return nil, fmt.Errorf("resolve variables: %w", err)
+	}
+	serverOpts := opts.Server
+	serverOpts.Vars = vars
+	serverOpts.Log = &lockedWriter{w: stdout}
+
+	server, warnings, err := mock.NewServer(files, serverOpts)
+	if err != nil {
+		return nil, err
+	}
+	for _, warning := range warnings {
+		fmt.Fprintf(stderr, "warning: %s\n", warning)
+	}
+	if len(server.Routes()) == 0 {
+		return nil, fmt.Errorf("no requests to mock: add a mock block or send a request once to record a response")
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	for _, route := range server.Routes() {
+		source := route.Source
+		if source != "mock" {
+			source = "run " + source
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\n", route.Method, route.Pattern, route.Name, source)
+	}
+	tw.Flush()
+	return server, nil
+}
@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMockServer_ListsRoutesAndLogsRequests(t *testing.T) {
+	withTempWorkingDir(t, func(root string) {
+		writeFile(t, filepath.Join(root, "env", "dev.env"), "greeting=hello\n")
+		writeFile(t, filepath.Join(root, "requests", "users", "get.req.yaml"), `
+version: 1
+kind: http
+name: users.get
+request:
+  method: GET
+  url: "{{base_url}}/users/{{id}}"
+mock:
+  body: "{{greeting}} {{id}}"
+`)
+		writeFile(t, filepath.Join(root, "requests", "users", "list.req.yaml"), `
+version: 1
+kind: http
+name: users.list
+request:
+  method: GET
+  url: "{{base_url}}/users"
+`)
+
+		opts, err := parseMockOptions([]string{"--env", "dev", "--port", "0", "--latency", "5ms-10ms"})
+		if err != nil {
+			t.Fatalf("parseMockOptions returned error: %v", err)
+		}
+		if opts.Server.Latency != 5*time.Millisecond || opts.Server.LatencyMax != 10*time.Millisecond || opts.Paths[0] != "requests" {
+			t.Fatalf("unexpected options %+v", opts)
+		}
+
+		var out bytes.Buffer
+		var errOut bytes.Buffer
+		server, err := newMockServer(opts, &out, &errOut)
+		if err != nil {
+			t.Fatalf("newMockServer returned error: %v", err)
+		}
+		if !strings.Contains(out.String(), "GET  /users/:id  users.get  mock") {
+			t.Fatalf("expected route listing, got %q", out.String())
+		}
+		if !strings.Contains(errOut.String(), "list.req.yaml: skipped: no mock block and no successful run in history") {
+			t.Fatalf("expected skipped warning, got %q", errOut.String())
+		}
+
+		ts := httptest.NewServer(server)
+		defer ts.Close()
+		resp, err := http.Get(ts.URL + "/users/7")
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != "hello 7" {
+			t.Fatalf("unexpected body %q", body)
+		}
+		if !strings.Contains(out.String(), "GET /users/7 -> users.get 200 (mock, ") {
+			t.Fatalf("expected request log, got %q", out.String())
+		}
+	})
+
+	for _, args := range [][]string{{"--latency", "fast"}, {"--latency", "200ms-50ms"}, {"--error-rate", "2"}, {"--error-status", "42"}} {
+		if _, err := parseMockOptions(args); err == nil {
+			t.Fatalf("expected error for %v", args)
+		}
+	}
+}
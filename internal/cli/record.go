@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jaykbpark/wirepad/internal/history"
+	"github.com/jaykbpark/wirepad/internal/recorder"
+	"github.com/jaykbpark/wirepad/internal/requestspec"
+)
+
+func runRecord(args []string, stdout io.Writer, stderr io.Writer) int {
+	if wantsHelp(args) {
+		printRecordUsage(stdout)
+		return 0
+	}
+
+	opts, err := parseRecordOptions(args)
+	if err != nil {
+		fmt.Fprintf(stderr, "record argument error: %v\n", err)
+		printRecordUsage(stderr)
+		return 2
+	}
+
+	handler, err := newRecorder(opts, stdout, stderr)
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+
+	listener, err := net.Listen("tcp", opts.Listen)
+	if err != nil {
+		fmt.Fprintf(stderr, "listen: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "Recording http://%s -> %s into %s/ (Ctrl-C to stop)\n", listener.Addr(), opts.Recorder.Upstream, filepath.ToSlash(opts.Recorder.Dir))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	httpServer := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = httpServer.Shutdown(shutdown)
+	}()
+	if err := httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintf(stderr, "serve: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func printRecordUsage(out io.Writer) {
+	writeSimpleUsage(out, "wirepad record --upstream <url> [--listen <addr>] [--dir <dir>]")
+}
+
+type recordOptions struct {
+	Listen   string
+	Recorder recorder.Options
+}
+
+func parseRecordOptions(args []string) (recordOptions, error) {
+	opts := recordOptions{Listen: ":8888", Recorder: recorder.Options{Dir: requestspec.RequestsDir}}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		switch {
+		case arg == "--listen", strings.HasPrefix(arg, "--listen="):
+			value, next, err := flagValue(args, i, "--listen")
+			if err != nil {
+				return opts, err
+			}
+			i = next
+			if _, _, err := net.SplitHostPort(value); err != nil {
+				return opts, fmt.Errorf("--listen must be host:port or :port")
+			}
+			opts.Listen = value
+		case arg == "--upstream", strings.HasPrefix(arg, "--upstream="):
+			value, next, err := flagValue(args, i, "--upstream")
+			if err != nil {
+				return opts, err
+			}
+			i = next
+			upstream, err := url.Parse(value)
+			if err != nil || (upstream.Scheme != "http" && upstream.Scheme != "https") || upstream.Host == "" {
+				return opts, fmt.Errorf("--upstream must be an http or https URL")
+			}
+			opts.Recorder.Upstream = upstream
+		case arg == "--dir", strings.HasPrefix(arg, "--dir="):
+			value, next, err := flagValue(args, i, "--dir")
+			if err != nil {
+				return opts, err
+			}
+			i = next
+			if value == "" {
+				return opts, fmt.Errorf("--dir value cannot be empty")
+			}
+			opts.Recorder.Dir = filepath.Clean(value)
+		case strings.HasPrefix(arg, "-"):
+			return opts, fmt.Errorf("unknown flag %q", arg)
+		default:
+			return opts, fmt.Errorf("unexpected argument %q", arg)
+		}
+	}
+
+	if opts.Recorder.Upstream == nil {
+		return opts, fmt.Errorf("--upstream is required")
+	}
+	return opts, nil
+}
+
+// newRecorder applies wirepad.yaml's history body limit and logs each
+// exchange to stdout.
+func newRecorder(opts recordOptions, stdout io.Writer, stderr io.Writer) (*recorder.Recorder, error) {
+	project, warnings, err := requestspec.LoadProject(requestspec.ProjectFile)
+	if err != nil {
+		return nil, err
+	}
+	printWarnings(stderr, warnings)
+	opts.Recorder.MaxBodyBytes = history.DefaultMaxBodyBytes
+	if project.History != nil {
+		opts.Recorder.MaxBodyBytes = int64(project.History.MaxBodyBytes)
+	}
+
+	opts.Recorder.Log = &lockedWriter{w: stdout}
+	return recorder.New(opts.Recorder)
+}
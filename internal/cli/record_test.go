@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecord_CapturesSpecsThatSend(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"id":"`+strings.TrimPrefix(r.URL.Path, "/users/")+`"}`)
+	}))
+	defer upstream.Close()
+
+	withTempWorkingDir(t, func(root string) {
+		opts, err := parseRecordOptions([]string{"--upstream", upstream.URL, "--dir", "captured", "--listen", "127.0.0.1:0"})
+		if err != nil {
+			t.Fatalf("parseRecordOptions returned error: %v", err)
+		}
+
+		var out bytes.Buffer
+		var errOut bytes.Buffer
+		handler, err := newRecorder(opts, &out, &errOut)
+		if err != nil {
+			t.Fatalf("newRecorder returned error: %v", err)
+		}
+		proxy := httptest.NewServer(handler)
+		defer proxy.Close()
+
+		resp, err := http.Get(proxy.URL + "/users/42")
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != `{"id":"42"}` {
+			t.Fatalf("unexpected proxied body %q", body)
+		}
+		if !strings.Contains(out.String(), "GET    /users/42 -> 200  captured/users/get.req.yaml  new") {
+			t.Fatalf("expected exchange log, got %q", out.String())
+		}
+
+		out.Reset()
+		code := runSend([]string{filepath.Join(root, "captured", "users", "get.req.yaml"), "--var", "base_url=" + upstream.URL, "--var", "user_id=7"}, &out, &errOut)
+		if code != 0 {
+			t.Fatalf("send of recorded spec exited %d: %s%s", code, out.String(), errOut.String())
+		}
+	})
+
+	for _, args := range [][]string{{}, {"--upstream", "ftp://example.com"}, {"--upstream", "http://x", "--listen", "8888"}, {"--upstream", "http://x", "extra"}} {
+		if _, err := parseRecordOptions(args); err == nil {
+			t.Fatalf("expected error for %v", args)
+		}
+	}
+}
@@ -3,10 +3,13 @@ package cli
 import (
 	"fmt"
 	"io"
+
+	"github.com/jaykbpark/wirepad/internal/config"
 )
 
 // Execute dispatches CLI arguments and returns a process exit code.
 func Execute(args []string, stdout io.Writer, stderr io.Writer) int {
+	config.SetWarningOutput(stderr)
 	if len(args) == 0 {
 		printRootUsage(stdout)
 		return 0
@@ -32,6 +35,16 @@ func Execute(args []string, stdout io.Writer, stderr io.Writer) int {
 		return runReq(rest, stdout, stderr)
 	case "send":
 		return runSend(rest, stdout, stderr)
+	case "run":
+		return runRun(rest, stdout, stderr)
+	case "test":
+		return runTest(rest, stdout, stderr)
+	case "bench":
+		return runBench(rest, stdout, stderr)
+	case "mock":
+		return runMock(rest, stdout, stderr)
+	case "record":
+		return runRecord(rest, stdout, stderr)
 	case "hist":
 		return runHist(rest, stdout, stderr)
 	case "diff":
@@ -40,6 +53,10 @@ func Execute(args []string, stdout io.Writer, stderr io.Writer) int {
 		return runReplay(rest, stdout, stderr)
 	case "ws":
 		return runWS(rest, stdout, stderr)
+	case "env":
+		return runEnv(rest, stdout, stderr)
+	case "cookies":
+		return runCookies(rest, stdout, stderr)
 	default:
 		fmt.Fprintf(stderr, "unknown command %q\n\n", cmd)
 		printRootUsage(stderr)
@@ -54,12 +71,19 @@ func printRootUsage(out io.Writer) {
 	fmt.Fprintln(out, "  wirepad <command> [args]")
 	fmt.Fprintln(out)
 	fmt.Fprintln(out, "Commands:")
-	fmt.Fprintln(out, "  req     Manage request specs")
-	fmt.Fprintln(out, "  send    Execute HTTP request specs")
-	fmt.Fprintln(out, "  hist    Show run history")
-	fmt.Fprintln(out, "  diff    Compare run results")
-	fmt.Fprintln(out, "  replay  Replay a previous run")
-	fmt.Fprintln(out, "  ws      WebSocket workflows")
+	fmt.Fprintln(out, "  req      Manage request specs")
+	fmt.Fprintln(out, "  send     Execute HTTP request specs")
+	fmt.Fprintln(out, "  run      Run a .flow.yaml of chained requests")
+	fmt.Fprintln(out, "  test     Run request specs as a test suite")
+	fmt.Fprintln(out, "  bench    Load test a request spec")
+	fmt.Fprintln(out, "  mock     Serve responses from request specs and history")
+	fmt.Fprintln(out, "  record   Capture proxied traffic into request specs")
+	fmt.Fprintln(out, "  hist     Show run history")
+	fmt.Fprintln(out, "  diff     Compare run results")
+	fmt.Fprintln(out, "  replay   Replay a previous run")
+	fmt.Fprintln(out, "  ws       WebSocket workflows")
+	fmt.Fprintln(out, "  env      Manage environments")
+	fmt.Fprintln(out, "  cookies  Inspect and clear stored cookies")
 	fmt.Fprintln(out)
 	fmt.Fprintln(out, "Run 'wirepad <command> --help' for details.")
 }
@@ -0,0 +1,459 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jaykbpark/wirepad/internal/assert"
+	"github.com/jaykbpark/wirepad/internal/config"
+	"github.com/jaykbpark/wirepad/internal/grpcclient"
+	"github.com/jaykbpark/wirepad/internal/history"
+	"github.com/jaykbpark/wirepad/internal/requestspec"
+)
+
+func runRun(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		printRunUsage(stderr)
+		return 2
+	}
+	if wantsHelp(args) {
+		printRunUsage(stdout)
+		return 0
+	}
+
+	opts, err := parseRequestFlags(args)
+	switch {
+	case err != nil:
+	case opts.RequestRef == "":
+		err = fmt.Errorf("missing <flow>")
+	case opts.Stream || opts.OutputPath != "" || opts.Timing || opts.DataPath != "":
+		err = fmt.Errorf("--stream, --max-events, --until, --output, --timing and --data are not supported by run")
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "run argument error: %v\n", err)
+		printRunUsage(stderr)
+		return 2
+	}
+
+	flowPath, err := requestspec.ResolveFlowPath(opts.RequestRef)
+	if err != nil {
+		fmt.Fprintf(stderr, "resolve flow: %v\n", err)
+		return 1
+	}
+
+	flow, warnings, err := requestspec.LoadFlow(flowPath, requestspec.LoadOptions{Strict: opts.Strict})
+	if err != nil {
+		var validationErr *requestspec.ValidationError
+		if errors.As(err, &validationErr) {
+			fmt.Fprintln(stderr, validationErr.Error())
+			return 1
+		}
+		fmt.Fprintf(stderr, "load flow: %v\n", err)
+		return 1
+	}
+	printWarnings(stderr, warnings)
+
+	// A flow sends each step once, so a spec's dataset is refused up front rather
+	// than silently reduced to a single request.
+	for _, step := range flow.Steps {
+		requestPath, err := requestspec.ResolvePath(step.Request)
+		if err != nil {
+			continue // reported when the step runs
+		}
+		if dataPath, err := resolveDataPath(requestPath, ""); err == nil && dataPath != "" {
+			fmt.Fprintf(stderr, "flow step %q: %s is data-driven (data: %s) but flows send each step once; loop over the rows with the step's for_each instead\n", step.Name, requestPath, dataPath)
+			return 1
+		}
+	}
+
+	env, err := config.ResolveVariables(config.ResolveOptions{EnvName: opts.EnvName, CLI: opts.Vars})
+	if err != nil {
+		fmt.Fprintf(stderr, "resolve variables: %v\n", err)
+		return 1
+	}
+
+	runner := &flowRunner{opts: opts, env: env, scope: make(map[string]string), out: stdout, stderr: stderr}
+	for key, value := range flow.Vars {
+		if _, ok := opts.Vars[key]; ok {
+			continue
+		}
+		resolved, err := config.InterpolateString(value, env)
+		if err != nil {
+			fmt.Fprintf(stderr, "interpolate flow vars.%s: %v\n", key, err)
+			return 1
+		}
+		runner.scope[key] = resolved
+	}
+	maps.Copy(runner.scope, opts.Vars)
+
+	started := time.Now().UTC()
+	runner.record = history.FlowRecord{
+		RunID:     history.NewRunID(started),
+		FlowName:  flow.Name,
+		FlowPath:  flowPath,
+		Env:       opts.EnvName,
+		StartedAt: started.Format("2006-01-02T15:04:05Z07:00"),
+		OK:        true,
+	}
+	if !opts.JSONOutput {
+		fmt.Fprintf(stdout, "Flow %s (%d steps)\n", flow.Name, len(flow.Steps))
+	}
+	for _, step := range flow.Steps {
+		if !runner.runStep(step) {
+			break
+		}
+	}
+	runner.record.DurationMS = time.Since(started).Milliseconds()
+
+	historyPath, err := history.SaveFlowRun(runner.record)
+	if err != nil {
+		fmt.Fprintf(stderr, "save flow history: %v\n", err)
+		return 1
+	}
+
+	if opts.JSONOutput {
+		payload, err := json.MarshalIndent(map[string]any{
+			"run_id":       runner.record.RunID,
+			"flow":         runner.record.FlowName,
+			"ok":           runner.record.OK,
+			"duration_ms":  runner.record.DurationMS,
+			"steps":        runner.record.Steps,
+			"history_path": historyPath,
+		}, "", "  ")
+		if err != nil {
+			return 1
+		}
+		fmt.Fprintln(stdout, string(payload))
+	} else {
+		printFlowSummary(stdout, runner.record, historyPath)
+	}
+
+	if !runner.record.OK {
+		return 1
+	}
+	return 0
+}
+
+func printRunUsage(out io.Writer) {
+	writeSimpleUsage(out, "wirepad run <flow> [--env <name>] [--var key=value] [--proxy <url>] [--cookies] [--strict] [--json]")
+}
+
+// flowRunner executes flow steps in order. scope holds flow vars, --var
+// values, exports and steps.<name>.* results; it overrides env.
+type flowRunner struct {
+	opts   sendOptions
+	env    map[string]string
+	scope  map[string]string
+	out    io.Writer
+	stderr io.Writer
+	record history.FlowRecord
+}
+
+// runStep runs one step, once per for_each item, and reports whether the
+// flow should go on.
+func (r *flowRunner) runStep(step requestspec.FlowStep) bool {
+	vars := r.lookup()
+	if step.If != "" {
+		condition, err := config.InterpolateString(step.If, vars)
+		if err != nil {
+			return r.fail(history.FlowStepRun{Name: step.Name, Request: step.Request}, step, fmt.Errorf("if: %w", err))
+		}
+		if !flowCondition(condition) {
+			r.record.Steps = append(r.record.Steps, history.FlowStepRun{Name: step.Name, Request: step.Request, Skipped: true, OK: true})
+			r.scope["steps."+step.Name+".ok"] = "false"
+			r.scope["steps."+step.Name+".skipped"] = "true"
+			if !r.opts.JSONOutput {
+				fmt.Fprintf(r.out, "  skip  %s (if: %s)\n", step.Name, condition)
+			}
+			return true
+		}
+	}
+
+	if step.ForEach == nil {
+		return r.runOnce(step, nil, nil)
+	}
+	items, err := loopItems(step.ForEach, vars)
+	if err != nil {
+		return r.fail(history.FlowStepRun{Name: step.Name, Request: step.Request}, step, fmt.Errorf("for_each: %w", err))
+	}
+	for i, item := range items {
+		iteration := i
+		if !r.runOnce(step, &iteration, item) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *flowRunner) runOnce(step requestspec.FlowStep, iteration *int, item any) bool {
+	entry := history.FlowStepRun{Name: step.Name, Request: step.Request, Iteration: iteration, ContinueOnError: step.ContinueOnError}
+
+	vars := r.lookup()
+	stepVars := maps.Clone(r.scope)
+	if iteration != nil {
+		for key, value := range itemVars(item, *iteration) {
+			vars[key] = value
+			stepVars[key] = value
+		}
+	}
+	for key, value := range step.Vars {
+		resolved, err := config.InterpolateString(value, vars)
+		if err != nil {
+			return r.fail(entry, step, fmt.Errorf("vars.%s: %w", key, err))
+		}
+		stepVars[key] = resolved
+	}
+
+	requestPath, err := requestspec.ResolvePath(step.Request)
+	if err != nil {
+		return r.fail(entry, step, fmt.Errorf("resolve request: %w", err))
+	}
+
+	// Exports come from response bodies, so they join the --var layer, whose
+	// values are never resolved as ${secret:...} references.
+	opts := r.opts
+	opts.Vars = stepVars
+	run, err := executeRequest(requestPath, opts, io.Discard, r.stderr)
+	if err != nil {
+		return r.fail(entry, step, err)
+	}
+
+	entry.RunID = run.record.RunID
+	entry.RunPath = run.historyPath
+	entry.Status = run.record.Status
+	entry.DurationMS = run.record.DurationMS
+	_, failed := assert.Counts(run.results)
+	entry.OK = failed == 0
+
+	prefix := "steps." + step.Name + "."
+	r.scope[prefix+"status"] = strconv.Itoa(run.record.Status)
+	r.scope[prefix+"ok"] = strconv.FormatBool(entry.OK)
+	r.scope[prefix+"run_id"] = run.record.RunID
+
+	for _, name := range sortedStringKeys(step.Export) {
+		value, err := exportValue(run, step.Export[name])
+		if err != nil {
+			entry.OK = false
+			entry.Error = fmt.Sprintf("export %s: %v", name, err)
+			break
+		}
+		if config.IsSensitiveKey(name) {
+			config.MarkSecret(value)
+		}
+		r.scope[name] = value
+		if entry.Exports == nil {
+			entry.Exports = make(map[string]string)
+		}
+		entry.Exports[name] = config.RedactString(value)
+	}
+
+	r.printStep(entry, run)
+	r.record.Steps = append(r.record.Steps, entry)
+	if !entry.OK && !step.ContinueOnError {
+		r.record.OK = false
+		return false
+	}
+	return true
+}
+
+// fail records a step that could not be sent.
+func (r *flowRunner) fail(entry history.FlowStepRun, step requestspec.FlowStep, err error) bool {
+	entry.OK = false
+	entry.Error = config.RedactString(err.Error())
+	r.record.Steps = append(r.record.Steps, entry)
+	r.scope["steps."+step.Name+".ok"] = "false"
+	if !r.opts.JSONOutput {
+		fmt.Fprintf(r.out, "  FAIL  %s%s: %s\n", step.Name, iterationLabel(entry.Iteration), entry.Error)
+	}
+	if step.ContinueOnError {
+		return true
+	}
+	r.record.OK = false
+	return false
+}
+
+func (r *flowRunner) printStep(entry history.FlowStepRun, run *requestRun) {
+	if r.opts.JSONOutput {
+		return
+	}
+	status := "ok  "
+	if !entry.OK {
+		status = "FAIL"
+	}
+	fmt.Fprintf(r.out, "  %s  %s%s  %s (%dms)\n", status, entry.Name, iterationLabel(entry.Iteration), describeRun(run), entry.DurationMS)
+	for _, result := range assert.Failures(run.results) {
+		fmt.Fprintf(r.out, "          %s\n", config.RedactString(result.String()))
+	}
+	if entry.Error != "" {
+		fmt.Fprintf(r.out, "          %s\n", entry.Error)
+	}
+}
+
+// lookup returns the variables visible to flow expressions.
+func (r *flowRunner) lookup() map[string]string {
+	vars := maps.Clone(r.env)
+	maps.Copy(vars, r.scope)
+	return vars
+}
+
+func describeRun(run *requestRun) string {
+	if run.grpc != nil {
+		return fmt.Sprintf("GRPC %s %s", run.grpc.Method, grpcclient.CodeName(run.grpc.Code))
+	}
+	return fmt.Sprintf("%s %d %s", strings.ToUpper(run.spec.Request.Method), run.record.Status, http.StatusText(run.record.Status))
+}
+
+func iterationLabel(iteration *int) string {
+	if iteration == nil {
+		return ""
+	}
+	return fmt.Sprintf("[%d]", *iteration)
+}
+
+func printFlowSummary(out io.Writer, record history.FlowRecord, historyPath string) {
+	passed, failed, skipped := 0, 0, 0
+	for _, step := range record.Steps {
+		switch {
+		case step.Skipped:
+			skipped++
+		case step.OK:
+			passed++
+		default:
+			failed++
+		}
+	}
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "Steps: %d passed, %d failed, %d skipped\n", passed, failed, skipped)
+	fmt.Fprintf(out, "Duration: %dms\n", record.DurationMS)
+	fmt.Fprintf(out, "Run ID: %s\n", record.RunID)
+	fmt.Fprintf(out, "History: %s\n", historyPath)
+}
+
+// flowCondition evaluates an interpolated if: expression. "a == b" and
+// "a != b" compare the trimmed, unquoted sides; anything else is true unless
+// it is empty, false, 0 or null.
+func flowCondition(expr string) bool {
+	if left, right, ok := strings.Cut(expr, "!="); ok {
+		return conditionOperand(left) != conditionOperand(right)
+	}
+	if left, right, ok := strings.Cut(expr, "=="); ok {
+		return conditionOperand(left) == conditionOperand(right)
+	}
+	switch strings.ToLower(conditionOperand(expr)) {
+	case "", "false", "0", "null":
+		return false
+	}
+	return true
+}
+
+func conditionOperand(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// loopItems expands for_each: a list has its strings interpolated, and a
+// string must interpolate to a JSON array.
+func loopItems(value any, vars map[string]string) ([]any, error) {
+	switch typed := value.(type) {
+	case []any:
+		items := make([]any, len(typed))
+		copy(items, typed)
+		if err := config.InterpolateAny(&items, vars); err != nil {
+			return nil, err
+		}
+		return items, nil
+	case string:
+		resolved, err := config.InterpolateString(typed, vars)
+		if err != nil {
+			return nil, err
+		}
+		var items []any
+		if err := json.Unmarshal([]byte(resolved), &items); err != nil {
+			return nil, fmt.Errorf("%q is not a JSON array", resolved)
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("expected a list or a JSON array string")
+	}
+}
+
+// itemVars exposes a loop item as {{item}} and {{index}}; object items also
+// get {{item.<key>}} for each top-level field.
+func itemVars(item any, index int) map[string]string {
+	vars := map[string]string{
+		"item":  exportString(item),
+		"index": strconv.Itoa(index),
+	}
+	if object, ok := item.(map[string]any); ok {
+		for key, value := range object {
+			vars["item."+key] = exportString(value)
+		}
+	}
+	return vars
+}
+
+// exportValue reads a step export from a run: a JSONPath into the response
+// body, headers.<name>, or status.
+func exportValue(run *requestRun, source string) (string, error) {
+	switch {
+	case source == "status":
+		return strconv.Itoa(run.record.Status), nil
+	case strings.HasPrefix(source, "headers."):
+		name := strings.TrimPrefix(source, "headers.")
+		for key, value := range run.headers {
+			if strings.EqualFold(key, name) {
+				return value, nil
+			}
+		}
+		return "", fmt.Errorf("response has no %s header", name)
+	}
+
+	var doc any
+	if err := json.Unmarshal(run.body, &doc); err != nil {
+		return "", fmt.Errorf("response body is not JSON")
+	}
+	value, ok, err := assert.Lookup(doc, source)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", fmt.Errorf("%s not found in response", source)
+	}
+	return exportString(value), nil
+}
+
+// exportString renders a JSON value as a variable: strings as-is, everything
+// else as JSON.
+func exportString(value any) string {
+	switch typed := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return typed
+	}
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(payload)
+}
+
+func sortedStringKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
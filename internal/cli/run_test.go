@@ -0,0 +1,279 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestExecute_RunFlowPassesExportsBetweenSteps(t *testing.T) {
+	withTempWorkingDir(t, func(root string) {
+		var mu sync.Mutex
+		var calls []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			calls = append(calls, r.Method+" "+r.URL.Path+" "+r.Header.Get("Authorization"))
+			mu.Unlock()
+			w.Header().Set("Content-Type", "application/json")
+			switch {
+			case r.URL.Path == "/login":
+				_, _ = io.WriteString(w, `{"token":"t-123"}`)
+			case r.Method == http.MethodPost && r.URL.Path == "/users":
+				w.Header().Set("Location", "/users/u_1")
+				w.WriteHeader(http.StatusCreated)
+				_, _ = io.WriteString(w, `{"id":"u_1","tags":[{"name":"a"},{"name":"b"}]}`)
+			case r.Header.Get("Authorization") != "Bearer t-123":
+				w.WriteHeader(http.StatusUnauthorized)
+			case r.Method == http.MethodDelete:
+				w.WriteHeader(http.StatusNoContent)
+			default:
+				_, _ = io.WriteString(w, `{"id":"u_1"}`)
+			}
+		}))
+		defer server.Close()
+
+		writeFile(t, filepath.Join(root, "env", "dev.env"), "base_url="+server.URL+"\n")
+		writeFile(t, filepath.Join(root, "requests", "auth", "login.req.yaml"), `
+version: 1
+kind: http
+name: auth.login
+request:
+  method: POST
+  url: "{{base_url}}/login"
+  body:
+    mode: json
+    json:
+      user: "{{username}}"
+`)
+		writeFile(t, filepath.Join(root, "requests", "users", "create.req.yaml"), `
+version: 1
+kind: http
+name: users.create
+request:
+  method: POST
+  url: "{{base_url}}/users"
+  headers:
+    Authorization: "Bearer {{token}}"
+expect:
+  status: 201
+`)
+		writeFile(t, filepath.Join(root, "requests", "users", "get.req.yaml"), `
+version: 1
+kind: http
+name: users.get
+request:
+  method: GET
+  url: "{{base_url}}{{path}}"
+  headers:
+    Authorization: "Bearer {{token}}"
+expect:
+  status: 200
+`)
+		writeFile(t, filepath.Join(root, "requests", "users", "delete.req.yaml"), `
+version: 1
+kind: http
+name: users.delete
+request:
+  method: DELETE
+  url: "{{base_url}}/users/{{user_id}}"
+  headers:
+    Authorization: "Bearer {{token}}"
+expect:
+  status: 204
+`)
+		writeFile(t, filepath.Join(root, "flows", "signup.flow.yaml"), `
+version: 1
+name: signup
+vars:
+  username: ada
+steps:
+  - request: auth/login
+    export:
+      token: "$.token"
+  - name: create
+    request: users/create
+    export:
+      user_id: "$.id"
+      location: headers.location
+      tags: "$.tags"
+  - name: fetch
+    request: users/get
+    if: "{{steps.create.status}} == 201"
+    vars:
+      path: "{{location}}"
+  - name: per-tag
+    request: users/get
+    for_each: "{{tags}}"
+    vars:
+      path: "/users/{{user_id}}/tags/{{item.name}}"
+  - name: unauthenticated
+    request: users/get
+    continue_on_error: true
+    vars:
+      path: /users/u_1
+      token: wrong
+  - name: skipped
+    request: users/get
+    if: "{{steps.create.status}} != 201"
+  - name: delete
+    request: users/delete
+`)
+
+		var out bytes.Buffer
+		var errOut bytes.Buffer
+		code := Execute([]string{"run", "signup", "--env", "dev"}, &out, &errOut)
+		if code != 0 {
+			t.Fatalf("expected exit code 0, got %d; stdout=%q stderr=%q", code, out.String(), errOut.String())
+		}
+		want := []string{
+			"POST /login ",
+			"POST /users Bearer t-123",
+			"GET /users/u_1 Bearer t-123",
+			"GET /users/u_1/tags/a Bearer t-123",
+			"GET /users/u_1/tags/b Bearer t-123",
+			"GET /users/u_1 Bearer wrong",
+			"DELETE /users/u_1 Bearer t-123",
+		}
+		if strings.Join(calls, "\n") != strings.Join(want, "\n") {
+			t.Fatalf("unexpected calls:\n%s", strings.Join(calls, "\n"))
+		}
+		for _, line := range []string{"ok    per-tag[1]  GET 200 OK", "FAIL  unauthenticated  GET 401 Unauthorized", "skip  skipped", "Steps: 6 passed, 1 failed, 1 skipped"} {
+			if !strings.Contains(out.String(), line) {
+				t.Fatalf("expected %q in stdout, got %q", line, out.String())
+			}
+		}
+
+		entries, err := os.ReadDir(filepath.Join(root, ".wirepad", "history", "flows"))
+		if err != nil || len(entries) != 1 {
+			t.Fatalf("expected 1 flow record, got %d (err=%v)", len(entries), err)
+		}
+		payload, err := os.ReadFile(filepath.Join(root, ".wirepad", "history", "flows", entries[0].Name()))
+		if err != nil {
+			t.Fatalf("read flow record: %v", err)
+		}
+		var record struct {
+			OK    bool `json:"ok"`
+			Steps []struct {
+				Name    string            `json:"name"`
+				RunPath string            `json:"run_path"`
+				Exports map[string]string `json:"exports"`
+			} `json:"steps"`
+		}
+		if err := json.Unmarshal(payload, &record); err != nil {
+			t.Fatalf("decode flow record: %v", err)
+		}
+		if !record.OK || len(record.Steps) != 8 {
+			t.Fatalf("unexpected flow record %s", payload)
+		}
+		if record.Steps[0].Exports["token"] != "[REDACTED]" || record.Steps[1].Exports["user_id"] != "u_1" {
+			t.Fatalf("expected redacted token and plain user_id exports, got %s", payload)
+		}
+		if _, err := os.Stat(record.Steps[1].RunPath); err != nil {
+			t.Fatalf("expected step run record at %q: %v", record.Steps[1].RunPath, err)
+		}
+	})
+}
+
+func TestExecute_RunFlowStopsAtFirstFailure(t *testing.T) {
+	withTempWorkingDir(t, func(root string) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		writeFile(t, filepath.Join(root, "requests", "ping.req.yaml"), `
+version: 1
+kind: http
+name: ping
+request:
+  method: GET
+  url: "`+server.URL+`"
+expect:
+  status: 200
+`)
+		writeFile(t, filepath.Join(root, "flows", "smoke.flow.yaml"), `
+version: 1
+steps:
+  - name: first
+    request: ping
+  - name: second
+    request: ping
+`)
+
+		var out bytes.Buffer
+		var errOut bytes.Buffer
+		code := Execute([]string{"run", "flows/smoke.flow.yaml"}, &out, &errOut)
+		if code != 1 {
+			t.Fatalf("expected exit code 1, got %d; stdout=%q", code, out.String())
+		}
+		if !strings.Contains(out.String(), "FAIL status equals: expected 200, got 500") || strings.Contains(out.String(), "second") {
+			t.Fatalf("expected the flow to stop after the failed first step, got %q", out.String())
+		}
+	})
+}
+
+func TestExecute_RunFlowSendsHostileExportsAsText(t *testing.T) {
+	withTempWorkingDir(t, func(root string) {
+		marker := filepath.Join(root, "ran")
+		hostile := "${secret:cmd:touch " + marker + "}"
+		var got []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/login" {
+				payload, _ := json.Marshal(map[string]string{"token": hostile})
+				_, _ = w.Write(payload)
+				return
+			}
+			got = append(got, r.Header.Get("X-Token"), r.Header.Get("X-Copy"))
+		}))
+		defer server.Close()
+
+		writeFile(t, filepath.Join(root, "requests", "login.req.yaml"), `
+version: 1
+kind: http
+name: login
+request:
+  method: POST
+  url: "`+server.URL+`/login"
+`)
+		writeFile(t, filepath.Join(root, "requests", "use.req.yaml"), `
+version: 1
+kind: http
+name: use
+request:
+  method: GET
+  url: "`+server.URL+`/use"
+  headers:
+    X-Token: "{{token}}"
+    X-Copy: "{{copy}}"
+`)
+		writeFile(t, filepath.Join(root, "flows", "hostile.flow.yaml"), `
+version: 1
+steps:
+  - request: login
+    export:
+      token: "$.token"
+  - request: use
+    vars:
+      copy: "{{token}}"
+`)
+
+		var out bytes.Buffer
+		var errOut bytes.Buffer
+		if code := Execute([]string{"run", "hostile"}, &out, &errOut); code != 0 {
+			t.Fatalf("expected exit code 0, got %d; stdout=%q stderr=%q", code, out.String(), errOut.String())
+		}
+		if len(got) != 2 || got[0] != hostile || got[1] != hostile {
+			t.Fatalf("expected the exported reference to be sent as text, got %q", got)
+		}
+		if _, err := os.Stat(marker); !os.IsNotExist(err) {
+			t.Fatalf("an exported secret reference ran a command (stat: %v)", err)
+		}
+	})
+}
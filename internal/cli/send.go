@@ -1,19 +1,32 @@
 package cli
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/jaykbpark/wirepad/internal/assert"
 	"github.com/jaykbpark/wirepad/internal/config"
+	"github.com/jaykbpark/wirepad/internal/cookies"
+	"github.com/jaykbpark/wirepad/internal/grpcclient"
 	"github.com/jaykbpark/wirepad/internal/history"
 	"github.com/jaykbpark/wirepad/internal/httpclient"
 	"github.com/jaykbpark/wirepad/internal/requestspec"
 )
 
+const oauthCacheDir = ".wirepad/oauth"
+
 func runSend(args []string, stdout io.Writer, stderr io.Writer) int {
 	if len(args) == 0 {
 		printSendUsage(stderr)
@@ -37,91 +50,340 @@ func runSend(args []string, stdout io.Writer, stderr io.Writer) int {
 		return 1
 	}
 
-	loadResult, err := requestspec.LoadFile(requestPath, requestspec.LoadOptions{Strict: opts.Strict})
+	dataPath, err := resolveDataPath(requestPath, opts.DataPath)
 	if err != nil {
-		var validationErr *requestspec.ValidationError
-		if errors.As(err, &validationErr) {
-			fmt.Fprintln(stderr, validationErr.Error())
-			return 1
-		}
 		fmt.Fprintf(stderr, "load request: %v\n", err)
 		return 1
 	}
+	if dataPath != "" {
+		return runSendData(requestPath, dataPath, opts, stdout, stderr)
+	}
 
-	for _, warning := range loadResult.Warnings {
-		fmt.Fprintf(stderr, "warning: %s: %s", warning.Field, warning.Message)
-		if warning.Hint != "" {
-			fmt.Fprintf(stderr, " (hint: %s)", warning.Hint)
+	run, err := executeRequest(requestPath, opts, stdout, stderr)
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+
+	passed, failed := assert.Counts(run.results)
+	if opts.JSONOutput {
+		if code := printSendJSON(stdout, run.record, run.historyPath); code != 0 {
+			return code
 		}
-		fmt.Fprintln(stderr)
+	} else {
+		if run.grpc != nil {
+			printGRPCHuman(stdout, run.grpc, run.record, run.historyPath, run.streamed)
+		} else {
+			printSendHuman(stdout, run.spec.Request.Method, run.http, run.record, run.historyPath, opts)
+		}
+		printAssertions(stdout, run.results, passed, failed)
 	}
 
-	spec := loadResult.Spec
-	if spec.Kind != requestspec.KindHTTP {
-		fmt.Fprintf(stderr, "wirepad send currently supports only kind=http, got %q\n", spec.Kind)
+	if failed > 0 {
 		return 1
 	}
+	return 0
+}
 
-	vars, err := config.ResolveVariables(config.ResolveOptions{
-		EnvName: opts.EnvName,
-		CLI:     opts.Vars,
-	})
+// requestRun is an executed request spec whose run record has been saved.
+type requestRun struct {
+	spec        *requestspec.Spec
+	record      history.RunRecord
+	historyPath string
+	results     []assert.Result
+	// body and headers are the full response, before history truncation and
+	// redaction.
+	body    []byte
+	headers map[string]string
+
+	http     *httpclient.Response
+	grpc     *grpcclient.Response
+	streamed bool
+}
+
+// executeRequest loads, sends and records the spec at requestPath. Streamed
+// output (SSE events, streamed bodies, gRPC stream messages) goes to live;
+// warnings and notices go to stderr. The returned error is ready to print.
+func executeRequest(requestPath string, opts sendOptions, live io.Writer, stderr io.Writer) (*requestRun, error) {
+	spec, project, err := loadRequest(requestPath, opts, stderr)
 	if err != nil {
-		fmt.Fprintf(stderr, "resolve variables: %v\n", err)
-		return 1
+		return nil, err
+	}
+	if spec.Kind != requestspec.KindHTTP && spec.Kind != requestspec.KindGRPC {
+		return nil, fmt.Errorf("wirepad send currently supports kind=http and kind=grpc, got %q", spec.Kind)
 	}
 
-	if err := config.InterpolateAny(spec, vars); err != nil {
-		fmt.Fprintf(stderr, "interpolate request variables: %v\n", err)
-		return 1
+	maxBodyBytes := int64(history.DefaultMaxBodyBytes)
+	if project.History != nil {
+		maxBodyBytes = int64(project.History.MaxBodyBytes)
+	}
+	if spec.Kind == requestspec.KindGRPC {
+		return executeGRPC(spec, requestPath, opts, maxBodyBytes, live)
+	}
+
+	runID := history.NewRunID(time.Now().UTC())
+	execOpts := httpclient.Options{OAuthCachePath: oauthCachePath(opts.EnvName)}
+	if opts.session != nil {
+		execOpts.OAuthSources = opts.session.oauth
+	}
+	var streamer *sseStreamer
+	if opts.Stream {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		streamer = &sseStreamer{out: live, runID: runID, json: opts.JSONOutput, maxEvents: opts.MaxEvents, until: opts.Until}
+		execOpts.Context = ctx
+		execOpts.Stream = true
+		execOpts.OnEvent = streamer.handle
+		execOpts.MaxBodyBytes = maxBodyBytes
+		if opts.OutputPath == "" && !opts.JSONOutput {
+			execOpts.Output = live
+		}
+	}
+	var jar *cookies.Jar
+	if opts.Cookies || (spec.Request.Cookies != nil && *spec.Request.Cookies) {
+		if opts.session != nil {
+			jar, err = opts.session.jar(cookies.Path(opts.EnvName))
+		} else {
+			jar, err = cookies.Load(cookies.Path(opts.EnvName))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("load cookies: %w", err)
+		}
+		execOpts.Jar = jar
+	}
+	var progress *progressPrinter
+	if opts.OutputPath != "" {
+		file, err := os.Create(opts.OutputPath)
+		if err != nil {
+			return nil, fmt.Errorf("create output file: %w", err)
+		}
+		defer file.Close()
+		progress = &progressPrinter{out: stderr}
+		execOpts.Output = file
+		execOpts.Progress = progress.update
+		execOpts.MaxBodyBytes = maxBodyBytes
 	}
 
-	resp, err := httpclient.ExecuteHTTP(spec, requestPath)
+	resp, err := httpclient.ExecuteHTTPWithOptions(spec, requestPath, execOpts)
+	if progress != nil {
+		progress.done()
+	}
+	transcriptPath := ""
+	if streamer != nil {
+		transcriptPath = streamer.close()
+		if streamer.err != nil {
+			return nil, fmt.Errorf("record event stream: %w", streamer.err)
+		}
+	}
 	if err != nil {
-		fmt.Fprintf(stderr, "send request: %v\n", err)
-		return 1
+		var retryErr *httpclient.RetryError
+		if errors.As(err, &retryErr) {
+			saveFailedRun(stderr, history.RunRecord{
+				RunID:       runID,
+				RequestName: spec.Name,
+				RequestPath: requestPath,
+				Env:         opts.EnvName,
+				StartedAt:   retryErr.Attempts[0].StartedAt.Format("2006-01-02T15:04:05Z07:00"),
+				Proxy:       httpclient.RedactProxy(spec.Request.Proxy),
+				Attempts:    historyAttempts(retryErr.Attempts),
+				Error:       config.RedactString(retryErr.Err.Error()),
+			})
+		}
+		return nil, fmt.Errorf("send request: %s", config.RedactString(err.Error()))
 	}
 
+	if jar != nil {
+		if err := jar.Save(); err != nil {
+			return nil, fmt.Errorf("save cookies: %w", err)
+		}
+	}
+
+	results := assert.Evaluate(spec.Expect, assert.Subject{
+		Status:     resp.StatusCode,
+		Protocol:   resp.Proto,
+		Headers:    flattenHeaders(resp.Headers),
+		Body:       resp.Body,
+		DurationMS: resp.Duration.Milliseconds(),
+		Timing:     resp.Timing.Milliseconds(),
+		GraphQL:    spec.Request.Body != nil && spec.Request.Body.Mode == "graphql",
+	})
+	_, failed := assert.Counts(results)
+
+	historyBody, truncated := history.TruncateBody(resp.Body, maxBodyBytes)
 	record := history.RunRecord{
-		RunID:           history.NewRunID(resp.StartedAt),
+		RunID:           runID,
 		RequestName:     spec.Name,
 		RequestPath:     requestPath,
 		Env:             opts.EnvName,
 		StartedAt:       resp.StartedAt.Format("2006-01-02T15:04:05Z07:00"),
 		DurationMS:      resp.Duration.Milliseconds(),
-		OK:              true,
+		OK:              failed == 0,
 		Status:          resp.StatusCode,
-		ResponseHeaders: flattenHeaders(resp.Headers),
-		ResponseBody:    string(resp.Body),
+		Protocol:        resp.Proto,
+		ResponseHeaders: config.RedactHeaders(flattenHeaders(resp.Headers)),
+		ResponseBody:    config.RedactString(historyBody),
+		OutputPath:      opts.OutputPath,
+		Transcript:      transcriptPath,
+		TLS:             historyTLS(resp.TLS),
+		Proxy:           httpclient.RedactProxy(spec.Request.Proxy),
+		Timing:          historyTiming(resp.Timing),
+		Assertions:      historyAssertions(results),
+		DataRow:         opts.DataRow,
+	}
+	if truncated || resp.BodyTruncated {
+		record.ResponseBodyBytes = resp.BodySize
+		record.ResponseBodyTruncated = true
+	}
+	if resp.ContentEncoding != "" {
+		record.ResponseEncoding = resp.ContentEncoding
+		record.ResponseEncodedBytes = resp.EncodedSize
+		record.ResponseBodyBytes = resp.BodySize
+		if !resp.Decoded {
+			record.ResponseBody = ""
+			record.ResponseBodyEncoded = true
+			record.ResponseBodyTruncated = false
+		}
+	}
+	if streamer != nil {
+		record.Events = streamer.count
+	}
+	if spec.Request.Retry != nil || len(resp.Attempts) > 1 {
+		record.Attempts = historyAttempts(resp.Attempts)
+	}
+	if jar != nil {
+		record.Cookies = &history.CookieLog{
+			Sent:     historyCookies(resp.CookiesSent),
+			Received: historyCookies(resp.CookiesReceived),
+		}
 	}
 
 	historyPath, err := history.SaveRun(record)
 	if err != nil {
-		fmt.Fprintf(stderr, "save run history: %v\n", err)
-		return 1
+		return nil, fmt.Errorf("save run history: %w", err)
 	}
 
-	if opts.JSONOutput {
-		return printSendJSON(stdout, record, historyPath)
+	return &requestRun{
+		spec:        spec,
+		record:      record,
+		historyPath: historyPath,
+		results:     results,
+		body:        resp.Body,
+		headers:     flattenHeaders(resp.Headers),
+		http:        resp,
+		streamed:    opts.Stream,
+	}, nil
+}
+
+// loadRequest loads the spec at requestPath and applies project defaults,
+// --proxy and variables. The returned error is ready to print.
+func loadRequest(requestPath string, opts sendOptions, stderr io.Writer) (*requestspec.Spec, *requestspec.Project, error) {
+	loadResult, err := requestspec.LoadFile(requestPath, requestspec.LoadOptions{Strict: opts.Strict})
+	if err != nil {
+		var validationErr *requestspec.ValidationError
+		if errors.As(err, &validationErr) {
+			return nil, nil, validationErr
+		}
+		return nil, nil, fmt.Errorf("load request: %w", err)
 	}
 
-	printSendHuman(stdout, spec.Request.Method, resp, record.RunID, historyPath)
-	return 0
+	printWarnings(stderr, loadResult.Warnings)
+
+	spec := loadResult.Spec
+	project, projectWarnings, err := requestspec.LoadProject(requestspec.ProjectFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	printWarnings(stderr, projectWarnings)
+	project.Apply(spec)
+	if opts.Proxy != "" {
+		spec.Request.Proxy = opts.Proxy
+	}
+
+	vars, err := config.ResolveVariables(config.ResolveOptions{
+		EnvName: opts.EnvName,
+		CLI:     opts.Vars,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("resolve variables: %w", err)
+	}
+
+	if err := config.InterpolateAny(spec, vars); err != nil {
+		return nil, nil, fmt.Errorf("interpolate request variables: %w", err)
+	}
+
+	markProxySecret(spec.Request.Proxy)
+	markAuthSecrets(spec.Request.Auth)
+	return spec, project, nil
 }
 
 func printSendUsage(out io.Writer) {
-	writeSimpleUsage(out, "wirepad send <request> [--env <name>] [--var key=value] [--strict] [--json]")
+	writeSimpleUsage(out, "wirepad send <request> [--env <name>] [--var key=value] [--proxy <url>] [--timing] [--stream] [--max-events <n>] [--until <jsonpath>] [--output <file>] [--data <file>] [--cookies] [--strict] [--json]")
 }
 
 type sendOptions struct {
 	RequestRef string
 	EnvName    string
 	Vars       map[string]string
+	Proxy      string
+	Timing     bool
+	Stream     bool
+	MaxEvents  int
+	Until      string
+	OutputPath string
+	Cookies    bool
 	Strict     bool
 	JSONOutput bool
+	// DataPath is the --data dataset; DataRow is the row being sent.
+	DataPath string
+	DataRow  *int
+
+	// session, when set, shares cookie jars and OAuth2 tokens with the other
+	// requests of the same command.
+	session *sendSession
+}
+
+// sendSession is the state shared by requests sent concurrently, so they see
+// each other's cookies and fetch one OAuth2 token per client.
+type sendSession struct {
+	oauth *httpclient.OAuthSources
+
+	mu   sync.Mutex
+	jars map[string]*cookies.Jar
+}
+
+func newSendSession() *sendSession {
+	return &sendSession{oauth: httpclient.NewOAuthSources(), jars: make(map[string]*cookies.Jar)}
+}
+
+// jar returns the cookie jar at path, loading it on first use.
+func (s *sendSession) jar(path string) (*cookies.Jar, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if jar, ok := s.jars[path]; ok {
+		return jar, nil
+	}
+	jar, err := cookies.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	s.jars[path] = jar
+	return jar, nil
 }
 
 func parseSendOptions(args []string) (sendOptions, error) {
+	opts, err := parseRequestFlags(args)
+	if err != nil {
+		return opts, err
+	}
+	if opts.RequestRef == "" {
+		return opts, fmt.Errorf("missing <request>")
+	}
+	return opts, nil
+}
+
+// parseRequestFlags reads the flags shared by send and run; the one
+// positional argument lands in RequestRef.
+func parseRequestFlags(args []string) (sendOptions, error) {
 	var opts sendOptions
 	opts.Vars = make(map[string]string)
 
@@ -159,6 +421,63 @@ func parseSendOptions(args []string) (sendOptions, error) {
 				return opts, err
 			}
 			opts.Vars[key] = value
+		case arg == "--proxy":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("--proxy requires a value")
+			}
+			i++
+			opts.Proxy = strings.TrimSpace(args[i])
+		case strings.HasPrefix(arg, "--proxy="):
+			opts.Proxy = strings.TrimSpace(strings.TrimPrefix(arg, "--proxy="))
+		case arg == "--timing":
+			opts.Timing = true
+		case arg == "--stream":
+			opts.Stream = true
+		case arg == "--max-events", strings.HasPrefix(arg, "--max-events="):
+			value, next, err := flagValue(args, i, "--max-events")
+			if err != nil {
+				return opts, err
+			}
+			i = next
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return opts, fmt.Errorf("--max-events must be a positive integer")
+			}
+			opts.MaxEvents = n
+			opts.Stream = true
+		case arg == "--until", strings.HasPrefix(arg, "--until="):
+			value, next, err := flagValue(args, i, "--until")
+			if err != nil {
+				return opts, err
+			}
+			i = next
+			if _, _, err := assert.Lookup(nil, value); err != nil {
+				return opts, fmt.Errorf("--until: %w", err)
+			}
+			opts.Until = value
+			opts.Stream = true
+		case arg == "--output", strings.HasPrefix(arg, "--output="):
+			value, next, err := flagValue(args, i, "--output")
+			if err != nil {
+				return opts, err
+			}
+			i = next
+			if value == "" {
+				return opts, fmt.Errorf("--output value cannot be empty")
+			}
+			opts.OutputPath = value
+		case arg == "--data", strings.HasPrefix(arg, "--data="):
+			value, next, err := flagValue(args, i, "--data")
+			if err != nil {
+				return opts, err
+			}
+			i = next
+			if value == "" {
+				return opts, fmt.Errorf("--data value cannot be empty")
+			}
+			opts.DataPath = value
+		case arg == "--cookies":
+			opts.Cookies = true
 		case arg == "--strict":
 			opts.Strict = true
 		case arg == "--json":
@@ -173,13 +492,21 @@ func parseSendOptions(args []string) (sendOptions, error) {
 		}
 	}
 
-	if opts.RequestRef == "" {
-		return opts, fmt.Errorf("missing <request>")
-	}
-
 	return opts, nil
 }
 
+// flagValue returns the value of a flag given as "--name value" or
+// "--name=value" and the index of the last argument consumed.
+func flagValue(args []string, i int, name string) (string, int, error) {
+	if value, ok := strings.CutPrefix(args[i], name+"="); ok {
+		return strings.TrimSpace(value), i, nil
+	}
+	if i+1 >= len(args) {
+		return "", i, fmt.Errorf("%s requires a value", name)
+	}
+	return strings.TrimSpace(args[i+1]), i + 1, nil
+}
+
 func parseVarPair(value string) (string, string, error) {
 	parts := strings.SplitN(value, "=", 2)
 	if len(parts) != 2 {
@@ -192,6 +519,149 @@ func parseVarPair(value string) (string, string, error) {
 	return key, parts[1], nil
 }
 
+func printWarnings(stderr io.Writer, warnings []requestspec.Issue) {
+	for _, warning := range warnings {
+		fmt.Fprintf(stderr, "warning: %s: %s", warning.Field, warning.Message)
+		if warning.Hint != "" {
+			fmt.Fprintf(stderr, " (hint: %s)", warning.Hint)
+		}
+		fmt.Fprintln(stderr)
+	}
+}
+
+// markProxySecret keeps proxy credentials out of output and history.
+func markProxySecret(proxy string) {
+	proxyURL, err := url.Parse(proxy)
+	if err != nil || proxyURL.User == nil {
+		return
+	}
+	if password, ok := proxyURL.User.Password(); ok {
+		config.MarkSecret(password)
+	}
+}
+
+// oauthCachePath is where OAuth2 tokens for an environment are cached.
+func oauthCachePath(envName string) string {
+	if envName == "" {
+		envName = "default"
+	}
+	return filepath.Join(oauthCacheDir, envName+".json")
+}
+
+// markAuthSecrets keeps request.auth credentials out of output and history.
+func markAuthSecrets(auth *requestspec.Auth) {
+	if auth == nil {
+		return
+	}
+	for _, value := range []string{auth.Password, auth.Token, auth.SecretAccessKey, auth.SessionToken, auth.ClientSecret, auth.RefreshToken} {
+		config.MarkSecret(value)
+	}
+	if auth.Type == "api_key" {
+		config.MarkSecret(auth.Value)
+	}
+}
+
+func historyCookies(list []*http.Cookie) []history.Cookie {
+	out := make([]history.Cookie, 0, len(list))
+	for _, cookie := range list {
+		entry := history.Cookie{
+			Name:     cookie.Name,
+			Value:    config.Redacted,
+			Domain:   cookie.Domain,
+			Path:     cookie.Path,
+			MaxAge:   cookie.MaxAge,
+			Secure:   cookie.Secure,
+			HTTPOnly: cookie.HttpOnly,
+		}
+		if !cookie.Expires.IsZero() {
+			entry.Expires = cookie.Expires.UTC().Format(time.RFC3339)
+		}
+		out = append(out, entry)
+	}
+	return out
+}
+
+func historyAttempts(attempts []httpclient.Attempt) []history.Attempt {
+	out := make([]history.Attempt, 0, len(attempts))
+	for _, attempt := range attempts {
+		out = append(out, history.Attempt{
+			Attempt:    attempt.Number,
+			StartedAt:  attempt.StartedAt.Format(time.RFC3339Nano),
+			DurationMS: attempt.Duration.Milliseconds(),
+			Status:     attempt.Status,
+			Error:      config.RedactString(attempt.Error),
+			DelayMS:    attempt.Delay.Milliseconds(),
+		})
+	}
+	return out
+}
+
+// saveFailedRun records a run that produced no response so its attempts
+// remain inspectable.
+func saveFailedRun(stderr io.Writer, record history.RunRecord) {
+	historyPath, err := history.SaveRun(record)
+	if err != nil {
+		fmt.Fprintf(stderr, "save run history: %v\n", err)
+		return
+	}
+	fmt.Fprintf(stderr, "History: %s\n", historyPath)
+}
+
+func historyTiming(timing httpclient.Timing) *history.Timing {
+	ms := timing.Milliseconds()
+	return &history.Timing{
+		DNSMS:            ms["dns_ms"],
+		ConnectMS:        ms["connect_ms"],
+		TLSMS:            ms["tls_ms"],
+		TTFBMS:           ms["ttfb_ms"],
+		TransferMS:       ms["transfer_ms"],
+		TotalMS:          ms["total_ms"],
+		ConnectionReused: timing.ConnReused,
+	}
+}
+
+func historyAssertions(results []assert.Result) *history.Assertions {
+	if len(results) == 0 {
+		return nil
+	}
+	passed, failed := assert.Counts(results)
+	out := &history.Assertions{Passed: passed, Failed: failed}
+	for _, result := range assert.Failures(results) {
+		out.Failures = append(out.Failures, history.AssertionFailure{
+			Path:     result.Path,
+			Operator: result.Operator,
+			Expected: result.Expected,
+			Actual:   redactValue(result.Actual),
+			Message:  result.Message,
+		})
+	}
+	return out
+}
+
+func redactValue(value any) any {
+	if s, ok := value.(string); ok {
+		return config.RedactString(s)
+	}
+	return value
+}
+
+func historyTLS(info *httpclient.TLSInfo) *history.TLSInfo {
+	if info == nil {
+		return nil
+	}
+	out := &history.TLSInfo{
+		Version:     info.Version,
+		CipherSuite: info.CipherSuite,
+		ServerName:  info.ServerName,
+		CertSubject: info.CertSubject,
+		CertIssuer:  info.CertIssuer,
+	}
+	if !info.CertNotAfter.IsZero() {
+		out.CertNotAfter = info.CertNotAfter.Format(time.RFC3339)
+	}
+	return out
+}
+
 func flattenHeaders(headers http.Header) map[string]string {
 	out := make(map[string]string, len(headers))
 	for key, values := range headers {
@@ -203,18 +673,103 @@ func flattenHeaders(headers http.Header) map[string]string {
 	return out
 }
 
-func printSendHuman(out io.Writer, method string, resp *httpclient.Response, runID string, historyPath string) {
+func printSendHuman(out io.Writer, method string, resp *httpclient.Response, record history.RunRecord, historyPath string, opts sendOptions) {
+	if opts.Stream {
+		fmt.Fprintln(out)
+	}
 	fmt.Fprintf(out, "%s %d %s\n", strings.ToUpper(method), resp.StatusCode, http.StatusText(resp.StatusCode))
 	fmt.Fprintf(out, "Duration: %dms\n", resp.Duration.Milliseconds())
-	fmt.Fprintf(out, "Run ID: %s\n", runID)
+	if resp.Proto != "" && resp.Proto != "HTTP/1.1" {
+		fmt.Fprintf(out, "Protocol: %s\n", resp.Proto)
+	}
+	if opts.Timing {
+		printTiming(out, resp.Timing)
+	}
+	if resp.TLS != nil {
+		fmt.Fprintf(out, "TLS: %s, %s", resp.TLS.Version, resp.TLS.CipherSuite)
+		if resp.TLS.CertSubject != "" {
+			fmt.Fprintf(out, ", cert %q expires %s", resp.TLS.CertSubject, resp.TLS.CertNotAfter.Format("2006-01-02"))
+		}
+		fmt.Fprintln(out)
+	}
+	if len(record.Attempts) > 1 {
+		fmt.Fprintf(out, "Attempts: %d (%s)\n", len(record.Attempts), describeAttempts(record.Attempts))
+	}
+	if record.Cookies != nil {
+		fmt.Fprintf(out, "Cookies: %d sent, %d received\n", len(record.Cookies.Sent), len(record.Cookies.Received))
+	}
+	if record.Transcript != "" {
+		fmt.Fprintf(out, "Events: %d (transcript: %s)\n", record.Events, record.Transcript)
+	}
+	if record.OutputPath != "" {
+		fmt.Fprintf(out, "Saved: %s (%s)\n", record.OutputPath, formatBytes(resp.BodySize))
+	}
+	if resp.ContentEncoding != "" {
+		if resp.Decoded {
+			fmt.Fprintf(out, "Encoding: %s (%s on the wire, %s decoded)\n", resp.ContentEncoding, formatBytes(resp.EncodedSize), formatBytes(resp.BodySize))
+		} else {
+			fmt.Fprintf(out, "Encoding: %s (not supported, body left encoded, %s)\n", resp.ContentEncoding, formatBytes(resp.EncodedSize))
+		}
+	}
+	if record.ResponseBodyTruncated {
+		fmt.Fprintf(out, "History body: first %s of %s kept\n", formatBytes(int64(len(record.ResponseBody))), formatBytes(record.ResponseBodyBytes))
+	}
+	fmt.Fprintf(out, "Run ID: %s\n", record.RunID)
 	fmt.Fprintf(out, "History: %s\n", historyPath)
 
-	if len(resp.Body) == 0 {
+	// Streamed and saved bodies were already delivered.
+	if len(resp.Body) == 0 || opts.Stream || opts.OutputPath != "" {
+		return
+	}
+	if resp.ContentEncoding != "" && !resp.Decoded {
+		fmt.Fprintf(out, "\n[%s-encoded body not shown; use --output to save it]\n", resp.ContentEncoding)
 		return
 	}
 
 	fmt.Fprintln(out)
-	fmt.Fprintln(out, prettyBody(resp.Body))
+	fmt.Fprintln(out, config.RedactString(prettyBody(resp.Body)))
+}
+
+func describeAttempts(attempts []history.Attempt) string {
+	parts := make([]string, 0, len(attempts))
+	for _, attempt := range attempts {
+		if attempt.Error != "" {
+			parts = append(parts, "error")
+			continue
+		}
+		parts = append(parts, strconv.Itoa(attempt.Status))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func printTiming(out io.Writer, timing httpclient.Timing) {
+	connection := "new"
+	if timing.ConnReused {
+		connection = "reused"
+	}
+	fmt.Fprintln(out, "Timing:")
+	fmt.Fprintf(out, "  DNS lookup:     %s\n", formatPhase(timing.DNS))
+	fmt.Fprintf(out, "  TCP connect:    %s\n", formatPhase(timing.Connect))
+	fmt.Fprintf(out, "  TLS handshake:  %s\n", formatPhase(timing.TLS))
+	fmt.Fprintf(out, "  TTFB:           %s\n", formatPhase(timing.TTFB))
+	fmt.Fprintf(out, "  Transfer:       %s\n", formatPhase(timing.Transfer))
+	fmt.Fprintf(out, "  Total:          %s\n", formatPhase(timing.Total))
+	fmt.Fprintf(out, "  Connection:     %s\n", connection)
+}
+
+func formatPhase(d time.Duration) string {
+	return fmt.Sprintf("%.1fms", float64(d.Microseconds())/1000)
+}
+
+func printAssertions(out io.Writer, results []assert.Result, passed, failed int) {
+	if len(results) == 0 {
+		return
+	}
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "Assertions: %d passed, %d failed\n", passed, failed)
+	for _, result := range assert.Failures(results) {
+		fmt.Fprintf(out, "  %s\n", config.RedactString(result.String()))
+	}
 }
 
 func printSendJSON(out io.Writer, record history.RunRecord, historyPath string) int {
@@ -223,9 +778,29 @@ func printSendJSON(out io.Writer, record history.RunRecord, historyPath string)
 		"request":      record.RequestName,
 		"ok":           record.OK,
 		"status":       record.Status,
+		"protocol":     record.Protocol,
 		"duration_ms":  record.DurationMS,
 		"history_path": historyPath,
 	}
+	if record.Assertions != nil {
+		envelope["assertions"] = record.Assertions
+	}
+	if record.Timing != nil {
+		envelope["timing"] = record.Timing
+	}
+	if record.Transcript != "" {
+		envelope["events"] = record.Events
+		envelope["transcript"] = record.Transcript
+	}
+	if record.OutputPath != "" {
+		envelope["output_path"] = record.OutputPath
+	}
+	if len(record.Attempts) > 0 {
+		envelope["attempts"] = record.Attempts
+	}
+	if record.GRPC != nil {
+		envelope["grpc"] = record.GRPC
+	}
 
 	payload, err := json.MarshalIndent(envelope, "", "  ")
 	if err != nil {
@@ -3,6 +3,7 @@ package cli
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -86,6 +87,174 @@ request:
 	})
 }
 
+func TestExecute_SendTimingAndFailedAssertions(t *testing.T) {
+	withTempWorkingDir(t, func(root string) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = io.WriteString(w, `{"count":0}`)
+		}))
+		defer server.Close()
+
+		writeFile(t, filepath.Join(root, "requests", "stats.req.yaml"), `
+version: 1
+kind: http
+name: stats
+request:
+  method: GET
+  url: "`+server.URL+`/stats"
+expect:
+  status: 200
+  body:
+    jsonpath:
+      "$.count":
+        gt: 0
+  timing:
+    total_ms: 60000
+`)
+
+		var out bytes.Buffer
+		var errOut bytes.Buffer
+		code := Execute([]string{"send", "stats", "--timing"}, &out, &errOut)
+		if code != 1 {
+			t.Fatalf("expected exit code 1 for failed assertion, got %d; stderr=%q", code, errOut.String())
+		}
+
+		stdout := out.String()
+		for _, want := range []string{"TTFB:", "Connection:     new", "Assertions: 2 passed, 1 failed", "FAIL body.jsonpath.$.count gt"} {
+			if !strings.Contains(stdout, want) {
+				t.Fatalf("expected %q in stdout, got %q", want, stdout)
+			}
+		}
+
+		entries, err := os.ReadDir(filepath.Join(root, ".wirepad", "history", "runs"))
+		if err != nil || len(entries) != 1 {
+			t.Fatalf("expected 1 run history file, got %d (err=%v)", len(entries), err)
+		}
+		payload, err := os.ReadFile(filepath.Join(root, ".wirepad", "history", "runs", entries[0].Name()))
+		if err != nil {
+			t.Fatalf("read run history: %v", err)
+		}
+		var record map[string]any
+		if err := json.Unmarshal(payload, &record); err != nil {
+			t.Fatalf("decode run history: %v", err)
+		}
+		if record["ok"] != false {
+			t.Fatalf("expected failed run to be recorded as not ok, got %v", record["ok"])
+		}
+		timing, _ := record["timing"].(map[string]any)
+		if _, ok := timing["ttfb_ms"]; !ok {
+			t.Fatalf("expected timing in run history, got %v", record["timing"])
+		}
+	})
+}
+
+func TestExecute_SendStreamsEventsToTranscript(t *testing.T) {
+	withTempWorkingDir(t, func(root string) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			flusher := w.(http.Flusher)
+			for _, data := range []string{`{"step":1}`, `{"step":2,"done":true}`, `{"step":3}`} {
+				fmt.Fprintf(w, "event: progress\ndata: %s\n\n", data)
+				flusher.Flush()
+			}
+			<-r.Context().Done()
+		}))
+		defer server.Close()
+
+		writeFile(t, filepath.Join(root, "requests", "events.req.yaml"), `
+version: 1
+kind: http
+name: events
+request:
+  method: GET
+  url: "`+server.URL+`"
+`)
+
+		var out bytes.Buffer
+		var errOut bytes.Buffer
+		code := Execute([]string{"send", "events", "--until", "$.done"}, &out, &errOut)
+		if code != 0 {
+			t.Fatalf("expected exit code 0, got %d; stderr=%q", code, errOut.String())
+		}
+
+		stdout := out.String()
+		if !strings.Contains(stdout, "[2] event=progress") || strings.Contains(stdout, `{"step":3}`) {
+			t.Fatalf("expected stream to stop after the done event, got %q", stdout)
+		}
+		if !strings.Contains(stdout, "Events: 2 (transcript: .wirepad/transcripts/") {
+			t.Fatalf("expected transcript summary, got %q", stdout)
+		}
+
+		matches, err := filepath.Glob(filepath.Join(root, ".wirepad", "transcripts", "*.sse.ndjson"))
+		if err != nil || len(matches) != 1 {
+			t.Fatalf("expected one SSE transcript, got %v (err=%v)", matches, err)
+		}
+		transcript, err := os.ReadFile(matches[0])
+		if err != nil {
+			t.Fatalf("read transcript: %v", err)
+		}
+		lines := strings.Split(strings.TrimSpace(string(transcript)), "\n")
+		if len(lines) != 2 || !strings.Contains(lines[1], `"event":"progress"`) {
+			t.Fatalf("unexpected transcript %q", transcript)
+		}
+	})
+}
+
+func TestExecute_SendOutputFileCapsHistoryBody(t *testing.T) {
+	withTempWorkingDir(t, func(root string) {
+		payload := strings.Repeat("0123456789", 100)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, payload)
+		}))
+		defer server.Close()
+
+		writeFile(t, filepath.Join(root, "wirepad.yaml"), `
+version: 1
+history:
+  max_body_bytes: 64
+`)
+		writeFile(t, filepath.Join(root, "requests", "download.req.yaml"), `
+version: 1
+kind: http
+name: download
+request:
+  method: GET
+  url: "`+server.URL+`"
+`)
+
+		var out bytes.Buffer
+		var errOut bytes.Buffer
+		code := Execute([]string{"send", "download", "--output", "download.bin", "--json"}, &out, &errOut)
+		if code != 0 {
+			t.Fatalf("expected exit code 0, got %d; stderr=%q", code, errOut.String())
+		}
+		if !strings.Contains(errOut.String(), "Downloading: 1000 B / 1000 B (100%)") {
+			t.Fatalf("expected final progress line on stderr, got %q", errOut.String())
+		}
+
+		saved, err := os.ReadFile(filepath.Join(root, "download.bin"))
+		if err != nil || string(saved) != payload {
+			t.Fatalf("expected full body in output file, got %d bytes (err=%v)", len(saved), err)
+		}
+
+		var envelope map[string]any
+		if err := json.Unmarshal(out.Bytes(), &envelope); err != nil {
+			t.Fatalf("decode json output: %v", err)
+		}
+		payloadRecord, err := os.ReadFile(asString(envelope["history_path"]))
+		if err != nil {
+			t.Fatalf("read run history: %v", err)
+		}
+		var record map[string]any
+		if err := json.Unmarshal(payloadRecord, &record); err != nil {
+			t.Fatalf("decode run history: %v", err)
+		}
+		if len(asString(record["response_body"])) != 64 || record["response_body_truncated"] != true || record["response_body_bytes"] != float64(1000) {
+			t.Fatalf("expected history body capped at 64 of 1000 bytes, got %v", record)
+		}
+	})
+}
+
 func withTempWorkingDir(t *testing.T, fn func(root string)) {
 	t.Helper()
 	previous, err := os.Getwd()
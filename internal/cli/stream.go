@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jaykbpark/wirepad/internal/assert"
+	"github.com/jaykbpark/wirepad/internal/config"
+	"github.com/jaykbpark/wirepad/internal/history"
+	"github.com/jaykbpark/wirepad/internal/httpclient"
+)
+
+// sseStreamer prints Server-Sent Events as they arrive and appends them to a
+// transcript named after the run.
+type sseStreamer struct {
+	out       io.Writer
+	runID     string
+	json      bool
+	maxEvents int
+	until     string
+
+	count      int
+	transcript *history.Transcript
+	err        error
+}
+
+func (s *sseStreamer) handle(event httpclient.SSEEvent) bool {
+	if s.transcript == nil {
+		transcript, err := history.CreateTranscript(s.runID + ".sse")
+		if err != nil {
+			s.err = err
+			return false
+		}
+		s.transcript = transcript
+	}
+
+	s.count++
+	entry := history.SSEEntry{
+		TS:    event.ReceivedAt.Format(time.RFC3339Nano),
+		ID:    event.ID,
+		Event: event.Event,
+		Data:  config.RedactString(event.Data),
+		Retry: event.Retry,
+	}
+	if err := s.transcript.Append(entry); err != nil {
+		s.err = err
+		return false
+	}
+	s.print(entry)
+
+	if s.maxEvents > 0 && s.count >= s.maxEvents {
+		return false
+	}
+	return s.until == "" || !untilMatched(s.until, event.Data)
+}
+
+func (s *sseStreamer) print(entry history.SSEEntry) {
+	if s.json {
+		payload, err := json.Marshal(entry)
+		if err == nil {
+			fmt.Fprintln(s.out, string(payload))
+		}
+		return
+	}
+
+	fmt.Fprintf(s.out, "[%d]", s.count)
+	if entry.Event != "" {
+		fmt.Fprintf(s.out, " event=%s", entry.Event)
+	}
+	if entry.ID != "" {
+		fmt.Fprintf(s.out, " id=%s", entry.ID)
+	}
+	fmt.Fprintln(s.out)
+	fmt.Fprintln(s.out, entry.Data)
+}
+
+func (s *sseStreamer) close() string {
+	if s.transcript == nil {
+		return ""
+	}
+	_ = s.transcript.Close()
+	return s.transcript.Path()
+}
+
+// untilMatched reports whether data is JSON with a non-null, non-false value
+// at path.
+func untilMatched(path, data string) bool {
+	var doc any
+	if err := json.Unmarshal([]byte(data), &doc); err != nil {
+		return false
+	}
+	value, ok, err := assert.Lookup(doc, path)
+	if err != nil || !ok {
+		return false
+	}
+	return value != nil && value != false
+}
+
+// progressPrinter renders a single-line download indicator, redrawn at most
+// every 100ms.
+type progressPrinter struct {
+	out      io.Writer
+	last     time.Time
+	received int64
+	total    int64
+}
+
+func (p *progressPrinter) update(received, total int64) {
+	p.received, p.total = received, total
+	if time.Since(p.last) < 100*time.Millisecond {
+		return
+	}
+	p.last = time.Now()
+	p.draw()
+}
+
+func (p *progressPrinter) done() {
+	if p.received == 0 && p.last.IsZero() {
+		return
+	}
+	p.draw()
+	fmt.Fprintln(p.out)
+}
+
+func (p *progressPrinter) draw() {
+	if p.total > 0 {
+		fmt.Fprintf(p.out, "\rDownloading: %s / %s (%d%%)", formatBytes(p.received), formatBytes(p.total), p.received*100/p.total)
+		return
+	}
+	fmt.Fprintf(p.out, "\rDownloading: %s", formatBytes(p.received))
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value := float64(n)
+	for _, suffix := range []string{"KiB", "MiB", "GiB"} {
+		value /= unit
+		if value < unit || suffix == "GiB" {
+			return fmt.Sprintf("%.1f %s", value, suffix)
+		}
+	}
+	return fmt.Sprintf("%d B", n)
+}
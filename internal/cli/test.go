@@ -0,0 +1,426 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jaykbpark/wirepad/internal/assert"
+	"github.com/jaykbpark/wirepad/internal/config"
+	"github.com/jaykbpark/wirepad/internal/report"
+	"github.com/jaykbpark/wirepad/internal/requestspec"
+)
+
+func runTest(args []string, stdout io.Writer, stderr io.Writer) int {
+	if wantsHelp(args) {
+		printTestUsage(stdout)
+		return 0
+	}
+
+	opts, err := parseTestOptions(args)
+	if err != nil {
+		fmt.Fprintf(stderr, "test argument error: %v\n", err)
+		printTestUsage(stderr)
+		return 2
+	}
+
+	cases, err := collectSuite(opts.Paths, opts.Tags)
+	if err != nil {
+		fmt.Fprintf(stderr, "collect requests: %v\n", err)
+		return 1
+	}
+	if len(cases) == 0 {
+		fmt.Fprintln(stderr, "no requests matched")
+		return 1
+	}
+
+	started := time.Now()
+	runSuite(cases, opts, stdout, &lockedWriter{w: stderr})
+	suite := buildReport(cases, started, time.Since(started))
+	passed, failed, skipped := suite.Counts()
+
+	if !opts.quiet() {
+		fmt.Fprintln(stdout)
+		fmt.Fprintf(stdout, "Tests: %d passed, %d failed, %d skipped\n", passed, failed, skipped)
+		fmt.Fprintf(stdout, "Duration: %dms\n", suite.Duration.Milliseconds())
+	}
+
+	code := 0
+	for _, target := range opts.Reports {
+		if err := writeReport(target, suite, stdout); err != nil {
+			fmt.Fprintf(stderr, "write %s report: %v\n", target.Format, err)
+			code = 1
+		}
+	}
+	if failed > 0 {
+		code = 1
+	}
+	return code
+}
+
+func printTestUsage(out io.Writer) {
+	writeSimpleUsage(out, "wirepad test [<dir|file>...] [--tag <tag>] [--concurrency <n>] [--env <name>] [--var key=value] [--proxy <url>] [--strict] [--report <format>[=<file>]] [--json]")
+}
+
+type testOptions struct {
+	// Paths are directories or .req.yaml files; requests/ when empty.
+	Paths []string
+	// Tags selects requests carrying any of them; empty selects all.
+	Tags        []string
+	Concurrency int
+	Send        sendOptions
+	// Reports are written after the run; --json is --report json.
+	Reports []reportTarget
+}
+
+// reportTarget is one --report value. An empty Path writes to stdout.
+type reportTarget struct {
+	Format string
+	Path   string
+}
+
+// quiet reports whether the progress lines are suppressed because a report
+// is written to stdout.
+func (o testOptions) quiet() bool {
+	return slices.ContainsFunc(o.Reports, func(target reportTarget) bool { return target.Path == "" })
+}
+
+func parseTestOptions(args []string) (testOptions, error) {
+	opts := testOptions{Concurrency: 1}
+	opts.Send.Vars = make(map[string]string)
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		switch {
+		case arg == "--env", strings.HasPrefix(arg, "--env="):
+			value, next, err := flagValue(args, i, "--env")
+			if err != nil {
+				return opts, err
+			}
+			i = next
+			if value == "" {
+				return opts, fmt.Errorf("--env value cannot be empty")
+			}
+			opts.Send.EnvName = value
+		case arg == "--var", strings.HasPrefix(arg, "--var="):
+			value, next, err := flagValue(args, i, "--var")
+			if err != nil {
+				return opts, err
+			}
+			i = next
+			key, val, err := parseVarPair(value)
+			if err != nil {
+				return opts, err
+			}
+			opts.Send.Vars[key] = val
+		case arg == "--proxy", strings.HasPrefix(arg, "--proxy="):
+			value, next, err := flagValue(args, i, "--proxy")
+			if err != nil {
+				return opts, err
+			}
+			i = next
+			opts.Send.Proxy = value
+		case arg == "--tag", strings.HasPrefix(arg, "--tag="):
+			value, next, err := flagValue(args, i, "--tag")
+			if err != nil {
+				return opts, err
+			}
+			i = next
+			if value == "" {
+				return opts, fmt.Errorf("--tag value cannot be empty")
+			}
+			opts.Tags = append(opts.Tags, value)
+		case arg == "--concurrency", strings.HasPrefix(arg, "--concurrency="):
+			value, next, err := flagValue(args, i, "--concurrency")
+			if err != nil {
+				return opts, err
+			}
+			i = next
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return opts, fmt.Errorf("--concurrency must be a positive integer")
+			}
+			opts.Concurrency = n
+		case arg == "--strict":
+			opts.Send.Strict = true
+		case arg == "--report", strings.HasPrefix(arg, "--report="):
+			value, next, err := flagValue(args, i, "--report")
+			if err != nil {
+				return opts, err
+			}
+			i = next
+			target, err := parseReportTarget(value)
+			if err != nil {
+				return opts, err
+			}
+			opts.Reports = append(opts.Reports, target)
+		case arg == "--json":
+			opts.Reports = append(opts.Reports, reportTarget{Format: "json"})
+		case strings.HasPrefix(arg, "-"):
+			return opts, fmt.Errorf("unknown flag %q", arg)
+		default:
+			opts.Paths = append(opts.Paths, arg)
+		}
+	}
+
+	if len(opts.Paths) == 0 {
+		opts.Paths = []string{requestspec.RequestsDir}
+	}
+	toStdout := 0
+	for _, target := range opts.Reports {
+		if target.Path == "" {
+			toStdout++
+		}
+	}
+	if toStdout > 1 {
+		return opts, fmt.Errorf("only one report can be written to stdout; give the others a file with --report <format>=<file>")
+	}
+	return opts, nil
+}
+
+func parseReportTarget(value string) (reportTarget, error) {
+	format, path, _ := strings.Cut(value, "=")
+	target := reportTarget{Format: strings.ToLower(strings.TrimSpace(format)), Path: strings.TrimSpace(path)}
+	if !slices.Contains(report.Formats, target.Format) {
+		return target, fmt.Errorf("unknown report format %q (expected %s)", format, strings.Join(report.Formats, ", "))
+	}
+	if strings.Contains(value, "=") && target.Path == "" {
+		return target, fmt.Errorf("--report %s= needs a file path", target.Format)
+	}
+	return target, nil
+}
+
+// suiteCase is one request file in a test run.
+type suiteCase struct {
+	path string
+	name string
+	// skip is why the case was not run.
+	skip string
+	// err is set when the request could not be loaded or sent.
+	err error
+	run *requestRun
+	// row is the dataset row sent with vars, for specs with data:.
+	row  *int
+	vars map[string]string
+}
+
+func (c *suiteCase) failed() bool {
+	if c.skip != "" {
+		return false
+	}
+	if c.err != nil {
+		return true
+	}
+	_, failed := assert.Counts(c.run.results)
+	return failed > 0
+}
+
+// collectSuite walks paths like ResolvePath walks requests/ and keeps the
+// files whose tags match. Files that cannot be parsed are kept so they fail.
+func collectSuite(paths []string, tags []string) ([]*suiteCase, error) {
+	files, err := requestFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	var cases []*suiteCase
+	for _, path := range files {
+		c := &suiteCase{path: path, name: path}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			c.err = fmt.Errorf("read request file: %w", err)
+			cases = append(cases, c)
+			continue
+		}
+		spec, _, err := requestspec.Parse(data)
+		if err != nil {
+			c.err = fmt.Errorf("load request: %w", err)
+			cases = append(cases, c)
+			continue
+		}
+		if len(tags) > 0 && !slices.ContainsFunc(tags, func(tag string) bool { return slices.Contains(spec.Tags, tag) }) {
+			continue
+		}
+		if spec.Name != "" {
+			c.name = spec.Name
+		}
+		if spec.Kind == requestspec.KindWS {
+			c.skip = "kind=ws is not run by wirepad test"
+		} else if dataPath := specDataPath(path, spec); dataPath != "" {
+			// A data-driven spec is one case per row, as with send.
+			rows, err := config.LoadDataRows(dataPath)
+			if err != nil {
+				c.err = fmt.Errorf("load data: %w", err)
+				cases = append(cases, c)
+				continue
+			}
+			for i, row := range rows {
+				index := i
+				cases = append(cases, &suiteCase{path: path, name: fmt.Sprintf("%s[%d]", c.name, i), row: &index, vars: row})
+			}
+			continue
+		}
+		cases = append(cases, c)
+	}
+	return cases, nil
+}
+
+// requestFiles expands directories to the .req.yaml files under them and
+// drops duplicates, keeping discovery order.
+func requestFiles(paths []string) ([]string, error) {
+	var out []string
+	seen := make(map[string]bool)
+	for _, root := range paths {
+		info, err := os.Stat(root)
+		if err != nil {
+			return nil, err
+		}
+		files := []string{root}
+		if info.IsDir() {
+			files, err = requestspec.FindFiles(root)
+			if err != nil {
+				return nil, fmt.Errorf("walk %s: %w", root, err)
+			}
+		} else if !strings.HasSuffix(root, ".req.yaml") {
+			return nil, fmt.Errorf("%s is not a .req.yaml file", root)
+		}
+
+		for _, path := range files {
+			path = filepath.Clean(path)
+			if !seen[path] {
+				seen[path] = true
+				out = append(out, path)
+			}
+		}
+	}
+	return out, nil
+}
+
+// runSuite sends cases on opts.Concurrency workers and prints each result
+// in discovery order as soon as it and every case before it are done.
+func runSuite(cases []*suiteCase, opts testOptions, stdout io.Writer, stderr io.Writer) {
+	// Cases share one cookie jar and OAuth2 token per client, so concurrent
+	// cases neither drop each other's cookies nor fetch a token each.
+	opts.Send.session = newSendSession()
+
+	done := make([]chan struct{}, len(cases))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for range min(opts.Concurrency, len(cases)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				c := cases[i]
+				if c.skip == "" && c.err == nil {
+					send := opts.Send
+					if c.row != nil {
+						send = dataRowOptions(send, c.vars, *c.row)
+					}
+					c.run, c.err = executeRequest(c.path, send, io.Discard, stderr)
+				}
+				close(done[i])
+			}
+		}()
+	}
+	go func() {
+		for i := range cases {
+			queue <- i
+		}
+		close(queue)
+	}()
+
+	for i, c := range cases {
+		<-done[i]
+		if !opts.quiet() {
+			printSuiteCase(stdout, c)
+		}
+	}
+	wg.Wait()
+}
+
+func printSuiteCase(out io.Writer, c *suiteCase) {
+	switch {
+	case c.skip != "":
+		fmt.Fprintf(out, "  skip  %s (%s)\n", c.name, c.skip)
+	case c.err != nil:
+		fmt.Fprintf(out, "  FAIL  %s: %s\n", c.name, config.RedactString(c.err.Error()))
+	default:
+		status := "ok  "
+		if c.failed() {
+			status = "FAIL"
+		}
+		fmt.Fprintf(out, "  %s  %s  %s (%dms)\n", status, c.name, describeRun(c.run), c.run.record.DurationMS)
+		for _, result := range assert.Failures(c.run.results) {
+			fmt.Fprintf(out, "          %s\n", config.RedactString(result.String()))
+		}
+	}
+}
+
+func buildReport(cases []*suiteCase, started time.Time, duration time.Duration) report.Suite {
+	suite := report.Suite{Name: "wirepad", StartedAt: started, Duration: duration}
+	for _, c := range cases {
+		entry := report.Case{Name: c.name, Path: filepath.ToSlash(c.path), OK: !c.failed()}
+		switch {
+		case c.skip != "":
+			entry.Skipped = c.skip
+		case c.err != nil:
+			entry.Error = config.RedactString(c.err.Error())
+		default:
+			record := c.run.record
+			entry.Status = record.Status
+			entry.DurationMS = record.DurationMS
+			entry.RunID = record.RunID
+			entry.HistoryPath = c.run.historyPath
+			if record.Assertions != nil {
+				for _, failure := range record.Assertions.Failures {
+					entry.Failures = append(entry.Failures, report.Failure(failure))
+				}
+			}
+		}
+		suite.Cases = append(suite.Cases, entry)
+	}
+	return suite
+}
+
+func writeReport(target reportTarget, suite report.Suite, stdout io.Writer) error {
+	if target.Path == "" {
+		return report.Write(stdout, target.Format, suite)
+	}
+	var buf bytes.Buffer
+	if err := report.Write(&buf, target.Format, suite); err != nil {
+		return err
+	}
+	if dir := filepath.Dir(target.Path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("create report directory: %w", err)
+		}
+	}
+	if err := os.WriteFile(target.Path, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("write %s: %w", target.Path, err)
+	}
+	return nil
+}
+
+// lockedWriter serializes writes from concurrent runs.
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
@@ -0,0 +1,262 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestExecute_TestRunsTaggedRequestsConcurrently(t *testing.T) {
+	withTempWorkingDir(t, func(root string) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/broken" {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = io.WriteString(w, `{"ok":true}`)
+		}))
+		defer server.Close()
+
+		writeFile(t, filepath.Join(root, "env", "stage.env"), "base_url="+server.URL+"\n")
+		for i := range 6 {
+			writeFile(t, filepath.Join(root, "requests", "users", fmt.Sprintf("get-%d.req.yaml", i)), fmt.Sprintf(`
+version: 1
+kind: http
+name: users.get-%d
+tags: [users, smoke]
+request:
+  method: GET
+  url: "{{base_url}}/users/%d"
+expect:
+  status: 200
+  body:
+    jsonpath:
+      "$.ok": true
+`, i, i))
+		}
+		writeFile(t, filepath.Join(root, "requests", "users", "broken.req.yaml"), `
+version: 1
+kind: http
+name: users.broken
+tags: [smoke]
+request:
+  method: GET
+  url: "{{base_url}}/broken"
+expect:
+  status: 200
+`)
+		writeFile(t, filepath.Join(root, "requests", "users", "slow.req.yaml"), `
+version: 1
+kind: http
+name: users.slow
+tags: [nightly]
+request:
+  method: GET
+  url: "{{base_url}}/slow"
+`)
+		writeFile(t, filepath.Join(root, "requests", "users", "feed.req.yaml"), `
+version: 1
+kind: ws
+name: users.feed
+tags: [smoke]
+request:
+  url: "ws://localhost/feed"
+`)
+		writeFile(t, filepath.Join(root, "requests", "orders", "list.req.yaml"), `
+version: 1
+kind: http
+name: orders.list
+tags: [smoke]
+request:
+  method: GET
+  url: "{{base_url}}/orders"
+`)
+
+		var out bytes.Buffer
+		var errOut bytes.Buffer
+		code := Execute([]string{"test", "requests/users", "--tag", "smoke", "--env", "stage", "--concurrency", "4"}, &out, &errOut)
+		if code != 1 {
+			t.Fatalf("expected exit code 1 for the failing request, got %d; stdout=%q stderr=%q", code, out.String(), errOut.String())
+		}
+		stdout := out.String()
+		for _, want := range []string{
+			"  FAIL  users.broken  GET 500 Internal Server Error",
+			"FAIL status equals: expected 200, got 500",
+			"  skip  users.feed (kind=ws is not run by wirepad test)",
+			"  ok    users.get-5  GET 200 OK",
+			"Tests: 6 passed, 1 failed, 1 skipped",
+		} {
+			if !strings.Contains(stdout, want) {
+				t.Fatalf("expected %q in stdout, got %q", want, stdout)
+			}
+		}
+		if strings.Contains(stdout, "users.slow") || strings.Contains(stdout, "orders.list") {
+			t.Fatalf("expected only smoke requests under requests/users, got %q", stdout)
+		}
+		if strings.Index(stdout, "users.broken") > strings.Index(stdout, "users.get-0") {
+			t.Fatalf("expected results in discovery order, got %q", stdout)
+		}
+
+		entries, err := os.ReadDir(filepath.Join(root, ".wirepad", "history", "runs"))
+		if err != nil || len(entries) != 7 {
+			t.Fatalf("expected 7 run records, got %d (err=%v)", len(entries), err)
+		}
+
+		out.Reset()
+		code = Execute([]string{"test", "--tag", "smoke", "--tag", "nightly", "--env", "stage", "--json"}, &out, &errOut)
+		if code != 1 {
+			t.Fatalf("expected exit code 1, got %d", code)
+		}
+		var summary struct {
+			OK      bool `json:"ok"`
+			Passed  int  `json:"passed"`
+			Failed  int  `json:"failed"`
+			Skipped int  `json:"skipped"`
+			Tests   []struct {
+				Name string `json:"name"`
+			} `json:"tests"`
+		}
+		if err := json.Unmarshal(out.Bytes(), &summary); err != nil {
+			t.Fatalf("decode json summary %q: %v", out.String(), err)
+		}
+		if summary.OK || summary.Passed != 8 || summary.Failed != 1 || summary.Skipped != 1 || summary.Tests[0].Name != "orders.list" {
+			t.Fatalf("unexpected summary %+v", summary)
+		}
+
+		out.Reset()
+		errOut.Reset()
+		if code := Execute([]string{"test", "--tag", "missing"}, &out, &errOut); code != 1 || !strings.Contains(errOut.String(), "no requests matched") {
+			t.Fatalf("expected no-match failure, got %d %q", code, errOut.String())
+		}
+	})
+}
+
+func TestExecute_TestWritesReports(t *testing.T) {
+	withTempWorkingDir(t, func(root string) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		writeFile(t, filepath.Join(root, "requests", "users", "get.req.yaml"), `
+version: 1
+kind: http
+name: users.get
+request:
+  method: GET
+  url: "`+server.URL+`/users/1"
+expect:
+  status: 200
+`)
+
+		var out bytes.Buffer
+		var errOut bytes.Buffer
+		code := Execute([]string{"test", "--report", "junit=reports/junit.xml", "--report", "tap"}, &out, &errOut)
+		if code != 1 {
+			t.Fatalf("expected exit code 1, got %d; stderr=%q", code, errOut.String())
+		}
+		if !strings.HasPrefix(out.String(), "TAP version 13\n1..1\nnot ok 1 - users.get\n") {
+			t.Fatalf("expected only TAP on stdout, got %q", out.String())
+		}
+
+		junit, err := os.ReadFile(filepath.Join(root, "reports", "junit.xml"))
+		if err != nil {
+			t.Fatalf("read junit report: %v", err)
+		}
+		for _, want := range []string{
+			`<testcase name="users.get" classname="requests.users"`,
+			`<failure message="status equals: expected 200, got 404" type="equals">`,
+		} {
+			if !strings.Contains(string(junit), want) {
+				t.Fatalf("expected %q in junit report %q", want, junit)
+			}
+		}
+
+		errOut.Reset()
+		if code := Execute([]string{"test", "--report", "tap", "--json"}, &out, &errOut); code != 2 || !strings.Contains(errOut.String(), "only one report can be written to stdout") {
+			t.Fatalf("expected stdout conflict error, got %d %q", code, errOut.String())
+		}
+	})
+}
+
+func TestExecute_TestSharesCookiesAndTokensAcrossConcurrentCases(t *testing.T) {
+	withTempWorkingDir(t, func(root string) {
+		var tokenCalls atomic.Int64
+		// Both cases wait here, so they are in flight together.
+		arrived := make(chan struct{}, 2)
+		release := make(chan struct{})
+		go func() {
+			<-arrived
+			<-arrived
+			close(release)
+		}()
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/token" {
+				tokenCalls.Add(1)
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = io.WriteString(w, `{"access_token":"suite-token","expires_in":3600}`)
+				return
+			}
+			if r.Header.Get("Authorization") != "Bearer suite-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			arrived <- struct{}{}
+			select {
+			case <-release:
+			case <-time.After(5 * time.Second):
+			}
+			name := strings.TrimPrefix(r.URL.Path, "/")
+			http.SetCookie(w, &http.Cookie{Name: name, Value: name + "-value", Path: "/"})
+		}))
+		defer server.Close()
+
+		for _, name := range []string{"first", "second"} {
+			writeFile(t, filepath.Join(root, "requests", name+".req.yaml"), `
+version: 1
+kind: http
+name: `+name+`
+request:
+  method: GET
+  url: "`+server.URL+`/`+name+`"
+  cookies: true
+  auth:
+    type: oauth2
+    grant: client_credentials
+    token_url: "`+server.URL+`/token"
+    client_id: suite
+    client_secret: suite-secret
+expect:
+  status: 200
+`)
+		}
+
+		var out bytes.Buffer
+		var errOut bytes.Buffer
+		if code := Execute([]string{"test", "--env", "dev", "--concurrency", "2"}, &out, &errOut); code != 0 {
+			t.Fatalf("expected exit code 0, got %d; stdout=%q stderr=%q", code, out.String(), errOut.String())
+		}
+		if tokenCalls.Load() != 1 {
+			t.Fatalf("expected one token request for the suite, got %d", tokenCalls.Load())
+		}
+		data, err := os.ReadFile(filepath.Join(root, ".wirepad", "cookies", "dev.json"))
+		if err != nil {
+			t.Fatalf("read cookie jar: %v", err)
+		}
+		for _, want := range []string{"first-value", "second-value"} {
+			if !strings.Contains(string(data), want) {
+				t.Fatalf("expected %s in the saved jar, got %s", want, data)
+			}
+		}
+	})
+}
@@ -0,0 +1,261 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// EncryptedEnvSuffix marks env files that are stored encrypted.
+	EncryptedEnvSuffix = ".enc"
+
+	encryptedEnvMagic    = "wirepad-env v1"
+	kdfPBKDF2            = "pbkdf2-sha256"
+	kdfHKDF              = "hkdf-sha256"
+	pbkdf2Iterations     = 600000
+	maxPBKDF2Iterations  = 10 * pbkdf2Iterations
+	encryptionKeySize    = 32
+	encryptionSaltSize   = 16
+	encryptedLineWidth   = 64
+	defaultEnvKeyFile    = ".wirepad/env.key"
+	envPassphraseVar     = "WIREPAD_ENV_PASSPHRASE"
+	envKeyFileVar        = "WIREPAD_ENV_KEY_FILE"
+	hkdfInfoEncryptedEnv = "wirepad encrypted env"
+)
+
+// EnvKey is the secret used to encrypt and decrypt .env.enc files.
+// Exactly one of Passphrase or KeyFile should be set.
+type EnvKey struct {
+	Passphrase string
+	KeyFile    string
+}
+
+// EnvKeyFromEnvironment picks the key from WIREPAD_ENV_PASSPHRASE,
+// WIREPAD_ENV_KEY_FILE or .wirepad/env.key, in that order.
+func EnvKeyFromEnvironment() EnvKey {
+	if passphrase := os.Getenv(envPassphraseVar); passphrase != "" {
+		return EnvKey{Passphrase: passphrase}
+	}
+	if keyFile := os.Getenv(envKeyFileVar); keyFile != "" {
+		return EnvKey{KeyFile: keyFile}
+	}
+	if _, err := os.Stat(defaultEnvKeyFile); err == nil {
+		return EnvKey{KeyFile: defaultEnvKeyFile}
+	}
+	return EnvKey{}
+}
+
+func (k EnvKey) kdf() (string, error) {
+	switch {
+	case k.Passphrase != "":
+		return kdfPBKDF2, nil
+	case k.KeyFile != "":
+		return kdfHKDF, nil
+	default:
+		return "", fmt.Errorf("no encryption key configured (set %s, %s or create %s)", envPassphraseVar, envKeyFileVar, defaultEnvKeyFile)
+	}
+}
+
+func (k EnvKey) derive(kdf string, salt []byte, iterations int) ([]byte, error) {
+	switch kdf {
+	case kdfPBKDF2:
+		if k.Passphrase == "" {
+			return nil, fmt.Errorf("file was encrypted with a passphrase; set %s", envPassphraseVar)
+		}
+		return derivedPassphraseKey(k.Passphrase, salt, iterations)
+	case kdfHKDF:
+		if k.KeyFile == "" {
+			return nil, fmt.Errorf("file was encrypted with a key file; set %s or create %s", envKeyFileVar, defaultEnvKeyFile)
+		}
+		material, err := os.ReadFile(k.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("read key file %q: %w", k.KeyFile, err)
+		}
+		material = bytes.TrimSpace(material)
+		if len(material) < 16 {
+			return nil, fmt.Errorf("key file %q is too short (need at least 16 bytes)", k.KeyFile)
+		}
+		return hkdf.Key(sha256.New, material, salt, hkdfInfoEncryptedEnv, encryptionKeySize)
+	default:
+		return nil, fmt.Errorf("unsupported kdf %q", kdf)
+	}
+}
+
+// derivedKeys caches PBKDF2 output per passphrase, salt and iteration count
+// so that test, run and bench derive a file's key once per process rather
+// than once per request.
+var derivedKeys = struct {
+	sync.Mutex
+	keys map[[sha256.Size]byte][]byte
+}{keys: make(map[[sha256.Size]byte][]byte)}
+
+func derivedPassphraseKey(passphrase string, salt []byte, iterations int) ([]byte, error) {
+	id := sha256.New()
+	fmt.Fprintf(id, "%d\x00%d\x00%s\x00", iterations, len(passphrase), passphrase)
+	id.Write(salt)
+	var cacheKey [sha256.Size]byte
+	copy(cacheKey[:], id.Sum(nil))
+
+	derivedKeys.Lock()
+	defer derivedKeys.Unlock()
+	if key, ok := derivedKeys.keys[cacheKey]; ok {
+		return key, nil
+	}
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, iterations, encryptionKeySize)
+	if err != nil {
+		return nil, err
+	}
+	derivedKeys.keys[cacheKey] = key
+	return key, nil
+}
+
+// EncryptEnv seals a plaintext env file with AES-256-GCM. The text header is
+// bound to the ciphertext as additional data so it cannot be altered.
+func EncryptEnv(plaintext []byte, key EnvKey) ([]byte, error) {
+	kdf, err := key.kdf()
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, encryptionSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("generate salt: %w", err)
+	}
+
+	iterations := 0
+	if kdf == kdfPBKDF2 {
+		iterations = pbkdf2Iterations
+	}
+
+	derived, err := key.derive(kdf, salt, iterations)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(derived)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+
+	var header bytes.Buffer
+	fmt.Fprintln(&header, encryptedEnvMagic)
+	fmt.Fprintf(&header, "kdf: %s\n", kdf)
+	if iterations > 0 {
+		fmt.Fprintf(&header, "iterations: %d\n", iterations)
+	}
+	fmt.Fprintf(&header, "salt: %s\n", base64.StdEncoding.EncodeToString(salt))
+	fmt.Fprintf(&header, "nonce: %s\n", base64.StdEncoding.EncodeToString(nonce))
+
+	sealed := aead.Seal(nil, nonce, plaintext, header.Bytes())
+	encoded := base64.StdEncoding.EncodeToString(sealed)
+
+	out := bytes.NewBuffer(header.Bytes())
+	out.WriteString("\n")
+	for len(encoded) > encryptedLineWidth {
+		out.WriteString(encoded[:encryptedLineWidth])
+		out.WriteString("\n")
+		encoded = encoded[encryptedLineWidth:]
+	}
+	out.WriteString(encoded)
+	out.WriteString("\n")
+	return out.Bytes(), nil
+}
+
+// DecryptEnv opens a file produced by EncryptEnv.
+func DecryptEnv(data []byte, key EnvKey) ([]byte, error) {
+	headerEnd := bytes.Index(data, []byte("\n\n"))
+	if headerEnd < 0 {
+		return nil, fmt.Errorf("malformed encrypted env file: missing header")
+	}
+	header := data[:headerEnd+1]
+
+	fields := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(header))
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+		if lineNum == 1 {
+			if line != encryptedEnvMagic {
+				return nil, fmt.Errorf("malformed encrypted env file: expected %q header", encryptedEnvMagic)
+			}
+			continue
+		}
+		name, value, ok := strings.Cut(line, ": ")
+		if !ok {
+			return nil, fmt.Errorf("malformed encrypted env file: header line %d", lineNum)
+		}
+		fields[name] = value
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(fields["salt"])
+	if err != nil || len(salt) == 0 {
+		return nil, fmt.Errorf("malformed encrypted env file: invalid salt")
+	}
+	nonce, err := base64.StdEncoding.DecodeString(fields["nonce"])
+	if err != nil || len(nonce) == 0 {
+		return nil, fmt.Errorf("malformed encrypted env file: invalid nonce")
+	}
+	iterations := 0
+	if fields["iterations"] != "" {
+		iterations, err = strconv.Atoi(fields["iterations"])
+		if err != nil || iterations <= 0 {
+			return nil, fmt.Errorf("malformed encrypted env file: invalid iterations")
+		}
+		if iterations > maxPBKDF2Iterations {
+			return nil, fmt.Errorf("malformed encrypted env file: %d iterations exceeds the limit of %d", iterations, maxPBKDF2Iterations)
+		}
+	}
+
+	body := strings.Join(strings.Fields(string(data[headerEnd+2:])), "")
+	sealed, err := base64.StdEncoding.DecodeString(body)
+	if err != nil {
+		return nil, fmt.Errorf("malformed encrypted env file: %w", err)
+	}
+
+	derived, err := key.derive(fields["kdf"], salt, iterations)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(derived)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("malformed encrypted env file: invalid nonce size")
+	}
+
+	plaintext, err := aead.Open(nil, nonce, sealed, header)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt failed: wrong key or tampered file")
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("init cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("init gcm: %w", err)
+	}
+	return aead, nil
+}
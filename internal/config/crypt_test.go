@@ -0,0 +1,129 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEncryptEnv_RoundTrip(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "env.key")
+	writeFile(t, keyFile, "0123456789abcdef0123456789abcdef\n")
+
+	keys := map[string]EnvKey{
+		"passphrase": {Passphrase: "correct horse battery staple"},
+		"key file":   {KeyFile: keyFile},
+	}
+
+	plaintext := []byte("# dev secrets\ntoken=abc123\n")
+	for name, key := range keys {
+		t.Run(name, func(t *testing.T) {
+			sealed, err := EncryptEnv(plaintext, key)
+			if err != nil {
+				t.Fatalf("EncryptEnv returned error: %v", err)
+			}
+			if bytes.Contains(sealed, []byte("abc123")) {
+				t.Fatalf("expected ciphertext not to contain plaintext secret")
+			}
+
+			opened, err := DecryptEnv(sealed, key)
+			if err != nil {
+				t.Fatalf("DecryptEnv returned error: %v", err)
+			}
+			if !bytes.Equal(opened, plaintext) {
+				t.Fatalf("expected %q, got %q", plaintext, opened)
+			}
+		})
+	}
+}
+
+func TestDecryptEnv_RejectsWrongKeyAndTampering(t *testing.T) {
+	key := EnvKey{Passphrase: "right"}
+	sealed, err := EncryptEnv([]byte("token=abc123\n"), key)
+	if err != nil {
+		t.Fatalf("EncryptEnv returned error: %v", err)
+	}
+
+	if _, err := DecryptEnv(sealed, EnvKey{Passphrase: "wrong"}); err == nil {
+		t.Fatal("expected wrong passphrase to fail")
+	}
+
+	tampered := bytes.Replace(sealed, []byte("iterations: 600000"), []byte("iterations: 600001"), 1)
+	if _, err := DecryptEnv(tampered, key); err == nil {
+		t.Fatal("expected tampered header to fail authentication")
+	}
+}
+
+func TestResolveVariables_ReadsEncryptedEnvFile(t *testing.T) {
+	root := t.TempDir()
+	key := EnvKey{Passphrase: "team-secret"}
+
+	sealed, err := EncryptEnv([]byte("token=from-encrypted\nbase=encrypted\nbase_url=https://api.example.com\nformat=json\n"), key)
+	if err != nil {
+		t.Fatalf("EncryptEnv returned error: %v", err)
+	}
+	writeFile(t, filepath.Join(root, "env", "dev.env"), "base=shared\n")
+	if err := os.WriteFile(filepath.Join(root, "env", "dev.env.enc"), sealed, 0o644); err != nil {
+		t.Fatalf("write encrypted env: %v", err)
+	}
+	writeFile(t, filepath.Join(root, ".wirepad", "env", "dev.env"), "base=private\n")
+
+	opts := ResolveOptions{
+		EnvName:       "dev",
+		SharedEnvDir:  filepath.Join(root, "env"),
+		PrivateEnvDir: filepath.Join(root, ".wirepad", "env"),
+		DotEnvPath:    filepath.Join(root, ".env"),
+		EnvKey:        &key,
+	}
+	got, err := ResolveVariables(opts)
+	if err != nil {
+		t.Fatalf("ResolveVariables returned error: %v", err)
+	}
+	if got["token"] != "from-encrypted" {
+		t.Fatalf("expected token from encrypted env, got %q", got["token"])
+	}
+	if got["base"] != "private" {
+		t.Fatalf("expected private env to override encrypted env, got %q", got["base"])
+	}
+
+	if redacted := RedactString("token=from-encrypted"); redacted != "token="+Redacted {
+		t.Fatalf("expected decrypted values to be redacted, got %q", redacted)
+	}
+	if plain := "GET https://api.example.com/users?format=json"; RedactString(plain) != plain {
+		t.Fatalf("expected non-secret decrypted values to print, got %q", RedactString(plain))
+	}
+
+	opts.EnvKey = &EnvKey{Passphrase: "wrong-secret"}
+	if _, err := ResolveVariables(opts); err == nil || !strings.Contains(err.Error(), "dev.env.enc") {
+		t.Fatalf("expected decrypt error naming the file, got %v", err)
+	}
+
+	var warnings bytes.Buffer
+	SetWarningOutput(&warnings)
+	t.Cleanup(func() { SetWarningOutput(nil) })
+	opts.EnvKey = &EnvKey{}
+	got, err = ResolveVariables(opts)
+	if err != nil {
+		t.Fatalf("expected the encrypted layer to be skipped without a key, got %v", err)
+	}
+	if _, ok := got["token"]; ok || got["base"] != "private" {
+		t.Fatalf("expected only plaintext layers, got %v", got)
+	}
+	if !strings.Contains(warnings.String(), "skipping "+filepath.Join(root, "env", "dev.env.enc")) {
+		t.Fatalf("expected a skip warning, got %q", warnings.String())
+	}
+}
+
+func TestDecryptEnv_RejectsExcessiveIterations(t *testing.T) {
+	sealed, err := EncryptEnv([]byte("a=b\n"), EnvKey{Passphrase: "team-secret"})
+	if err != nil {
+		t.Fatalf("EncryptEnv returned error: %v", err)
+	}
+	tampered := strings.Replace(string(sealed), "iterations: 600000", "iterations: 2000000000", 1)
+	_, err = DecryptEnv([]byte(tampered), EnvKey{Passphrase: "team-secret"})
+	if err == nil || !strings.Contains(err.Error(), "exceeds the limit") {
+		t.Fatalf("expected iteration limit error, got %v", err)
+	}
+}
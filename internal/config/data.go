@@ -0,0 +1,105 @@
+package config
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LoadDataRows reads a dataset for data-driven runs: a .csv file whose
+// header row names the columns, or a .json array of objects. Each row maps
+// column names to variable values.
+func LoadDataRows(path string) ([]map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read data file: %w", err)
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	var rows []map[string]string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		rows, err = parseCSVRows(data)
+	case ".json":
+		rows, err = parseJSONRows(data)
+	default:
+		return nil, fmt.Errorf("data file %q: expected a .csv or .json file", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse data file %q: %w", path, err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("data file %q has no rows", path)
+	}
+	return rows, nil
+}
+
+func parseCSVRows(data []byte) ([]map[string]string, error) {
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	header := records[0]
+	seen := make(map[string]bool, len(header))
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, fmt.Errorf("column %d has an empty name", i+1)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate column %q", name)
+		}
+		seen[name] = true
+		header[i] = name
+	}
+
+	rows := make([]map[string]string, 0, len(records)-1)
+	for _, record := range records[1:] {
+		row := make(map[string]string, len(header))
+		for i, name := range header {
+			row[name] = record[i]
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// parseJSONRows reads an array of objects. Strings are used as-is, null
+// becomes "", and other values keep their JSON text so nested objects can be
+// interpolated into JSON bodies.
+func parseJSONRows(data []byte) ([]map[string]string, error) {
+	var items []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("expected an array of objects: %w", err)
+	}
+
+	rows := make([]map[string]string, 0, len(items))
+	for _, item := range items {
+		row := make(map[string]string, len(item))
+		for key, raw := range item {
+			var s string
+			switch {
+			case json.Unmarshal(raw, &s) == nil:
+				row[key] = s
+			case string(raw) == "null":
+				row[key] = ""
+			default:
+				var compact bytes.Buffer
+				if err := json.Compact(&compact, raw); err != nil {
+					return nil, err
+				}
+				row[key] = compact.String()
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadDataRows(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "cases.csv")
+	if err := os.WriteFile(csvPath, []byte("\xef\xbb\xbfname, email\nAda,ada@example.com\n\"Lovelace, A\",\"\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := LoadDataRows(csvPath)
+	if err != nil {
+		t.Fatalf("LoadDataRows(csv) returned error: %v", err)
+	}
+	want := []map[string]string{
+		{"name": "Ada", "email": "ada@example.com"},
+		{"name": "Lovelace, A", "email": ""},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Fatalf("unexpected csv rows %#v", rows)
+	}
+
+	jsonPath := filepath.Join(dir, "cases.json")
+	if err := os.WriteFile(jsonPath, []byte(`[{"name": "Ada", "age": 36, "admin": true, "tags": ["a", "b"], "note": null}]`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	rows, err = LoadDataRows(jsonPath)
+	if err != nil {
+		t.Fatalf("LoadDataRows(json) returned error: %v", err)
+	}
+	want = []map[string]string{{"name": "Ada", "age": "36", "admin": "true", "tags": `["a","b"]`, "note": ""}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Fatalf("unexpected json rows %#v", rows)
+	}
+
+	for name, content := range map[string]string{
+		"ragged.csv":  "a,b\n1\n",
+		"dup.csv":     "a,a\n1,2\n",
+		"empty.csv":   "a,b\n",
+		"object.json": `{"a": 1}`,
+		"cases.txt":   "a\n1\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadDataRows(path); err == nil || !strings.Contains(err.Error(), name) {
+			t.Fatalf("expected error for %s, got %v", name, err)
+		}
+	}
+}
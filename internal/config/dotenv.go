@@ -0,0 +1,325 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// parseDotEnv implements the de-facto dotenv grammar:
+//
+//   - blank lines and lines starting with # are ignored
+//   - an optional "export " prefix is allowed
+//   - single-quoted values are literal and may span lines
+//   - double-quoted values may span lines and support \n \r \t \" \\ \$ escapes
+//   - unquoted values end at the line end or at an inline " #" comment
+//   - ${NAME}, ${NAME:-default} and $NAME expand from earlier keys in the same
+//     file, then from the process environment (not inside single quotes)
+//   - ${secret:...} references are kept verbatim for the secret providers
+//
+// path is only used in error messages.
+func parseDotEnv(data string, path string) (map[string]string, error) {
+	p := &dotEnvParser{
+		src:  strings.ReplaceAll(data, "\r\n", "\n"),
+		line: 1,
+		path: path,
+		out:  make(map[string]string),
+	}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	return p.out, nil
+}
+
+type dotEnvParser struct {
+	src  string
+	pos  int
+	line int
+	path string
+	out  map[string]string
+}
+
+func (p *dotEnvParser) errorf(line int, format string, args ...any) error {
+	return fmt.Errorf("parse env file %q line %d: %s", p.path, line, fmt.Sprintf(format, args...))
+}
+
+func (p *dotEnvParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *dotEnvParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *dotEnvParser) next() byte {
+	c := p.src[p.pos]
+	p.pos++
+	if c == '\n' {
+		p.line++
+	}
+	return c
+}
+
+func (p *dotEnvParser) skipSpaces() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+// restOfLine consumes through the next newline and returns the text before it.
+func (p *dotEnvParser) restOfLine() string {
+	start := p.pos
+	for !p.eof() && p.peek() != '\n' {
+		p.pos++
+	}
+	text := p.src[start:p.pos]
+	if !p.eof() {
+		p.next()
+	}
+	return text
+}
+
+func (p *dotEnvParser) parse() error {
+	for !p.eof() {
+		p.skipSpaces()
+		if p.eof() {
+			break
+		}
+		if p.peek() == '\n' {
+			p.next()
+			continue
+		}
+		if p.peek() == '#' {
+			p.restOfLine()
+			continue
+		}
+
+		if err := p.parseAssignment(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *dotEnvParser) parseAssignment() error {
+	line := p.line
+
+	if strings.HasPrefix(p.src[p.pos:], "export ") || strings.HasPrefix(p.src[p.pos:], "export\t") {
+		p.pos += len("export")
+		p.skipSpaces()
+	}
+
+	start := p.pos
+	for !p.eof() && p.peek() != '=' && p.peek() != '\n' {
+		p.pos++
+	}
+	if p.eof() || p.peek() != '=' {
+		return p.errorf(line, "expected KEY=VALUE")
+	}
+	key := strings.TrimSpace(p.src[start:p.pos])
+	p.next() // '='
+
+	if key == "" {
+		return p.errorf(line, "empty key")
+	}
+	if !isEnvKey(key) {
+		return p.errorf(line, "invalid key %q", key)
+	}
+
+	p.skipSpaces()
+
+	var value string
+	var err error
+	switch p.peek() {
+	case '\'':
+		value, err = p.parseSingleQuoted(line)
+	case '"':
+		value, err = p.parseDoubleQuoted(line)
+	default:
+		value, err = p.parseUnquoted(line)
+	}
+	if err != nil {
+		return err
+	}
+
+	p.out[key] = value
+	return nil
+}
+
+func (p *dotEnvParser) parseSingleQuoted(line int) (string, error) {
+	p.next() // opening quote
+	start := p.pos
+	for !p.eof() && p.peek() != '\'' {
+		p.next()
+	}
+	if p.eof() {
+		return "", p.errorf(line, "unterminated single-quoted value")
+	}
+	value := p.src[start:p.pos]
+	p.next() // closing quote
+
+	if err := p.finishQuotedLine(); err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+func (p *dotEnvParser) parseDoubleQuoted(line int) (string, error) {
+	p.next() // opening quote
+
+	var b strings.Builder
+	for {
+		if p.eof() {
+			return "", p.errorf(line, "unterminated double-quoted value")
+		}
+		c := p.next()
+		switch c {
+		case '"':
+			if err := p.finishQuotedLine(); err != nil {
+				return "", err
+			}
+			return b.String(), nil
+		case '\\':
+			if p.eof() {
+				return "", p.errorf(line, "unterminated double-quoted value")
+			}
+			escaped := p.next()
+			switch escaped {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '"', '\\', '\'', '$':
+				b.WriteByte(escaped)
+			case '\n':
+				// Line continuation.
+			default:
+				b.WriteByte('\\')
+				b.WriteByte(escaped)
+			}
+		case '$':
+			expanded, err := p.parseExpansion(line)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(expanded)
+		default:
+			b.WriteByte(c)
+		}
+	}
+}
+
+// finishQuotedLine allows only whitespace or a comment after a closing quote.
+func (p *dotEnvParser) finishQuotedLine() error {
+	line := p.line
+	rest := strings.TrimSpace(p.restOfLine())
+	if rest != "" && !strings.HasPrefix(rest, "#") {
+		return p.errorf(line, "unexpected characters after closing quote: %q", rest)
+	}
+	return nil
+}
+
+func (p *dotEnvParser) parseUnquoted(line int) (string, error) {
+	raw := p.restOfLine()
+
+	// An inline comment needs whitespace before the '#', so values such as
+	// URL fragments (a#b) survive.
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == '#' && i > 0 && (raw[i-1] == ' ' || raw[i-1] == '\t') {
+			raw = raw[:i]
+			break
+		}
+	}
+	raw = strings.TrimSpace(raw)
+
+	var b strings.Builder
+	for i := 0; i < len(raw); i++ {
+		if raw[i] != '$' {
+			b.WriteByte(raw[i])
+			continue
+		}
+		sub := &dotEnvParser{src: raw[i+1:], line: line, path: p.path, out: p.out}
+		expanded, err := sub.parseExpansion(line)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(expanded)
+		i += sub.pos
+	}
+	return b.String(), nil
+}
+
+// parseExpansion is called after a '$' has been consumed.
+func (p *dotEnvParser) parseExpansion(line int) (string, error) {
+	if p.peek() == '{' {
+		rest := p.src[p.pos:]
+		end := strings.IndexByte(rest, '}')
+		if end < 0 {
+			return "", p.errorf(line, "unterminated ${ expansion")
+		}
+		inner := rest[1:end]
+		for i := 0; i <= end; i++ {
+			p.next()
+		}
+
+		if strings.HasPrefix(inner, "secret:") {
+			return "${" + inner + "}", nil
+		}
+
+		name, fallback, hasDefault := strings.Cut(inner, ":-")
+		if !isEnvKey(name) {
+			return "", p.errorf(line, "invalid variable name %q in ${...}", name)
+		}
+		if value, ok := p.lookup(name); ok && (value != "" || !hasDefault) {
+			return value, nil
+		}
+		return fallback, nil
+	}
+
+	start := p.pos
+	for !p.eof() && isEnvNameByte(p.peek(), p.pos == start) {
+		p.pos++
+	}
+	if p.pos == start {
+		return "$", nil
+	}
+	value, _ := p.lookup(p.src[start:p.pos])
+	return value, nil
+}
+
+func (p *dotEnvParser) lookup(name string) (string, bool) {
+	if value, ok := p.out[name]; ok {
+		return value, true
+	}
+	return os.LookupEnv(name)
+}
+
+func isEnvKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if isEnvNameByte(c, i == 0) || (i > 0 && (c == '.' || c == '-')) {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+func isEnvNameByte(c byte, first bool) bool {
+	switch {
+	case c == '_', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		return true
+	case c >= '0' && c <= '9':
+		return !first
+	default:
+		return false
+	}
+}
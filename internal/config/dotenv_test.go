@@ -0,0 +1,91 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseDotEnv_Grammar(t *testing.T) {
+	t.Setenv("WIREPAD_TEST_HOME", "/home/wirepad")
+
+	input := strings.Join([]string{
+		"# leading comment",
+		"export BASE=https://api.example.com # inline comment",
+		"URL=${BASE}/v1",
+		"SHORT=$BASE/v2",
+		"FRAGMENT=https://example.com/a#b",
+		"FROM_OS=${WIREPAD_TEST_HOME}",
+		"DEFAULTED=${MISSING_VALUE:-fallback}",
+		`ESCAPED="line1\nline2\t\"quoted\" \$BASE"`,
+		`LITERAL='${BASE} stays $literal "quotes"'`,
+		`JSON='{"a": "b"}'`,
+		`PEM="-----BEGIN KEY-----`,
+		`abc`,
+		`-----END KEY-----"`,
+		"SECRET=${secret:cmd:pass show api/dev}",
+		"EMPTY=",
+		`QUOTED_EMPTY="" # nothing`,
+	}, "\n") + "\n"
+
+	got, err := parseDotEnv(input, "test.env")
+	if err != nil {
+		t.Fatalf("parseDotEnv returned error: %v", err)
+	}
+
+	want := map[string]string{
+		"BASE":         "https://api.example.com",
+		"URL":          "https://api.example.com/v1",
+		"SHORT":        "https://api.example.com/v2",
+		"FRAGMENT":     "https://example.com/a#b",
+		"FROM_OS":      "/home/wirepad",
+		"DEFAULTED":    "fallback",
+		"ESCAPED":      "line1\nline2\t\"quoted\" $BASE",
+		"LITERAL":      `${BASE} stays $literal "quotes"`,
+		"JSON":         `{"a": "b"}`,
+		"PEM":          "-----BEGIN KEY-----\nabc\n-----END KEY-----",
+		"SECRET":       "${secret:cmd:pass show api/dev}",
+		"EMPTY":        "",
+		"QUOTED_EMPTY": "",
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("%s: expected %q, got %q", key, value, got[key])
+		}
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d keys, got %d: %v", len(want), len(got), got)
+	}
+}
+
+func TestParseDotEnv_LineAccurateErrors(t *testing.T) {
+	cases := map[string]struct {
+		input string
+		want  string
+	}{
+		"missing equals": {
+			input: "A=1\n\nbroken-line\n",
+			want:  "line 3: expected KEY=VALUE",
+		},
+		"unterminated quote reports opening line": {
+			input: "A=1\nPEM=\"-----BEGIN\nabc\n",
+			want:  "line 2: unterminated double-quoted value",
+		},
+		"trailing text after quote": {
+			input: "A=1\nB=2\nC='x' y\n",
+			want:  "line 3: unexpected characters after closing quote",
+		},
+		"invalid key": {
+			input: "1A=1\n",
+			want:  `line 1: invalid key "1A"`,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseDotEnv(tc.input, "test.env")
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("expected error containing %q, got %v", tc.want, err)
+			}
+		})
+	}
+}
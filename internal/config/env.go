@@ -1,12 +1,13 @@
 package config
 
 import (
-	"bufio"
 	"crypto/rand"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -22,9 +23,100 @@ type ResolveOptions struct {
 	PrivateEnvDir string
 	SharedEnvDir  string
 	DotEnvPath    string
+
+	// SecretProviders adds or overrides ${secret:<scheme>:<ref>} providers.
+	// The built-in schemes are cmd, file and env.
+	SecretProviders map[string]SecretProvider
+
+	// EnvKey decrypts env/<name>.env.enc. Defaults to EnvKeyFromEnvironment.
+	EnvKey *EnvKey
+}
+
+const (
+	// LayerGenerated names the layer holding runtime variables such as uuid.
+	LayerGenerated = "generated"
+	// LayerCLI names the layer holding --var overrides.
+	LayerCLI = "--var"
+)
+
+var warnings = struct {
+	sync.Mutex
+	out  io.Writer
+	seen map[string]struct{}
+}{out: io.Discard, seen: make(map[string]struct{})}
+
+// SetWarningOutput sets where the package reports problems that do not stop
+// a run, such as a skipped encrypted env file. Each message is printed once.
+func SetWarningOutput(w io.Writer) {
+	if w == nil {
+		w = io.Discard
+	}
+	warnings.Lock()
+	warnings.out = w
+	warnings.Unlock()
+}
+
+func warnf(format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	warnings.Lock()
+	defer warnings.Unlock()
+	if _, ok := warnings.seen[msg]; ok {
+		return
+	}
+	warnings.seen[msg] = struct{}{}
+	fmt.Fprintf(warnings.out, "warning: %s\n", msg)
+}
+
+// Layer is one source of variables. Layers are ordered lowest precedence first.
+type Layer struct {
+	Source string
+	Values map[string]string
 }
 
 func ResolveVariables(opts ResolveOptions) (map[string]string, error) {
+	layers, err := ResolveLayers(opts)
+	if err != nil {
+		return nil, err
+	}
+	resolved := MergeLayers(layers)
+
+	// Secret references stay in the map and are resolved by InterpolateString
+	// when a request uses them, so only the winning layer's reference is ever
+	// looked up and unused references never run. Only env files may hold
+	// references: --var values, which also carry flow exports and dataset
+	// rows, stay literal text.
+	providers := defaultSecretProviders()
+	for scheme, provider := range opts.SecretProviders {
+		providers[scheme] = provider
+	}
+	sources := LayerSources(layers)
+	for key, value := range resolved {
+		if source := sources[key]; source != LayerCLI && source != LayerGenerated && IsSecretRef(value) {
+			registerSecretRef(value, providers)
+		}
+	}
+
+	return resolved, nil
+}
+
+// ValidateEnvName rejects env names that would point outside the env
+// directories, such as "../prod" or "a/b".
+func ValidateEnvName(name string) error {
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.Contains(name, "..") {
+		return fmt.Errorf("invalid env name %q: use a plain name such as dev", name)
+	}
+	return nil
+}
+
+// ResolveLayers loads every variable layer that exists for opts, without
+// resolving secret references.
+func ResolveLayers(opts ResolveOptions) ([]Layer, error) {
+	if opts.EnvName != "" {
+		if err := ValidateEnvName(opts.EnvName); err != nil {
+			return nil, err
+		}
+	}
+
 	privateEnvDir := opts.PrivateEnvDir
 	if privateEnvDir == "" {
 		privateEnvDir = defaultPrivateEnvDir
@@ -40,90 +132,107 @@ func ResolveVariables(opts ResolveOptions) (map[string]string, error) {
 		dotEnvPath = defaultDotEnvPath
 	}
 
-	resolved := generatedVars()
-
-	// Lowest explicit file precedence: .env
-	if err := mergeFileIfExists(resolved, dotEnvPath); err != nil {
-		return nil, err
+	key := EnvKeyFromEnvironment()
+	if opts.EnvKey != nil {
+		key = *opts.EnvKey
 	}
 
-	// Then shared env/<name>.env.
+	layers := []Layer{{Source: LayerGenerated, Values: generatedVars()}}
+
+	// Lowest explicit file precedence: .env
+	paths := []string{dotEnvPath}
 	if opts.EnvName != "" {
-		if err := mergeFileIfExists(resolved, filepath.Join(sharedEnvDir, opts.EnvName+".env")); err != nil {
-			return nil, err
-		}
+		paths = append(paths,
+			// Then shared env/<name>.env.
+			filepath.Join(sharedEnvDir, opts.EnvName+".env"),
+			// Then committed encrypted secrets env/<name>.env.enc.
+			filepath.Join(sharedEnvDir, opts.EnvName+".env"+EncryptedEnvSuffix),
+			// Then private .wirepad/env/<name>.env.
+			filepath.Join(privateEnvDir, opts.EnvName+".env"),
+		)
 	}
 
-	// Then private .wirepad/env/<name>.env.
-	if opts.EnvName != "" {
-		if err := mergeFileIfExists(resolved, filepath.Join(privateEnvDir, opts.EnvName+".env")); err != nil {
+	for _, path := range paths {
+		values, exists, err := parseEnvFile(path, key)
+		if err != nil {
 			return nil, err
 		}
+		if exists {
+			layers = append(layers, Layer{Source: path, Values: values})
+		}
 	}
 
 	// Highest precedence: --var key=value.
-	for key, value := range opts.CLI {
-		resolved[key] = value
+	if len(opts.CLI) > 0 {
+		cli := make(map[string]string, len(opts.CLI))
+		for key, value := range opts.CLI {
+			cli[key] = value
+		}
+		layers = append(layers, Layer{Source: LayerCLI, Values: cli})
 	}
 
-	return resolved, nil
+	return layers, nil
 }
 
-func mergeFileIfExists(dst map[string]string, path string) error {
-	values, exists, err := parseEnvFile(path)
-	if err != nil {
-		return err
-	}
-	if !exists {
-		return nil
+// MergeLayers flattens layers so later layers win.
+func MergeLayers(layers []Layer) map[string]string {
+	out := make(map[string]string)
+	for _, layer := range layers {
+		for key, value := range layer.Values {
+			out[key] = value
+		}
 	}
+	return out
+}
 
-	for key, value := range values {
-		dst[key] = value
+// LayerSources reports the source of the winning layer for every key.
+func LayerSources(layers []Layer) map[string]string {
+	out := make(map[string]string)
+	for _, layer := range layers {
+		for key := range layer.Values {
+			out[key] = layer.Source
+		}
 	}
-	return nil
+	return out
 }
 
-func parseEnvFile(path string) (map[string]string, bool, error) {
-	f, err := os.Open(path)
+// parseEnvFile reads a dotenv file. Files ending in .enc are decrypted with key first.
+func parseEnvFile(path string, key EnvKey) (map[string]string, bool, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil, false, nil
 		}
 		return nil, false, fmt.Errorf("open env file %q: %w", path, err)
 	}
-	defer f.Close()
-
-	out := make(map[string]string)
-	scanner := bufio.NewScanner(f)
-	lineNum := 0
-	for scanner.Scan() {
-		lineNum++
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
 
-		line = strings.TrimPrefix(line, "export ")
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) != 2 {
-			return nil, true, fmt.Errorf("parse env file %q line %d: expected KEY=VALUE", path, lineNum)
+	encrypted := strings.HasSuffix(path, EncryptedEnvSuffix)
+	if encrypted {
+		// Without a key the shared plaintext layers are still usable, so the
+		// encrypted layer is skipped rather than failing every resolve.
+		if key == (EnvKey{}) {
+			warnf("skipping %s: no decryption key configured (set %s, %s or create %s)", path, envPassphraseVar, envKeyFileVar, defaultEnvKeyFile)
+			return nil, false, nil
 		}
-
-		key := strings.TrimSpace(parts[0])
-		if key == "" {
-			return nil, true, fmt.Errorf("parse env file %q line %d: empty key", path, lineNum)
+		data, err = DecryptEnv(data, key)
+		if err != nil {
+			return nil, true, fmt.Errorf("decrypt env file %q: %w", path, err)
 		}
-
-		value := strings.TrimSpace(parts[1])
-		value = strings.Trim(value, `"'`)
-		out[key] = value
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, true, fmt.Errorf("read env file %q: %w", path, err)
+	out, err := parseDotEnv(string(data), path)
+	if err != nil {
+		return nil, true, err
+	}
+	if encrypted {
+		// Encrypted files also hold plain settings such as base_url, which
+		// would mask unrelated output if every value were registered.
+		for key, value := range out {
+			if IsSensitiveKey(key) {
+				MarkSecret(value)
+			}
+		}
 	}
-
 	return out, true, nil
 }
 
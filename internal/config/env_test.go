@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -77,6 +78,17 @@ func TestResolveVariables_SkipsMissingFiles(t *testing.T) {
 	}
 }
 
+func TestResolveVariables_RejectsPathEnvNames(t *testing.T) {
+	for _, name := range []string{"../prod", "a/b", `a\b`, ".."} {
+		if _, err := ResolveVariables(ResolveOptions{EnvName: name}); err == nil || !strings.Contains(err.Error(), "invalid env name") {
+			t.Fatalf("ResolveVariables(%q) error = %v, want invalid env name", name, err)
+		}
+	}
+	if err := ValidateEnvName("stage-eu.v2"); err != nil {
+		t.Fatalf("expected a plain name to pass, got %v", err)
+	}
+}
+
 func TestResolveVariables_InvalidEnvLine(t *testing.T) {
 	root := t.TempDir()
 	writeFile(t, filepath.Join(root, ".env"), "broken-line\n")
@@ -11,6 +11,7 @@ var interpolationPattern = regexp.MustCompile(`\{\{\s*([a-zA-Z0-9_.-]+)\s*\}\}`)
 
 func InterpolateString(input string, vars map[string]string) (string, error) {
 	var unresolved []string
+	var secretErr error
 
 	output := interpolationPattern.ReplaceAllStringFunc(input, func(match string) string {
 		groups := interpolationPattern.FindStringSubmatch(match)
@@ -23,9 +24,20 @@ func InterpolateString(input string, vars map[string]string) (string, error) {
 			unresolved = append(unresolved, key)
 			return match
 		}
+		if IsSecretRef(value) && secretErr == nil {
+			secret, err := resolveRegisteredSecret(value)
+			if err != nil {
+				secretErr = fmt.Errorf("resolve secret for %q: %w", key, err)
+				return match
+			}
+			return secret
+		}
 		return value
 	})
 
+	if secretErr != nil {
+		return "", secretErr
+	}
 	if len(unresolved) > 0 {
 		return "", fmt.Errorf("unresolved variable(s): %s", strings.Join(unique(unresolved), ", "))
 	}
@@ -33,6 +45,15 @@ func InterpolateString(input string, vars map[string]string) (string, error) {
 	return output, nil
 }
 
+// ReferencedVariables lists the distinct {{var}} names used in input, in order of appearance.
+func ReferencedVariables(input string) []string {
+	var names []string
+	for _, groups := range interpolationPattern.FindAllStringSubmatch(input, -1) {
+		names = append(names, strings.TrimSpace(groups[1]))
+	}
+	return unique(names)
+}
+
 func InterpolateAny(target any, vars map[string]string) error {
 	return interpolateValue(reflect.ValueOf(target), vars)
 }
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Redacted replaces sensitive values in rendered output and persisted history.
+const Redacted = "[REDACTED]"
+
+var sensitiveKeyParts = []string{
+	"authorization",
+	"token",
+	"api_key",
+	"apikey",
+	"secret",
+	"password",
+}
+
+// minSecretLength is the shortest value MarkSecret registers. Shorter values
+// would mask unrelated text everywhere they occur.
+const minSecretLength = 4
+
+var secretValues = struct {
+	sync.RWMutex
+	values map[string]struct{}
+	// sorted holds the values longest first. MarkSecret replaces it rather
+	// than editing it, so readers can use it after unlocking.
+	sorted []string
+}{values: make(map[string]struct{})}
+
+// MarkSecret registers a value that must never appear in output or history.
+// Values shorter than four characters are skipped with a warning.
+func MarkSecret(value string) {
+	if strings.TrimSpace(value) == "" {
+		return
+	}
+	if n := utf8.RuneCountInString(value); n < minSecretLength {
+		warnf("a %d-character secret is too short to redact and will appear in output", n)
+		return
+	}
+	secretValues.Lock()
+	defer secretValues.Unlock()
+	if _, ok := secretValues.values[value]; ok {
+		return
+	}
+	secretValues.values[value] = struct{}{}
+	i := sort.Search(len(secretValues.sorted), func(i int) bool { return len(secretValues.sorted[i]) < len(value) })
+	sorted := make([]string, 0, len(secretValues.sorted)+1)
+	sorted = append(sorted, secretValues.sorted[:i]...)
+	sorted = append(sorted, value)
+	secretValues.sorted = append(sorted, secretValues.sorted[i:]...)
+}
+
+// IsSensitiveKey reports whether a variable or header name is redacted by convention.
+func IsSensitiveKey(key string) bool {
+	lower := strings.ToLower(strings.ReplaceAll(key, "-", "_"))
+	for _, part := range sensitiveKeyParts {
+		if strings.Contains(lower, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactString replaces every registered secret value found in s.
+func RedactString(s string) string {
+	secretValues.RLock()
+	sorted := secretValues.sorted
+	secretValues.RUnlock()
+	return replaceSecrets(s, sorted)
+}
+
+// RedactValues replaces values in s without registering them, for secrets
+// that only matter to one piece of output. Values shorter than four
+// characters are left alone, as with MarkSecret.
+func RedactValues(s string, values []string) string {
+	sorted := make([]string, 0, len(values))
+	for _, value := range values {
+		if utf8.RuneCountInString(value) >= minSecretLength && strings.TrimSpace(value) != "" {
+			sorted = append(sorted, value)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+	return replaceSecrets(s, sorted)
+}
+
+// replaceSecrets masks values, which are sorted longest first so a secret
+// containing another secret is fully masked.
+func replaceSecrets(s string, values []string) string {
+	for _, value := range values {
+		s = strings.ReplaceAll(s, value, Redacted)
+	}
+	return s
+}
+
+// RedactHeaders returns a copy of headers with sensitive names and registered
+// secret values masked.
+func RedactHeaders(headers map[string]string) map[string]string {
+	if headers == nil {
+		return nil
+	}
+	out := make(map[string]string, len(headers))
+	for key, value := range headers {
+		if IsSensitiveKey(key) || strings.EqualFold(key, "cookie") || strings.EqualFold(key, "set-cookie") {
+			out[key] = Redacted
+			continue
+		}
+		out[key] = RedactString(value)
+	}
+	return out
+}
@@ -0,0 +1,219 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const secretCommandTimeout = 30 * time.Second
+
+var secretRefPattern = regexp.MustCompile(`\$\{secret:([a-zA-Z0-9_-]+):([^}]*)\}`)
+
+// SecretProvider resolves the <ref> part of a ${secret:<scheme>:<ref>} env value.
+type SecretProvider interface {
+	Resolve(ref string) (string, error)
+}
+
+// SecretProviderFunc adapts a plain function to SecretProvider.
+type SecretProviderFunc func(ref string) (string, error)
+
+func (f SecretProviderFunc) Resolve(ref string) (string, error) {
+	return f(ref)
+}
+
+func defaultSecretProviders() map[string]SecretProvider {
+	return map[string]SecretProvider{
+		"cmd":  SecretProviderFunc(resolveCommandSecret),
+		"file": SecretProviderFunc(resolveFileSecret),
+		"env":  SecretProviderFunc(resolveEnvSecret),
+	}
+}
+
+// secretCache keeps resolved secrets for the lifetime of the process so that
+// a password manager prompt or command runs at most once per reference.
+var secretCache = struct {
+	sync.Mutex
+	values map[string]string
+}{values: make(map[string]string)}
+
+// secretRefs holds the ${secret:...} values ResolveVariables read from env
+// files, with the providers that resolve them. InterpolateString resolves
+// only these, so a reference passed with --var, exported from a response or
+// read from a dataset row is sent as written and never run.
+var secretRefs = struct {
+	sync.Mutex
+	providers map[string]map[string]SecretProvider
+}{providers: make(map[string]map[string]SecretProvider)}
+
+func registerSecretRef(value string, providers map[string]SecretProvider) {
+	secretRefs.Lock()
+	secretRefs.providers[value] = providers
+	secretRefs.Unlock()
+}
+
+// resolveRegisteredSecret resolves value if ResolveVariables handed it out.
+func resolveRegisteredSecret(value string) (string, error) {
+	secretRefs.Lock()
+	providers, ok := secretRefs.providers[value]
+	secretRefs.Unlock()
+	if !ok {
+		return value, nil
+	}
+	return resolveSecretRefs(value, providers)
+}
+
+// IsSecretRef reports whether value contains a ${secret:...} reference.
+func IsSecretRef(value string) bool {
+	return secretRefPattern.MatchString(value)
+}
+
+// resolveSecretRefs replaces every secret reference in value and marks the
+// resolved secrets for redaction.
+func resolveSecretRefs(value string, providers map[string]SecretProvider) (string, error) {
+	var resolveErr error
+	out := secretRefPattern.ReplaceAllStringFunc(value, func(match string) string {
+		if resolveErr != nil {
+			return match
+		}
+		groups := secretRefPattern.FindStringSubmatch(match)
+		scheme, ref := groups[1], strings.TrimSpace(groups[2])
+
+		secret, err := lookupSecret(scheme, ref, providers)
+		if err != nil {
+			resolveErr = err
+			return match
+		}
+		return secret
+	})
+	if resolveErr != nil {
+		return "", resolveErr
+	}
+	return out, nil
+}
+
+func lookupSecret(scheme, ref string, providers map[string]SecretProvider) (string, error) {
+	provider, ok := providers[scheme]
+	if !ok {
+		return "", fmt.Errorf("unknown secret provider %q", scheme)
+	}
+	if ref == "" {
+		return "", fmt.Errorf("secret provider %q: empty reference", scheme)
+	}
+
+	cacheKey := scheme + ":" + ref
+	secretCache.Lock()
+	defer secretCache.Unlock()
+
+	if secret, ok := secretCache.values[cacheKey]; ok {
+		return secret, nil
+	}
+
+	secret, err := provider.Resolve(ref)
+	if err != nil {
+		return "", fmt.Errorf("secret provider %q: %w", scheme, err)
+	}
+	secretCache.values[cacheKey] = secret
+	MarkSecret(secret)
+	return secret, nil
+}
+
+func resolveCommandSecret(ref string) (string, error) {
+	args, err := splitCommand(ref)
+	if err != nil {
+		return "", err
+	}
+	if len(args) == 0 {
+		return "", fmt.Errorf("empty command")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), secretCommandTimeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("run %q: %w: %s", args[0], err, msg)
+		}
+		return "", fmt.Errorf("run %q: %w", args[0], err)
+	}
+
+	// Password managers print the secret on the first line; anything after
+	// (pass metadata, trailing newline) is not part of the value.
+	secret, _, _ := strings.Cut(string(out), "\n")
+	return strings.TrimRight(secret, "\r"), nil
+}
+
+// splitCommand splits ref into arguments the way a POSIX shell would:
+// whitespace separates, quotes group and a backslash escapes the next
+// character outside single quotes.
+func splitCommand(ref string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+	for _, r := range ref {
+		switch {
+		case escaped:
+			if quote == '"' && r != '"' && r != '\\' && r != '$' && r != '`' {
+				current.WriteRune('\\')
+			}
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if escaped || quote != 0 {
+		return nil, fmt.Errorf("unterminated quote or escape in command %q", ref)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+func resolveFileSecret(ref string) (string, error) {
+	data, err := os.ReadFile(ref)
+	if err != nil {
+		return "", fmt.Errorf("read %q: %w", ref, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+func resolveEnvSecret(ref string) (string, error) {
+	value, ok := os.LookupEnv(ref)
+	if !ok {
+		return "", fmt.Errorf("environment variable %q is not set", ref)
+	}
+	return value, nil
+}
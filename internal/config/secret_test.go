@@ -0,0 +1,199 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func interpolateForTest(t *testing.T, input string, vars map[string]string) string {
+	t.Helper()
+	out, err := InterpolateString(input, vars)
+	if err != nil {
+		t.Fatalf("InterpolateString(%q) returned error: %v", input, err)
+	}
+	return out
+}
+
+func TestResolveVariables_SecretProviders(t *testing.T) {
+	root := t.TempDir()
+	secretPath := filepath.Join(root, "db-password")
+	writeFile(t, secretPath, "from-file\n")
+	t.Setenv("WIREPAD_TEST_CI_TOKEN", "from-env")
+
+	writeFile(t, filepath.Join(root, "env", "dev.env"), strings.Join([]string{
+		"file_secret=${secret:file:" + secretPath + "}",
+		"env_secret=${secret:env:WIREPAD_TEST_CI_TOKEN}",
+		"custom_secret=Bearer ${secret:vault:api/dev}",
+		"plain=value",
+	}, "\n")+"\n")
+
+	got, err := ResolveVariables(ResolveOptions{
+		EnvName:       "dev",
+		SharedEnvDir:  filepath.Join(root, "env"),
+		PrivateEnvDir: filepath.Join(root, "missing"),
+		DotEnvPath:    filepath.Join(root, "missing.env"),
+		SecretProviders: map[string]SecretProvider{
+			"vault": SecretProviderFunc(func(ref string) (string, error) {
+				return "vault:" + ref, nil
+			}),
+		},
+	})
+	if err != nil {
+		t.Fatalf("ResolveVariables returned error: %v", err)
+	}
+
+	if out := interpolateForTest(t, "{{file_secret}}", got); out != "from-file" {
+		t.Fatalf("expected file secret, got %q", out)
+	}
+	if out := interpolateForTest(t, "{{env_secret}}", got); out != "from-env" {
+		t.Fatalf("expected env secret, got %q", out)
+	}
+	if out := interpolateForTest(t, "{{custom_secret}}", got); out != "Bearer vault:api/dev" {
+		t.Fatalf("expected custom provider secret, got %q", out)
+	}
+	if out := interpolateForTest(t, "{{plain}}", got); out != "value" {
+		t.Fatalf("expected plain value untouched, got %q", out)
+	}
+
+	redacted := RedactString("token=from-env body=vault:api/dev")
+	if strings.Contains(redacted, "from-env") || strings.Contains(redacted, "vault:api/dev") {
+		t.Fatalf("expected resolved secrets to be redacted, got %q", redacted)
+	}
+}
+
+func TestResolveVariables_SecretResolvedOnlyWhenUsed(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".env"), "token=${secret:counting:lazy-ref}\nbroken=${secret:failing:x}\n")
+
+	calls := 0
+	opts := ResolveOptions{
+		DotEnvPath: filepath.Join(root, ".env"),
+		CLI:        map[string]string{"token": "cli-token"},
+		SecretProviders: map[string]SecretProvider{
+			"counting": SecretProviderFunc(func(ref string) (string, error) {
+				calls++
+				return "resolved", nil
+			}),
+		},
+	}
+
+	got, err := ResolveVariables(opts)
+	if err != nil {
+		t.Fatalf("an unused reference to a missing provider must not fail: %v", err)
+	}
+	if out := interpolateForTest(t, "{{token}}", got); out != "cli-token" {
+		t.Fatalf("expected CLI override, got %q", out)
+	}
+
+	opts.CLI = nil
+	got, err = ResolveVariables(opts)
+	if err != nil {
+		t.Fatalf("ResolveVariables returned error: %v", err)
+	}
+	if calls != 0 {
+		t.Fatalf("expected no lookup before the secret is used, got %d provider calls", calls)
+	}
+	for range 2 {
+		if out := interpolateForTest(t, "{{token}}", got); out != "resolved" {
+			t.Fatalf("expected resolved secret, got %q", out)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("expected secret to be cached per process, got %d provider calls", calls)
+	}
+
+	if _, err := InterpolateString("{{broken}}", got); err == nil || !strings.Contains(err.Error(), `unknown secret provider "failing"`) {
+		t.Fatalf("expected the used broken reference to fail, got %v", err)
+	}
+}
+
+func TestInterpolateString_LeavesUnregisteredSecretRefs(t *testing.T) {
+	ref := "${secret:cmd:touch should-not-run}"
+	if out := interpolateForTest(t, "{{value}}", map[string]string{"value": ref}); out != ref {
+		t.Fatalf("expected a reference outside the env to stay literal, got %q", out)
+	}
+}
+
+func TestResolveVariables_CLISecretRefStaysLiteral(t *testing.T) {
+	root := t.TempDir()
+	marker := filepath.Join(root, "ran")
+	ref := "${secret:cmd:touch " + marker + "}"
+	writeFile(t, filepath.Join(root, ".env"), "file_token=${secret:env:WIREPAD_TEST_FILE_TOKEN}\n")
+	t.Setenv("WIREPAD_TEST_FILE_TOKEN", "from-file-ref")
+
+	got, err := ResolveVariables(ResolveOptions{
+		DotEnvPath: filepath.Join(root, ".env"),
+		CLI:        map[string]string{"token": ref, "file_token": "${secret:cmd:touch " + marker + "-2}"},
+	})
+	if err != nil {
+		t.Fatalf("ResolveVariables returned error: %v", err)
+	}
+	for _, key := range []string{"token", "file_token"} {
+		if out := interpolateForTest(t, "{{"+key+"}}", got); out != got[key] {
+			t.Fatalf("expected --var %s to stay literal, got %q", key, out)
+		}
+	}
+	for _, path := range []string{marker, marker + "-2"} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Fatalf("a --var secret reference ran a command (stat %s: %v)", path, err)
+		}
+	}
+}
+
+func TestSplitCommand(t *testing.T) {
+	for input, want := range map[string][]string{
+		`pass show api/dev`:                  {"pass", "show", "api/dev"},
+		`pass show "api/dev token"`:          {"pass", "show", "api/dev token"},
+		`op read 'op://vault/it''s'`:         {"op", "read", "op://vault/its"},
+		`printf "%s\n" a\ b`:                 {"printf", `%s\n`, "a b"},
+		`echo "say \"hi\"" ''`:               {"echo", `say "hi"`, ""},
+		"  security\tfind-generic-password ": {"security", "find-generic-password"},
+	} {
+		got, err := splitCommand(input)
+		if err != nil {
+			t.Fatalf("splitCommand(%q) returned error: %v", input, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("splitCommand(%q) = %q, want %q", input, got, want)
+		}
+	}
+	if _, err := splitCommand(`pass show "open`); err == nil {
+		t.Fatal("expected unterminated quote error")
+	}
+}
+
+func TestMarkSecret_SkipsShortValues(t *testing.T) {
+	var warnings bytes.Buffer
+	SetWarningOutput(&warnings)
+	t.Cleanup(func() { SetWarningOutput(nil) })
+
+	MarkSecret("ab")
+	MarkSecret("ab")
+	if got := RedactString("tab and cab"); got != "tab and cab" {
+		t.Fatalf("expected a short secret to be left alone, got %q", got)
+	}
+	if strings.Count(warnings.String(), "too short to redact") != 1 {
+		t.Fatalf("expected one warning, got %q", warnings.String())
+	}
+}
+
+func TestRedactString_MasksLongestSecretFirst(t *testing.T) {
+	MarkSecret("inner-redact-test")
+	MarkSecret("outer-inner-redact-test-value")
+	MarkSecret("inner-redact-test")
+	if got := RedactString("a=outer-inner-redact-test-value b=inner-redact-test"); got != "a="+Redacted+" b="+Redacted {
+		t.Fatalf("expected both secrets fully masked, got %q", got)
+	}
+
+	got := RedactValues("id=per-call-value-long per-call-value abc", []string{"per-call-value", "abc", "per-call-value-long"})
+	if got != "id="+Redacted+" "+Redacted+" abc" {
+		t.Fatalf("unexpected RedactValues output %q", got)
+	}
+	if RedactString("per-call-value") != "per-call-value" {
+		t.Fatal("expected RedactValues not to register its values")
+	}
+}
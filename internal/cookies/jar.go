@@ -0,0 +1,334 @@
+package cookies
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Dir holds one jar file per environment.
+const Dir = ".wirepad/cookies"
+
+// Path returns the jar file for an environment.
+func Path(envName string) string {
+	if envName == "" {
+		envName = "default"
+	}
+	return filepath.Join(Dir, envName+".json")
+}
+
+// Entry is a stored cookie. Session cookies (no Expires) are kept on disk too
+// so a login in one run carries over to the next.
+type Entry struct {
+	Name     string    `json:"name"`
+	Value    string    `json:"value"`
+	Domain   string    `json:"domain"`
+	Path     string    `json:"path"`
+	HostOnly bool      `json:"host_only,omitempty"`
+	Secure   bool      `json:"secure,omitempty"`
+	HTTPOnly bool      `json:"http_only,omitempty"`
+	SameSite string    `json:"same_site,omitempty"`
+	Expires  time.Time `json:"expires,omitzero"`
+	Created  time.Time `json:"created"`
+}
+
+func (e Entry) expired(at time.Time) bool {
+	return !e.Expires.IsZero() && !at.Before(e.Expires)
+}
+
+func (e Entry) key() string {
+	return e.Domain + ";" + e.Path + ";" + e.Name
+}
+
+type jarFile struct {
+	Cookies []Entry `json:"cookies"`
+}
+
+// Jar is an http.CookieJar following the RFC 6265 domain, path, expiry and
+// Secure rules, persisted as JSON.
+type Jar struct {
+	path string
+	now  func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]Entry
+	// saveMu orders concurrent saves so the last write holds the newest
+	// cookies.
+	saveMu sync.Mutex
+}
+
+// Load reads the jar at path; a missing file yields an empty jar.
+func Load(path string) (*Jar, error) {
+	jar := &Jar{path: path, now: time.Now, entries: make(map[string]Entry)}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return jar, nil
+		}
+		return nil, fmt.Errorf("read cookie jar: %w", err)
+	}
+
+	var file jarFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("decode cookie jar %q: %w", path, err)
+	}
+	for _, entry := range file.Cookies {
+		jar.entries[entry.key()] = entry
+	}
+	return jar, nil
+}
+
+// Save writes unexpired cookies back to the jar file.
+func (j *Jar) Save() error {
+	j.saveMu.Lock()
+	defer j.saveMu.Unlock()
+
+	entries := j.Entries()
+	payload, err := json.MarshalIndent(jarFile{Cookies: entries}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode cookie jar: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(j.path), 0o700); err != nil {
+		return fmt.Errorf("create cookie jar directory: %w", err)
+	}
+	if err := os.WriteFile(j.path, append(payload, '\n'), 0o600); err != nil {
+		return fmt.Errorf("write cookie jar: %w", err)
+	}
+	return nil
+}
+
+// Entries returns unexpired cookies ordered by domain, path and name.
+func (j *Jar) Entries() []Entry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	at := j.now()
+	out := make([]Entry, 0, len(j.entries))
+	for _, entry := range j.entries {
+		if !entry.expired(at) {
+			out = append(out, entry)
+		}
+	}
+	sort.Slice(out, func(a, b int) bool {
+		if out[a].Domain != out[b].Domain {
+			return out[a].Domain < out[b].Domain
+		}
+		if out[a].Path != out[b].Path {
+			return out[a].Path < out[b].Path
+		}
+		return out[a].Name < out[b].Name
+	})
+	return out
+}
+
+// Clear removes cookies whose domain matches domain, or all cookies when
+// domain is empty. It returns the number removed.
+func (j *Jar) Clear(domain string) int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	domain = strings.TrimPrefix(strings.ToLower(domain), ".")
+	removed := 0
+	for key, entry := range j.entries {
+		if domain == "" || entry.Domain == domain || strings.HasSuffix(entry.Domain, "."+domain) {
+			delete(j.entries, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+// SetCookies implements http.CookieJar.
+func (j *Jar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	host, err := canonicalHost(u.Host)
+	if err != nil {
+		return
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	at := j.now()
+	for _, cookie := range cookies {
+		entry, ok := newEntry(cookie, u, host, at)
+		if !ok {
+			continue
+		}
+		key := entry.key()
+		if entry.expired(at) {
+			delete(j.entries, key)
+			continue
+		}
+		if previous, ok := j.entries[key]; ok {
+			entry.Created = previous.Created
+		}
+		j.entries[key] = entry
+	}
+}
+
+// Cookies implements http.CookieJar.
+func (j *Jar) Cookies(u *url.URL) []*http.Cookie {
+	host, err := canonicalHost(u.Host)
+	if err != nil {
+		return nil
+	}
+	secure := u.Scheme == "https" || u.Scheme == "wss"
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	at := j.now()
+	var matched []Entry
+	for key, entry := range j.entries {
+		if entry.expired(at) {
+			delete(j.entries, key)
+			continue
+		}
+		if entry.Secure && !secure {
+			continue
+		}
+		if entry.HostOnly && host != entry.Domain {
+			continue
+		}
+		if !entry.HostOnly && !domainMatch(host, entry.Domain) {
+			continue
+		}
+		if !pathMatch(path, entry.Path) {
+			continue
+		}
+		matched = append(matched, entry)
+	}
+
+	// Longer paths first, then older cookies first (RFC 6265 section 5.4).
+	sort.Slice(matched, func(a, b int) bool {
+		if len(matched[a].Path) != len(matched[b].Path) {
+			return len(matched[a].Path) > len(matched[b].Path)
+		}
+		return matched[a].Created.Before(matched[b].Created)
+	})
+
+	out := make([]*http.Cookie, 0, len(matched))
+	for _, entry := range matched {
+		out = append(out, &http.Cookie{Name: entry.Name, Value: entry.Value})
+	}
+	return out
+}
+
+func newEntry(cookie *http.Cookie, u *url.URL, host string, at time.Time) (Entry, bool) {
+	entry := Entry{
+		Name:     cookie.Name,
+		Value:    cookie.Value,
+		Secure:   cookie.Secure,
+		HTTPOnly: cookie.HttpOnly,
+		SameSite: sameSiteName(cookie.SameSite),
+		Created:  at.UTC(),
+	}
+
+	domain := strings.TrimPrefix(strings.ToLower(cookie.Domain), ".")
+	switch {
+	case domain == "" || domain == host:
+		entry.Domain = host
+		entry.HostOnly = domain == ""
+	case net.ParseIP(host) != nil || !strings.Contains(domain, "."):
+		// IP hosts cannot set domain cookies, and a bare label like "com"
+		// would leak the cookie to every site under it.
+		return Entry{}, false
+	case !domainMatch(host, domain):
+		return Entry{}, false
+	default:
+		entry.Domain = domain
+	}
+
+	// Secure cookies may only be set from secure origins, except localhost.
+	if entry.Secure && u.Scheme != "https" && u.Scheme != "wss" && !isLocalhost(host) {
+		return Entry{}, false
+	}
+
+	entry.Path = cookie.Path
+	if entry.Path == "" || !strings.HasPrefix(entry.Path, "/") {
+		entry.Path = defaultPath(u.Path)
+	}
+
+	switch {
+	case cookie.MaxAge < 0:
+		entry.Expires = at.Add(-time.Second).UTC()
+	case cookie.MaxAge > 0:
+		entry.Expires = at.Add(time.Duration(cookie.MaxAge) * time.Second).UTC()
+	case !cookie.Expires.IsZero():
+		entry.Expires = cookie.Expires.UTC()
+	}
+	return entry, true
+}
+
+func canonicalHost(host string) (string, error) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "" {
+		return "", fmt.Errorf("empty host")
+	}
+	return host, nil
+}
+
+func domainMatch(host, domain string) bool {
+	if host == domain {
+		return true
+	}
+	return strings.HasSuffix(host, "."+domain) && net.ParseIP(host) == nil
+}
+
+func pathMatch(requestPath, cookiePath string) bool {
+	if requestPath == cookiePath {
+		return true
+	}
+	if !strings.HasPrefix(requestPath, cookiePath) {
+		return false
+	}
+	return strings.HasSuffix(cookiePath, "/") || requestPath[len(cookiePath)] == '/'
+}
+
+func defaultPath(path string) string {
+	if path == "" || path[0] != '/' {
+		return "/"
+	}
+	i := strings.LastIndex(path, "/")
+	if i == 0 {
+		return "/"
+	}
+	return path[:i]
+}
+
+func isLocalhost(host string) bool {
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func sameSiteName(mode http.SameSite) string {
+	switch mode {
+	case http.SameSiteLaxMode:
+		return "Lax"
+	case http.SameSiteStrictMode:
+		return "Strict"
+	case http.SameSiteNoneMode:
+		return "None"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,128 @@
+package cookies
+
+import (
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func mustURL(t *testing.T, raw string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("parse %q: %v", raw, err)
+	}
+	return u
+}
+
+func cookieNames(list []*http.Cookie) string {
+	names := make([]string, 0, len(list))
+	for _, cookie := range list {
+		names = append(names, cookie.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestJar_DomainPathSecureAndExpiry(t *testing.T) {
+	current := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	jar, err := Load(filepath.Join(t.TempDir(), "dev.json"))
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	jar.now = func() time.Time { return current }
+
+	jar.SetCookies(mustURL(t, "https://api.example.com/v1/login"), []*http.Cookie{
+		{Name: "host", Value: "1"},
+		{Name: "shared", Value: "2", Domain: ".example.com", Path: "/"},
+		{Name: "scoped", Value: "3", Path: "/v1/admin"},
+		{Name: "secure", Value: "4", Secure: true, Path: "/"},
+		{Name: "short", Value: "5", MaxAge: 60, Path: "/"},
+		{Name: "foreign", Value: "6", Domain: "other.com"},
+		{Name: "tld", Value: "7", Domain: "com"},
+	})
+
+	cases := []struct {
+		url  string
+		want string
+	}{
+		{"https://api.example.com/v1/users", "host,secure,shared,short"},
+		{"https://api.example.com/v1/admin/x", "scoped,host,secure,shared,short"},
+		{"http://api.example.com/v1/users", "host,shared,short"},
+		{"https://www.example.com/", "shared"},
+		{"https://api.example.com/v1admin", "shared,secure,short"},
+	}
+	for _, tc := range cases {
+		got := cookieNames(jar.Cookies(mustURL(t, tc.url)))
+		if !sameSet(got, tc.want) {
+			t.Fatalf("Cookies(%s) = %s, want %s", tc.url, got, tc.want)
+		}
+	}
+	if got := cookieNames(jar.Cookies(mustURL(t, "https://api.example.com/v1/admin/x"))); !strings.HasPrefix(got, "scoped,") {
+		t.Fatalf("expected the longest path first, got %s", got)
+	}
+
+	current = current.Add(2 * time.Minute)
+	if got := cookieNames(jar.Cookies(mustURL(t, "https://www.example.com/"))); got != "shared" {
+		t.Fatalf("expected expired cookie to be dropped, got %s", got)
+	}
+
+	jar.SetCookies(mustURL(t, "https://api.example.com/"), []*http.Cookie{{Name: "shared", Domain: "example.com", Path: "/", MaxAge: -1}})
+	if got := cookieNames(jar.Cookies(mustURL(t, "https://www.example.com/"))); got != "" {
+		t.Fatalf("expected Max-Age<0 to delete the cookie, got %s", got)
+	}
+}
+
+func TestJar_PersistsAndClears(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cookies", "dev.json")
+	jar, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	jar.SetCookies(mustURL(t, "http://localhost:8080/login"), []*http.Cookie{
+		{Name: "session", Value: "abc", HttpOnly: true},
+		{Name: "pref", Value: "dark", Expires: time.Now().Add(time.Hour)},
+	})
+	if err := jar.Save(); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+
+	reloaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	got := reloaded.Cookies(mustURL(t, "http://localhost:9090/profile"))
+	if !sameSet(cookieNames(got), "pref,session") {
+		t.Fatalf("expected session and persistent cookies after reload, got %s", cookieNames(got))
+	}
+
+	if removed := reloaded.Clear("localhost"); removed != 2 {
+		t.Fatalf("expected 2 cookies cleared, got %d", removed)
+	}
+	if len(reloaded.Entries()) != 0 {
+		t.Fatalf("expected empty jar, got %+v", reloaded.Entries())
+	}
+}
+
+func sameSet(got, want string) bool {
+	a := strings.Split(got, ",")
+	b := strings.Split(want, ",")
+	if len(a) != len(b) {
+		return false
+	}
+	seen := map[string]int{}
+	for _, name := range a {
+		seen[name]++
+	}
+	for _, name := range b {
+		seen[name]--
+	}
+	for _, count := range seen {
+		if count != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,315 @@
+package grpcclient
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/jaykbpark/wirepad/internal/httpclient"
+	"github.com/jaykbpark/wirepad/internal/requestspec"
+)
+
+// maxMessageBytes bounds a single response message.
+const maxMessageBytes = 64 << 20
+
+var codeNames = []string{
+	"OK", "CANCELLED", "UNKNOWN", "INVALID_ARGUMENT", "DEADLINE_EXCEEDED", "NOT_FOUND",
+	"ALREADY_EXISTS", "PERMISSION_DENIED", "RESOURCE_EXHAUSTED", "FAILED_PRECONDITION",
+	"ABORTED", "OUT_OF_RANGE", "UNIMPLEMENTED", "INTERNAL", "UNAVAILABLE", "DATA_LOSS",
+	"UNAUTHENTICATED",
+}
+
+// CodeName returns the canonical name of a gRPC status code.
+func CodeName(code int) string {
+	if code >= 0 && code < len(codeNames) {
+		return codeNames[code]
+	}
+	return "CODE_" + strconv.Itoa(code)
+}
+
+type Response struct {
+	StartedAt time.Time
+	Duration  time.Duration
+	Proto     string
+	// Service and Method are the fully-qualified names that were called.
+	Service string
+	Method  string
+	// Code and Message are the gRPC status from grpc-status and grpc-message.
+	Code     int
+	Message  string
+	Headers  http.Header
+	Trailers http.Header
+	// Messages holds each response message in the proto3 JSON mapping.
+	Messages []json.RawMessage
+	// Body is the response message for unary calls and a JSON array of
+	// messages for server-streaming calls.
+	Body            []byte
+	ServerStreaming bool
+}
+
+type Options struct {
+	// Context cancels the call. Cancelling a server stream ends it without an
+	// error so the messages so far can still be recorded.
+	Context context.Context
+	// OnMessage receives each message of a server stream as it arrives,
+	// indexed from zero. Returning false stops reading.
+	OnMessage func(index int, message json.RawMessage) bool
+}
+
+// Execute makes a unary or server-streaming call described by a kind=grpc
+// spec over HTTP/2; http URLs use h2c.
+func Execute(spec *requestspec.Spec, requestPath string, opts Options) (*Response, error) {
+	if spec == nil || spec.Request == nil {
+		return nil, fmt.Errorf("missing request block")
+	}
+	req := *spec.Request
+
+	target, err := url.Parse(req.URL)
+	if err != nil {
+		return nil, fmt.Errorf("parse request url: %w", err)
+	}
+	switch target.Scheme {
+	case "http":
+		req.HTTPVersion = "h2c"
+	case "https":
+		req.HTTPVersion = "2"
+	default:
+		return nil, fmt.Errorf("grpc request url must use http (h2c) or https, got %q", target.Scheme)
+	}
+
+	baseDir := filepath.Dir(requestPath)
+	reg, err := loadRegistry(&req, baseDir)
+	if err != nil {
+		return nil, err
+	}
+	service, method, err := reg.method(req.Service, req.Method)
+	if err != nil {
+		return nil, err
+	}
+	if method.IsStreamingClient() {
+		return nil, fmt.Errorf("%s/%s is client-streaming; only unary and server-streaming calls are supported", service.FullName(), method.Name())
+	}
+
+	payload, err := encodeMessage(method.Input(), req.Message)
+	if err != nil {
+		return nil, fmt.Errorf("encode request.message: %w", err)
+	}
+	frame := make([]byte, 5, 5+len(payload))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(payload)))
+	frame = append(frame, payload...)
+
+	transport, err := httpclient.NewTransport(&req, baseDir)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Transport: transport}
+
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	timeout := time.Duration(req.TimeoutMS) * time.Millisecond
+	if timeout <= 0 && !method.IsStreamingServer() {
+		timeout = 30 * time.Second
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	target.Path = strings.TrimSuffix(target.Path, "/") + "/" + string(service.FullName()) + "/" + string(method.Name())
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, target.String(), bytes.NewReader(frame))
+	if err != nil {
+		return nil, fmt.Errorf("build grpc request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/grpc")
+	httpReq.Header.Set("TE", "trailers")
+	httpReq.Header.Set("User-Agent", "wirepad")
+	httpReq.Header.Set("Grpc-Accept-Encoding", "gzip")
+	if timeout > 0 {
+		httpReq.Header.Set("Grpc-Timeout", strconv.FormatInt(timeout.Milliseconds(), 10)+"m")
+	}
+	for key, value := range req.Metadata {
+		values, ok := value.([]any)
+		if !ok {
+			values = []any{value}
+		}
+		for _, item := range values {
+			text := fmt.Sprint(item)
+			// Binary metadata travels base64-encoded, unpadded per the gRPC spec.
+			if strings.HasSuffix(strings.ToLower(key), "-bin") {
+				text = base64.RawStdEncoding.EncodeToString([]byte(text))
+			}
+			httpReq.Header.Add(key, text)
+		}
+	}
+	if err := setAuthMetadata(httpReq, req.Auth); err != nil {
+		return nil, err
+	}
+
+	start := time.Now().UTC()
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("execute grpc request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	out := &Response{
+		StartedAt:       start,
+		Proto:           resp.Proto,
+		Service:         string(service.FullName()),
+		Method:          string(method.Name()),
+		Headers:         resp.Header.Clone(),
+		ServerStreaming: method.IsStreamingServer(),
+	}
+
+	onMessage := opts.OnMessage
+	if !method.IsStreamingServer() {
+		onMessage = nil
+	}
+	stopped := false
+	if resp.StatusCode != http.StatusOK {
+		out.Code, out.Message = httpStatusCode(resp.StatusCode), "HTTP status "+resp.Status
+	} else if stopped, err = readMessages(resp, method.Output(), out, onMessage); err != nil {
+		if opts.Context == nil || opts.Context.Err() == nil {
+			return nil, err
+		}
+		stopped = true
+	}
+	out.Duration = time.Since(start)
+	out.Trailers = resp.Trailer.Clone()
+
+	// A trailers-only response carries the status in its headers.
+	status := resp.Trailer.Get("Grpc-Status")
+	message := resp.Trailer.Get("Grpc-Message")
+	if status == "" {
+		status, message = resp.Header.Get("Grpc-Status"), resp.Header.Get("Grpc-Message")
+	}
+	if status != "" {
+		code, err := strconv.Atoi(status)
+		if err != nil {
+			return nil, fmt.Errorf("invalid grpc-status %q", status)
+		}
+		out.Code = code
+		if decoded, err := url.PathUnescape(message); err == nil {
+			message = decoded
+		}
+		out.Message = message
+	} else if stopped {
+		out.Code, out.Message = 1, "stream stopped by client"
+	} else if resp.StatusCode == http.StatusOK {
+		out.Code, out.Message = 2, "response ended without grpc-status"
+	}
+
+	if method.IsStreamingServer() {
+		list := out.Messages
+		if list == nil {
+			list = []json.RawMessage{}
+		}
+		out.Body, _ = json.Marshal(list)
+	} else if len(out.Messages) > 0 {
+		out.Body = out.Messages[len(out.Messages)-1]
+	}
+	return out, nil
+}
+
+// readMessages reads length-prefixed messages until the body ends. stopped
+// reports that onMessage ended the stream early.
+func readMessages(resp *http.Response, output protoreflect.MessageDescriptor, out *Response, onMessage func(int, json.RawMessage) bool) (stopped bool, err error) {
+	compressed := resp.Header.Get("Grpc-Encoding")
+	header := make([]byte, 5)
+	for {
+		if _, err := io.ReadFull(resp.Body, header); err != nil {
+			if errors.Is(err, io.EOF) {
+				return false, nil
+			}
+			return false, fmt.Errorf("read grpc message: %w", err)
+		}
+		size := binary.BigEndian.Uint32(header[1:])
+		if size > maxMessageBytes {
+			return false, fmt.Errorf("grpc message of %d bytes exceeds the %d byte limit", size, maxMessageBytes)
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(resp.Body, data); err != nil {
+			return false, fmt.Errorf("read grpc message: %w", err)
+		}
+		if header[0] == 1 {
+			if compressed != "gzip" {
+				return false, fmt.Errorf("unsupported grpc-encoding %q", compressed)
+			}
+			zr, err := gzip.NewReader(bytes.NewReader(data))
+			if err != nil {
+				return false, fmt.Errorf("decompress grpc message: %w", err)
+			}
+			if data, err = io.ReadAll(io.LimitReader(zr, maxMessageBytes)); err != nil {
+				return false, fmt.Errorf("decompress grpc message: %w", err)
+			}
+		}
+
+		value, err := decodeMessage(output, data)
+		if err != nil {
+			return false, fmt.Errorf("decode response message: %w", err)
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return false, fmt.Errorf("encode response message: %w", err)
+		}
+		out.Messages = append(out.Messages, encoded)
+		if onMessage != nil && !onMessage(len(out.Messages)-1, encoded) {
+			return true, nil
+		}
+	}
+}
+
+// setAuthMetadata sends request.auth as call metadata. Only credentials that
+// fit in a header apply; validation rejects the others for kind=grpc.
+func setAuthMetadata(req *http.Request, auth *requestspec.Auth) error {
+	if auth == nil {
+		return nil
+	}
+	switch {
+	case auth.Type == "basic":
+		req.SetBasicAuth(auth.Username, auth.Password)
+	case auth.Type == "bearer":
+		req.Header.Set("Authorization", "Bearer "+auth.Token)
+	case auth.Type == "api_key" && auth.In != "query":
+		req.Header.Set(auth.Name, auth.Value)
+	default:
+		return fmt.Errorf("request.auth type %q is not supported for grpc requests", auth.Type)
+	}
+	return nil
+}
+
+// httpStatusCode maps a non-200 HTTP status to a gRPC code as described in
+// the gRPC HTTP/2 protocol spec.
+func httpStatusCode(status int) int {
+	switch status {
+	case http.StatusBadRequest:
+		return 13
+	case http.StatusUnauthorized:
+		return 16
+	case http.StatusForbidden:
+		return 7
+	case http.StatusNotFound:
+		return 12
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return 14
+	default:
+		return 2
+	}
+}
@@ -0,0 +1,337 @@
+package grpcclient
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/jaykbpark/wirepad/internal/requestspec"
+)
+
+const greeterProto = `
+syntax = "proto3";
+
+package demo.v1;
+
+import "google/protobuf/timestamp.proto";
+
+option go_package = "example.com/demo/v1;demo";
+
+service Greeter {
+  rpc SayHello (HelloRequest) returns (HelloReply);
+  rpc Count (CountRequest) returns (stream CountReply) {}
+  rpc Upload (stream HelloRequest) returns (HelloReply);
+}
+
+enum Mood {
+  MOOD_UNSPECIFIED = 0;
+  HAPPY = 1;
+}
+
+message HelloRequest {
+  string name = 1;
+  repeated int32 lucky = 2 [packed = true];
+  map<string, string> tags = 3;
+  Mood mood = 4;
+  reserved 9;
+}
+
+message HelloReply {
+  string message = 1;
+  int64 id = 2;
+  google.protobuf.Timestamp at = 3;
+  Mood mood = 4;
+  oneof extra {
+    string note = 5;
+  }
+}
+
+message CountRequest { int32 to = 1; }
+message CountReply { int32 n = 1; }
+`
+
+// greeterServer is an in-process gRPC server over h2c built on the same
+// descriptors the client loads.
+func greeterServer(t *testing.T, reg *registry) *httptest.Server {
+	t.Helper()
+	_, sayHello, _ := reg.method("demo.v1.Greeter", "SayHello")
+	_, count, _ := reg.method("demo.v1.Greeter", "Count")
+
+	writeMessage := func(w http.ResponseWriter, md protoreflect.MessageDescriptor, value any) {
+		payload, err := encodeMessage(md, value)
+		if err != nil {
+			t.Errorf("server encode: %v", err)
+			return
+		}
+		header := make([]byte, 5)
+		binary.BigEndian.PutUint32(header[1:], uint32(len(payload)))
+		_, _ = w.Write(append(header, payload...))
+		w.(http.Flusher).Flush()
+	}
+	readMessage := func(r *http.Request, md protoreflect.MessageDescriptor) map[string]any {
+		body, _ := io.ReadAll(r.Body)
+		if len(body) < 5 {
+			return nil
+		}
+		value, err := decodeMessage(md, body[5:])
+		if err != nil {
+			t.Errorf("server decode: %v", err)
+		}
+		object, _ := value.(map[string]any)
+		return object
+	}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/grpc" || r.Header.Get("Authorization") != "Bearer t0k" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/grpc")
+		switch r.URL.Path {
+		case "/demo.v1.Greeter/SayHello":
+			in := readMessage(r, sayHello.Input())
+			if in["name"] == "nobody" {
+				w.Header().Set("Grpc-Status", "5")
+				w.Header().Set("Grpc-Message", "no such person")
+				return
+			}
+			lucky, _ := json.Marshal(in["lucky"])
+			tags, _ := json.Marshal(in["tags"])
+			w.Header().Set("X-Served-By", "greeter")
+			w.Header().Set("X-Seen-Metadata", strings.Join(append(r.Header.Values("X-Role"), r.Header.Values("Trace-Bin")...), ","))
+			writeMessage(w, sayHello.Output(), map[string]any{
+				"message": "hello " + in["name"].(string) + " " + string(lucky) + " " + string(tags),
+				"id":      "9007199254740993",
+				"at":      "2026-03-01T10:00:00Z",
+				"mood":    in["mood"],
+			})
+			w.Header().Set(http.TrailerPrefix+"Grpc-Status", "0")
+			w.Header().Set(http.TrailerPrefix+"X-Trace", "abc")
+		case "/demo.v1.Greeter/Count":
+			in := readMessage(r, count.Input())
+			to, _ := in["to"].(json.Number).Int64()
+			for n := int64(1); n <= to; n++ {
+				writeMessage(w, count.Output(), map[string]any{"n": n})
+			}
+			w.Header().Set(http.TrailerPrefix+"Grpc-Status", "0")
+		default:
+			w.Header().Set("Grpc-Status", "12")
+		}
+	}))
+	server.Config.Protocols = new(http.Protocols)
+	server.Config.Protocols.SetUnencryptedHTTP2(true)
+	server.Start()
+	return server
+}
+
+func greeterSpec(t *testing.T, url, method string, message map[string]any) (*requestspec.Spec, string) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "protos"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "protos", "greeter.proto"), []byte(greeterProto), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return &requestspec.Spec{
+		Kind: requestspec.KindGRPC,
+		Name: "greeter",
+		Request: &requestspec.Request{
+			URL:      url,
+			Service:  "demo.v1.Greeter",
+			Method:   method,
+			Message:  message,
+			Metadata: map[string]any{"authorization": "Bearer t0k"},
+			Proto:    []string{"protos/greeter.proto"},
+		},
+	}, filepath.Join(dir, "greeter.req.yaml")
+}
+
+func TestExecute_UnaryCall(t *testing.T) {
+	spec, path := greeterSpec(t, "", "SayHello", map[string]any{
+		"name":  "ada",
+		"lucky": []any{7, "13"},
+		"tags":  map[string]any{"team": "core"},
+		"mood":  "HAPPY",
+	})
+	spec.Request.Metadata["x-role"] = []any{"admin", "ops"}
+	spec.Request.Metadata["trace-bin"] = "hi\x00"
+	reg, err := loadRegistry(spec.Request, filepath.Dir(path))
+	if err != nil {
+		t.Fatalf("loadRegistry returned error: %v", err)
+	}
+	server := greeterServer(t, reg)
+	defer server.Close()
+	spec.Request.URL = server.URL
+
+	resp, err := Execute(spec, path, Options{})
+	if err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+	if resp.Code != 0 || resp.Proto != "HTTP/2.0" {
+		t.Fatalf("expected OK over HTTP/2, got code=%d %q proto=%s", resp.Code, resp.Message, resp.Proto)
+	}
+	var reply map[string]any
+	if err := json.Unmarshal(resp.Body, &reply); err != nil {
+		t.Fatalf("decode body %s: %v", resp.Body, err)
+	}
+	want := map[string]any{
+		"message": `hello ada [7,13] {"team":"core"}`,
+		"id":      "9007199254740993",
+		"at":      "2026-03-01T10:00:00Z",
+		"mood":    "HAPPY",
+	}
+	for key, value := range want {
+		if reply[key] != value {
+			t.Fatalf("reply.%s = %v, want %v (body %s)", key, reply[key], value, resp.Body)
+		}
+	}
+	if _, ok := reply["note"]; ok {
+		t.Fatalf("unset oneof member should be omitted, got %s", resp.Body)
+	}
+	if resp.Headers.Get("X-Served-By") != "greeter" || resp.Trailers.Get("X-Trace") != "abc" {
+		t.Fatalf("expected headers and trailers, got %v / %v", resp.Headers, resp.Trailers)
+	}
+	if got := resp.Headers.Get("X-Seen-Metadata"); got != "admin,ops,aGkA" {
+		t.Fatalf("expected repeated and base64 -bin metadata, got %q", got)
+	}
+
+	spec.Request.Message = map[string]any{"name": "nobody"}
+	resp, err = Execute(spec, path, Options{})
+	if err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+	if resp.Code != 5 || CodeName(resp.Code) != "NOT_FOUND" || resp.Message != "no such person" || len(resp.Body) != 0 {
+		t.Fatalf("expected trailers-only NOT_FOUND, got %+v", resp)
+	}
+
+	spec.Request.Message = map[string]any{"nickname": "x"}
+	if _, err := Execute(spec, path, Options{}); err == nil || !strings.Contains(err.Error(), `unknown field "nickname"`) {
+		t.Fatalf("expected unknown field error, got %v", err)
+	}
+
+	spec.Request.Method = "Upload"
+	if _, err := Execute(spec, path, Options{}); err == nil || !strings.Contains(err.Error(), "client-streaming") {
+		t.Fatalf("expected client-streaming error, got %v", err)
+	}
+}
+
+func TestExecute_ServerStreaming(t *testing.T) {
+	spec, path := greeterSpec(t, "", "Count", map[string]any{"to": 3})
+	reg, err := loadRegistry(spec.Request, filepath.Dir(path))
+	if err != nil {
+		t.Fatalf("loadRegistry returned error: %v", err)
+	}
+	server := greeterServer(t, reg)
+	defer server.Close()
+	spec.Request.URL = server.URL
+
+	var seen []string
+	resp, err := Execute(spec, path, Options{OnMessage: func(index int, message json.RawMessage) bool {
+		seen = append(seen, string(message))
+		return true
+	}})
+	if err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+	if resp.Code != 0 || string(resp.Body) != `[{"n":1},{"n":2},{"n":3}]` || len(seen) != 3 {
+		t.Fatalf("unexpected stream result code=%d body=%s seen=%v", resp.Code, resp.Body, seen)
+	}
+}
+
+func TestExecute_SendsAuthAsMetadata(t *testing.T) {
+	spec, path := greeterSpec(t, "", "SayHello", map[string]any{"name": "ada"})
+	delete(spec.Request.Metadata, "authorization")
+	spec.Request.Auth = &requestspec.Auth{Type: "bearer", Token: "t0k"}
+	reg, err := loadRegistry(spec.Request, filepath.Dir(path))
+	if err != nil {
+		t.Fatalf("loadRegistry returned error: %v", err)
+	}
+	server := greeterServer(t, reg)
+	defer server.Close()
+	spec.Request.URL = server.URL
+
+	resp, err := Execute(spec, path, Options{})
+	if err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+	if resp.Code != 0 {
+		t.Fatalf("expected the bearer token to be sent as authorization metadata, got code=%d %q", resp.Code, resp.Message)
+	}
+
+	spec.Request.Auth = &requestspec.Auth{Type: "digest", Username: "ada", Password: "pw"}
+	if _, err := Execute(spec, path, Options{}); err == nil || !strings.Contains(err.Error(), `"digest" is not supported`) {
+		t.Fatalf("expected unsupported auth error, got %v", err)
+	}
+}
+
+func TestDecodeDescriptorSet(t *testing.T) {
+	field := func(name string, number int32, label descriptorpb.FieldDescriptorProto_Label, typ descriptorpb.FieldDescriptorProto_Type, typeName string) *descriptorpb.FieldDescriptorProto {
+		f := &descriptorpb.FieldDescriptorProto{Name: proto.String(name), Number: proto.Int32(number), Label: label.Enum(), Type: typ.Enum()}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+	const (
+		optional = descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+		repeated = descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+	)
+	set, err := proto.Marshal(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{{
+		Name:    proto.String("shop.proto"),
+		Package: proto.String("shop"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("Item"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("sku_id", 1, optional, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+			}},
+			{Name: proto.String("ItemList"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("items", 1, repeated, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".shop.Item"),
+				field("counts", 2, repeated, descriptorpb.FieldDescriptorProto_TYPE_INT32, ""),
+			}},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name:   proto.String("Shop"),
+			Method: []*descriptorpb.MethodDescriptorProto{{Name: proto.String("List"), InputType: proto.String(".shop.Item"), OutputType: proto.String(".shop.ItemList")}},
+		}},
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := decodeDescriptorSet(set)
+	if err != nil {
+		t.Fatalf("decodeDescriptorSet returned error: %v", err)
+	}
+	_, m, err := newRegistry(files).method("Shop", "List")
+	if err != nil {
+		t.Fatalf("method lookup: %v", err)
+	}
+
+	encoded, err := encodeMessage(m.Output(), map[string]any{"items": []any{map[string]any{"skuId": "a1"}}, "counts": []any{1, 2}})
+	if err != nil {
+		t.Fatalf("encodeMessage returned error: %v", err)
+	}
+	decoded, err := decodeMessage(m.Output(), encoded)
+	if err != nil {
+		t.Fatalf("decodeMessage returned error: %v", err)
+	}
+	payload, _ := json.Marshal(decoded)
+	if string(payload) != `{"counts":[1,2],"items":[{"skuId":"a1"}]}` {
+		t.Fatalf("unexpected round trip %s", payload)
+	}
+
+	if _, err := decodeDescriptorSet(set[:len(set)-3]); err == nil {
+		t.Fatal("expected a truncated descriptor set to fail")
+	}
+}
@@ -0,0 +1,50 @@
+package grpcclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// encodeMessage converts a JSON-like value (as decoded from YAML or JSON) to
+// protobuf bytes, following the proto3 JSON mapping.
+func encodeMessage(md protoreflect.MessageDescriptor, value any) ([]byte, error) {
+	msg := dynamicpb.NewMessage(md)
+	if value != nil {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		if err := protojson.Unmarshal(data, msg); err != nil {
+			return nil, err
+		}
+	}
+	return proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+}
+
+// decodeMessage converts protobuf bytes to the proto3 JSON mapping. Fields
+// without presence are included with their default value.
+func decodeMessage(md protoreflect.MessageDescriptor, data []byte) (any, error) {
+	msg := dynamicpb.NewMessage(md)
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return nil, fmt.Errorf("%s: %w", md.FullName(), err)
+	}
+	printed, err := protojson.MarshalOptions{EmitDefaultValues: true}.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", md.FullName(), err)
+	}
+	// Decoding keeps the numbers as printed and lets callers re-encode the
+	// message with sorted keys; protojson output is deliberately unstable.
+	decoder := json.NewDecoder(bytes.NewReader(printed))
+	decoder.UseNumber()
+	var out any
+	if err := decoder.Decode(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,143 @@
+package grpcclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/jaykbpark/wirepad/internal/requestspec"
+)
+
+// The fixtures in testdata/golden are produced by testdata/gen straight from
+// google.golang.org/protobuf and protocompile, so the tests below check that
+// the loader and codec wiring (descriptor sets, .proto imports, JSON mapping
+// options) matches what those libraries produce on their own.
+var goldenCases = []string{"scalars", "composite", "well_known"}
+
+func goldenRegistries(t *testing.T) map[string]*registry {
+	t.Helper()
+	set, err := os.ReadFile(filepath.Join("testdata", "golden", "golden.pb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := decodeDescriptorSet(set)
+	if err != nil {
+		t.Fatalf("decodeDescriptorSet returned error: %v", err)
+	}
+	fromSet := newRegistry(files)
+	fromProto, err := loadRegistry(&requestspec.Request{Proto: []string{"golden.proto"}}, filepath.Join("testdata", "golden"))
+	if err != nil {
+		t.Fatalf("loadRegistry returned error: %v", err)
+	}
+	return map[string]*registry{"descriptor_set": fromSet, "proto": fromProto}
+}
+
+func readGolden(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "golden", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func decodeJSON(t *testing.T, data []byte) any {
+	t.Helper()
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var out any
+	if err := decoder.Decode(&out); err != nil {
+		t.Fatalf("decode JSON %s: %v", data, err)
+	}
+	return out
+}
+
+func TestCodec_MatchesGoldenFixtures(t *testing.T) {
+	for source, reg := range goldenRegistries(t) {
+		_, echo, err := reg.method("golden.v1.Fixtures", "Echo")
+		if err != nil {
+			t.Fatalf("%s: method lookup: %v", source, err)
+		}
+		for _, name := range goldenCases {
+			wire := readGolden(t, name+".bin")
+			canonical := readGolden(t, name+".json")
+
+			encoded, err := encodeMessage(echo.Input(), decodeJSON(t, canonical))
+			if err != nil {
+				t.Fatalf("%s/%s: encodeMessage returned error: %v", source, name, err)
+			}
+			if !bytes.Equal(encoded, wire) {
+				t.Fatalf("%s/%s: encoded bytes differ from golden\n got: %x\nwant: %x", source, name, encoded, wire)
+			}
+
+			decoded, err := decodeMessage(echo.Output(), wire)
+			if err != nil {
+				t.Fatalf("%s/%s: decodeMessage returned error: %v", source, name, err)
+			}
+			payload, err := json.Marshal(decoded)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := decodeJSON(t, payload), decodeJSON(t, canonical); !reflect.DeepEqual(got, want) {
+				t.Fatalf("%s/%s: decoded JSON differs from golden\n got: %s\nwant: %s", source, name, payload, strings.Join(strings.Fields(string(canonical)), ""))
+			}
+		}
+	}
+}
+
+func TestDecodeDescriptorSet_GoldenFixture(t *testing.T) {
+	files, err := decodeDescriptorSet(readGolden(t, "golden.pb"))
+	if err != nil {
+		t.Fatalf("decodeDescriptorSet returned error: %v", err)
+	}
+	var names []string
+	files.RangeFiles(func(file protoreflect.FileDescriptor) bool {
+		names = append(names, file.Path())
+		return true
+	})
+	sort.Strings(names)
+	want := []string{
+		"golden.proto",
+		"google/protobuf/duration.proto",
+		"google/protobuf/struct.proto",
+		"google/protobuf/timestamp.proto",
+		"google/protobuf/wrappers.proto",
+	}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("unexpected files %q", names)
+	}
+
+	reg := newRegistry(files)
+	if got := reg.serviceNames(); !reflect.DeepEqual(got, []string{"golden.v1.Fixtures"}) {
+		t.Fatalf("unexpected services %q", got)
+	}
+	_, watch, err := reg.method("golden.v1.Fixtures", "Watch")
+	if err != nil {
+		t.Fatalf("method lookup: %v", err)
+	}
+	if !watch.IsStreamingServer() || watch.IsStreamingClient() || watch.Input().FullName() != "golden.v1.Inner" {
+		t.Fatalf("unexpected Watch descriptor %v", watch)
+	}
+
+	everything, err := files.FindDescriptorByName("golden.v1.Everything")
+	if err != nil {
+		t.Fatalf("golden.v1.Everything missing from registry: %v", err)
+	}
+	fields := everything.(protoreflect.MessageDescriptor).Fields()
+	for name, want := range map[string]string{"f_sint64": "sint64", "packed_ints": "int32", "color": "enum", "at": "message"} {
+		field := fields.ByName(protoreflect.Name(name))
+		if field == nil {
+			t.Fatalf("field %s missing", name)
+		}
+		if got := field.Kind().String(); got != want {
+			t.Fatalf("field %s: kind %q, want %q", name, got, want)
+		}
+	}
+}
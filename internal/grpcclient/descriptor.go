@@ -0,0 +1,61 @@
+package grpcclient
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// registry indexes the services of a set of files by fully-qualified name,
+// without a leading dot.
+type registry struct {
+	files    *protoregistry.Files
+	services map[string]protoreflect.ServiceDescriptor
+}
+
+func newRegistry(files *protoregistry.Files) *registry {
+	reg := &registry{files: files, services: make(map[string]protoreflect.ServiceDescriptor)}
+	files.RangeFiles(func(file protoreflect.FileDescriptor) bool {
+		services := file.Services()
+		for i := 0; i < services.Len(); i++ {
+			reg.services[string(services.Get(i).FullName())] = services.Get(i)
+		}
+		return true
+	})
+	return reg
+}
+
+// method finds service/method; service may omit its package when the name
+// is unique.
+func (r *registry) method(service, method string) (protoreflect.ServiceDescriptor, protoreflect.MethodDescriptor, error) {
+	svc, ok := r.services[strings.TrimPrefix(service, ".")]
+	if !ok {
+		var matches []protoreflect.ServiceDescriptor
+		for name, candidate := range r.services {
+			if name == service || strings.HasSuffix(name, "."+service) {
+				matches = append(matches, candidate)
+			}
+		}
+		if len(matches) != 1 {
+			return nil, nil, fmt.Errorf("unknown service %q (known: %s)", service, strings.Join(r.serviceNames(), ", "))
+		}
+		svc = matches[0]
+	}
+	m := svc.Methods().ByName(protoreflect.Name(method))
+	if m == nil {
+		return nil, nil, fmt.Errorf("service %s has no method %q", svc.FullName(), method)
+	}
+	return svc, m, nil
+}
+
+func (r *registry) serviceNames() []string {
+	names := make([]string, 0, len(r.services))
+	for name := range r.services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,137 @@
+package grpcclient
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/jaykbpark/wirepad/internal/requestspec"
+)
+
+// loadRegistry builds the type registry for a request from
+// request.descriptor_set or request.proto. Paths resolve against baseDir, the
+// directory of the request file.
+func loadRegistry(req *requestspec.Request, baseDir string) (*registry, error) {
+	if req.DescriptorSet != "" {
+		data, err := os.ReadFile(resolvePath(baseDir, req.DescriptorSet))
+		if err != nil {
+			return nil, fmt.Errorf("read request.descriptor_set: %w", err)
+		}
+		files, err := decodeDescriptorSet(data)
+		if err != nil {
+			return nil, err
+		}
+		return newRegistry(files), nil
+	}
+
+	importPaths := make([]string, 0, len(req.ImportPaths)+1)
+	for _, path := range req.ImportPaths {
+		importPaths = append(importPaths, resolvePath(baseDir, path))
+	}
+	importPaths = append(importPaths, baseDir)
+
+	paths := make([]string, 0, len(req.Proto))
+	for _, path := range req.Proto {
+		paths = append(paths, resolvePath(baseDir, path))
+	}
+	files, err := compileProtos(paths, importPaths)
+	if err != nil {
+		return nil, err
+	}
+	return newRegistry(files), nil
+}
+
+// decodeDescriptorSet reads a FileDescriptorSet such as protoc
+// --include_imports --descriptor_set_out writes.
+func decodeDescriptorSet(data []byte) (*protoregistry.Files, error) {
+	set := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(data, set); err != nil {
+		return nil, fmt.Errorf("decode descriptor set: %w", err)
+	}
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("decode descriptor set: %w", err)
+	}
+	return files, nil
+}
+
+// compileProtos compiles .proto files and their imports. Imports resolve
+// against the import paths, then the importing file's directory, then the
+// built-in well-known types.
+func compileProtos(paths, importPaths []string) (*protoregistry.Files, error) {
+	for _, path := range paths {
+		importPaths = append(importPaths, filepath.Dir(path))
+	}
+	names := make([]string, 0, len(paths))
+	for _, path := range paths {
+		name, err := protoName(path, importPaths)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{ImportPaths: importPaths}),
+	}
+	compiled, err := compiler.Compile(context.Background(), names...)
+	if err != nil {
+		return nil, fmt.Errorf("parse proto: %w", err)
+	}
+
+	files := new(protoregistry.Files)
+	var add func(protoreflect.FileDescriptor) error
+	add = func(file protoreflect.FileDescriptor) error {
+		if _, err := files.FindFileByPath(file.Path()); err == nil {
+			return nil
+		}
+		imports := file.Imports()
+		for i := 0; i < imports.Len(); i++ {
+			if err := add(imports.Get(i).FileDescriptor); err != nil {
+				return err
+			}
+		}
+		return files.RegisterFile(file)
+	}
+	for _, file := range compiled {
+		if err := add(file); err != nil {
+			return nil, fmt.Errorf("register proto: %w", err)
+		}
+	}
+	return files, nil
+}
+
+// protoName returns path relative to the first import path that contains it,
+// the name the file is known by to its importers.
+func protoName(path string, importPaths []string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	for _, root := range importPaths {
+		root, err := filepath.Abs(root)
+		if err != nil {
+			return "", err
+		}
+		if rel, err := filepath.Rel(root, abs); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return filepath.ToSlash(rel), nil
+		}
+	}
+	return "", fmt.Errorf("proto file %s is outside the import paths", path)
+}
+
+func resolvePath(baseDir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(baseDir, path)
+}
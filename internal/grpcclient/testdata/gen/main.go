@@ -0,0 +1,139 @@
+// Command gen writes the golden protobuf fixtures under ../golden with
+// implementations independent of the grpcclient codec:
+//
+//   - golden.pb is the FileDescriptorSet for golden.proto and its imports,
+//     compiled by protocompile (the compiler behind buf build). It matches
+//     protoc --include_imports --descriptor_set_out=golden.pb golden.proto.
+//   - <case>.bin is each message in cases.json, parsed by protojson and
+//     marshalled deterministically by google.golang.org/protobuf.
+//   - <case>.json is that message printed back by protojson.
+//
+// Run it from this directory with: go run .
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const dir = "../golden"
+
+func main() {
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{ImportPaths: []string{dir}}),
+	}
+	files, err := compiler.Compile(context.Background(), "golden.proto")
+	if err != nil {
+		log.Fatal(err)
+	}
+	golden := files[0]
+
+	set := &descriptorpb.FileDescriptorSet{}
+	seen := map[string]bool{}
+	var add func(protoreflect.FileDescriptor)
+	add = func(fd protoreflect.FileDescriptor) {
+		if seen[fd.Path()] {
+			return
+		}
+		seen[fd.Path()] = true
+		imports := fd.Imports()
+		for i := 0; i < imports.Len(); i++ {
+			add(imports.Get(i).FileDescriptor)
+		}
+		set.File = append(set.File, protodesc.ToFileDescriptorProto(fd))
+	}
+	add(golden)
+	write("golden.pb", marshal(set))
+
+	raw, err := os.ReadFile(filepath.Join(dir, "cases.json"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	var cases map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &cases); err != nil {
+		log.Fatal(err)
+	}
+	everything := golden.Messages().ByName("Everything")
+	for name, input := range cases {
+		msg := dynamicpb.NewMessage(everything)
+		if err := protojson.Unmarshal(input, msg); err != nil {
+			log.Fatalf("%s: %v", name, err)
+		}
+		write(name+".bin", marshal(msg))
+		write(name+".json", printJSON(msg))
+	}
+}
+
+func marshal(m proto.Message) []byte {
+	out, err := proto.MarshalOptions{Deterministic: true}.Marshal(m)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return out
+}
+
+func write(name string, data []byte) {
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// printJSON prints m the way the grpcclient decoder does: proto3 defaults are
+// included, while unset fields with presence (messages, oneof members and
+// optional scalars) are left out rather than printed as null.
+func printJSON(m protoreflect.Message) []byte {
+	printed, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(m.Interface())
+	if err != nil {
+		log.Fatal(err)
+	}
+	var object map[string]any
+	if err := json.Unmarshal(printed, &object); err != nil {
+		log.Fatal(err)
+	}
+	dropUnset(m, object)
+	out, err := json.MarshalIndent(object, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	return append(out, '\n')
+}
+
+func dropUnset(m protoreflect.Message, object map[string]any) {
+	if m.Descriptor().FullName().Parent() == "google.protobuf" {
+		return
+	}
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if fd.HasPresence() && !m.Has(fd) {
+			delete(object, fd.JSONName())
+			continue
+		}
+		if fd.Message() == nil || fd.IsMap() {
+			continue
+		}
+		if fd.IsList() {
+			list, items := m.Get(fd).List(), object[fd.JSONName()].([]any)
+			for j := 0; j < list.Len(); j++ {
+				if child, ok := items[j].(map[string]any); ok {
+					dropUnset(list.Get(j).Message(), child)
+				}
+			}
+			continue
+		}
+		if child, ok := object[fd.JSONName()].(map[string]any); ok {
+			dropUnset(m.Get(fd).Message(), child)
+		}
+	}
+}
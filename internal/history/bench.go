@@ -0,0 +1,66 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const benchDir = ".wirepad/history/bench"
+
+// BenchRecord aggregates one wirepad bench run; individual requests are not
+// saved.
+type BenchRecord struct {
+	RunID       string `json:"run_id"`
+	RequestName string `json:"request_name"`
+	RequestPath string `json:"request_path"`
+	Env         string `json:"env,omitempty"`
+	Method      string `json:"method"`
+	URL         string `json:"url"`
+	StartedAt   string `json:"started_at"`
+	DurationMS  int64  `json:"duration_ms"`
+	Concurrency int    `json:"concurrency"`
+	// Limit is -n; zero when the run was bounded by DurationLimitMS only.
+	Limit           int     `json:"limit,omitempty"`
+	DurationLimitMS int64   `json:"duration_limit_ms,omitempty"`
+	Rate            float64 `json:"rate_per_second,omitempty"`
+	Requests        int     `json:"requests"`
+	Errors          int     `json:"errors"`
+	ThroughputRPS   float64 `json:"throughput_rps"`
+	BytesReceived   int64   `json:"bytes_received"`
+	Latency         Latency `json:"latency"`
+	// Statuses counts responses by status code; Errors are requests that got
+	// no response, counted by message in ErrorCounts.
+	Statuses    map[int]int    `json:"statuses"`
+	ErrorCounts map[string]int `json:"error_counts,omitempty"`
+}
+
+// Latency summarizes response times in milliseconds, from sending a request
+// until its body is read.
+type Latency struct {
+	MinMS  float64 `json:"min_ms"`
+	MeanMS float64 `json:"mean_ms"`
+	P50MS  float64 `json:"p50_ms"`
+	P90MS  float64 `json:"p90_ms"`
+	P99MS  float64 `json:"p99_ms"`
+	MaxMS  float64 `json:"max_ms"`
+}
+
+func SaveBenchRun(record BenchRecord) (string, error) {
+	if err := os.MkdirAll(benchDir, 0o755); err != nil {
+		return "", fmt.Errorf("create history bench directory: %w", err)
+	}
+
+	path := filepath.Join(benchDir, record.RunID+".json")
+	payload, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("encode bench record: %w", err)
+	}
+	payload = append(payload, '\n')
+
+	if err := os.WriteFile(path, payload, 0o644); err != nil {
+		return "", fmt.Errorf("write bench record: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,58 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const flowsDir = ".wirepad/history/flows"
+
+// FlowRecord is one run of a .flow.yaml. Each executed step links to the
+// RunRecord it produced.
+type FlowRecord struct {
+	RunID      string        `json:"run_id"`
+	FlowName   string        `json:"flow_name"`
+	FlowPath   string        `json:"flow_path"`
+	Env        string        `json:"env,omitempty"`
+	StartedAt  string        `json:"started_at"`
+	DurationMS int64         `json:"duration_ms"`
+	OK         bool          `json:"ok"`
+	Steps      []FlowStepRun `json:"steps"`
+}
+
+// FlowStepRun is one execution of a flow step; a for_each step has one per
+// item. Export values are redacted.
+type FlowStepRun struct {
+	Name            string            `json:"name"`
+	Request         string            `json:"request"`
+	Iteration       *int              `json:"iteration,omitempty"`
+	Skipped         bool              `json:"skipped,omitempty"`
+	RunID           string            `json:"run_id,omitempty"`
+	RunPath         string            `json:"run_path,omitempty"`
+	Status          int               `json:"status,omitempty"`
+	DurationMS      int64             `json:"duration_ms,omitempty"`
+	OK              bool              `json:"ok"`
+	ContinueOnError bool              `json:"continue_on_error,omitempty"`
+	Exports         map[string]string `json:"exports,omitempty"`
+	Error           string            `json:"error,omitempty"`
+}
+
+func SaveFlowRun(record FlowRecord) (string, error) {
+	if err := os.MkdirAll(flowsDir, 0o755); err != nil {
+		return "", fmt.Errorf("create history flows directory: %w", err)
+	}
+
+	path := filepath.Join(flowsDir, record.RunID+".json")
+	payload, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("encode flow record: %w", err)
+	}
+	payload = append(payload, '\n')
+
+	if err := os.WriteFile(path, payload, 0o644); err != nil {
+		return "", fmt.Errorf("write flow record: %w", err)
+	}
+	return path, nil
+}
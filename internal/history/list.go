@@ -0,0 +1,61 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LatestRun returns the most recent run record of the request at
+// requestPath that keep accepts, or nil when there is none.
+func LatestRun(requestPath string, keep func(RunRecord) bool) (*RunRecord, error) {
+	latest, err := LatestRuns(keep)
+	if err != nil {
+		return nil, err
+	}
+	return latest[filepath.Clean(requestPath)], nil
+}
+
+// LatestRuns reads runs/ once and returns the most recent record that keep
+// accepts for every request, keyed by the cleaned request path. Of records
+// started in the same second, the one with the greatest run ID wins.
+func LatestRuns(keep func(RunRecord) bool) (map[string]*RunRecord, error) {
+	latest := make(map[string]*RunRecord)
+	entries, err := os.ReadDir(runsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return latest, nil
+		}
+		return nil, fmt.Errorf("read history runs directory: %w", err)
+	}
+
+	latestAt := make(map[string]time.Time)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(runsDir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("read run record: %w", err)
+		}
+		var record RunRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			// Records from older versions or partial writes are not fatal.
+			continue
+		}
+		if keep != nil && !keep(record) {
+			continue
+		}
+		path := filepath.Clean(record.RequestPath)
+		at, _ := time.Parse(time.RFC3339, record.StartedAt)
+		current, ok := latest[path]
+		if !ok || at.After(latestAt[path]) || (at.Equal(latestAt[path]) && record.RunID > current.RunID) {
+			latest[path] = &record
+			latestAt[path] = at
+		}
+	}
+	return latest, nil
+}
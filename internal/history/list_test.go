@@ -0,0 +1,79 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func saveRunsForTest(t *testing.T, records ...RunRecord) {
+	t.Helper()
+	for _, record := range records {
+		if _, err := SaveRun(record); err != nil {
+			t.Fatalf("SaveRun(%s) returned error: %v", record.RunID, err)
+		}
+	}
+}
+
+func TestLatestRuns_FiltersAndPicksNewestPerRequest(t *testing.T) {
+	t.Chdir(t.TempDir())
+	saveRunsForTest(t,
+		RunRecord{RunID: "a-old", RequestPath: "requests/a.req.yaml", StartedAt: "2026-03-01T10:00:00Z", OK: true},
+		RunRecord{RunID: "a-new", RequestPath: "./requests/a.req.yaml", StartedAt: "2026-03-01T10:00:05Z", OK: true},
+		RunRecord{RunID: "a-failed", RequestPath: "requests/a.req.yaml", StartedAt: "2026-03-01T10:00:09Z"},
+		RunRecord{RunID: "b-only", RequestPath: "requests/b.req.yaml", StartedAt: "2026-03-01T09:00:00Z", OK: true},
+		RunRecord{RunID: "c-failed", RequestPath: "requests/c.req.yaml", StartedAt: "2026-03-01T09:00:00Z"},
+	)
+	if err := os.WriteFile(filepath.Join(runsDir, "partial.json"), []byte(`{"run_id":`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(runsDir, "notes.txt"), []byte("not a record"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	latest, err := LatestRuns(func(record RunRecord) bool { return record.OK })
+	if err != nil {
+		t.Fatalf("LatestRuns returned error: %v", err)
+	}
+	if len(latest) != 2 {
+		t.Fatalf("expected records for a and b only, got %v", latest)
+	}
+	if got := latest["requests/a.req.yaml"]; got == nil || got.RunID != "a-new" {
+		t.Fatalf("expected a-new for a (paths are cleaned, failed runs filtered), got %+v", got)
+	}
+	if got := latest["requests/b.req.yaml"]; got == nil || got.RunID != "b-only" {
+		t.Fatalf("expected b-only for b, got %+v", got)
+	}
+
+	got, err := LatestRun("requests/a.req.yaml", nil)
+	if err != nil || got == nil || got.RunID != "a-failed" {
+		t.Fatalf("expected the unfiltered latest run a-failed, got %+v (err=%v)", got, err)
+	}
+	if got, err := LatestRun("requests/missing.req.yaml", nil); err != nil || got != nil {
+		t.Fatalf("expected no run for an unknown request, got %+v (err=%v)", got, err)
+	}
+}
+
+func TestLatestRuns_BreaksTiesByRunID(t *testing.T) {
+	t.Chdir(t.TempDir())
+	// The second-resolution started_at of both runs is equal, so the run ID
+	// decides, whatever order the files are read in.
+	saveRunsForTest(t,
+		RunRecord{RunID: "2026-03-01T10-00-00Z_bbbb", RequestPath: "requests/a.req.yaml", StartedAt: "2026-03-01T10:00:00Z"},
+		RunRecord{RunID: "2026-03-01T10-00-00Z_aaaa", RequestPath: "requests/a.req.yaml", StartedAt: "2026-03-01T10:00:00Z"},
+		RunRecord{RunID: "2026-03-01T10-00-00Z_cccc", RequestPath: "requests/a.req.yaml", StartedAt: "2026-03-01T10:00:00Z"},
+	)
+
+	got, err := LatestRun("requests/a.req.yaml", nil)
+	if err != nil || got == nil || got.RunID != "2026-03-01T10-00-00Z_cccc" {
+		t.Fatalf("expected the greatest run ID to win the tie, got %+v (err=%v)", got, err)
+	}
+}
+
+func TestLatestRuns_MissingHistory(t *testing.T) {
+	t.Chdir(t.TempDir())
+	latest, err := LatestRuns(nil)
+	if err != nil || len(latest) != 0 {
+		t.Fatalf("expected an empty result without history, got %v (err=%v)", latest, err)
+	}
+}
@@ -7,25 +7,163 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
+	"unicode/utf8"
 )
 
 const runsDir = ".wirepad/history/runs"
 
+// DefaultMaxBodyBytes caps the response body stored in a run record unless
+// wirepad.yaml sets history.max_body_bytes.
+const DefaultMaxBodyBytes = 1 << 20
+
 type RunRecord struct {
-	RunID           string            `json:"run_id"`
-	RequestName     string            `json:"request_name"`
-	RequestPath     string            `json:"request_path"`
-	Env             string            `json:"env,omitempty"`
-	StartedAt       string            `json:"started_at"`
-	DurationMS      int64             `json:"duration_ms"`
-	OK              bool              `json:"ok"`
-	Status          int               `json:"status"`
-	ResponseHeaders map[string]string `json:"response_headers,omitempty"`
-	ResponseBody    string            `json:"response_body,omitempty"`
+	RunID                 string            `json:"run_id"`
+	RequestName           string            `json:"request_name"`
+	RequestPath           string            `json:"request_path"`
+	Env                   string            `json:"env,omitempty"`
+	StartedAt             string            `json:"started_at"`
+	DurationMS            int64             `json:"duration_ms"`
+	OK                    bool              `json:"ok"`
+	Status                int               `json:"status"`
+	Protocol              string            `json:"protocol,omitempty"`
+	ResponseHeaders       map[string]string `json:"response_headers,omitempty"`
+	ResponseBody          string            `json:"response_body,omitempty"`
+	ResponseBodyBytes     int64             `json:"response_body_bytes,omitempty"`
+	ResponseBodyTruncated bool              `json:"response_body_truncated,omitempty"`
+	ResponseEncoding      string            `json:"response_encoding,omitempty"`
+	ResponseEncodedBytes  int64             `json:"response_encoded_bytes,omitempty"`
+	ResponseBodyEncoded   bool              `json:"response_body_encoded,omitempty"`
+	OutputPath            string            `json:"output_path,omitempty"`
+	Transcript            string            `json:"transcript,omitempty"`
+	Events                int               `json:"events,omitempty"`
+	TLS                   *TLSInfo          `json:"tls,omitempty"`
+	Proxy                 string            `json:"proxy,omitempty"`
+	Timing                *Timing           `json:"timing,omitempty"`
+	Assertions            *Assertions       `json:"assertions,omitempty"`
+	Attempts              []Attempt         `json:"attempts,omitempty"`
+	Cookies               *CookieLog        `json:"cookies,omitempty"`
+	Error                 string            `json:"error,omitempty"`
+	GRPC                  *GRPCCall         `json:"grpc,omitempty"`
+	// DataRow is the dataset row a data-driven send used.
+	DataRow *int `json:"data_row,omitempty"`
+}
+
+// GRPCCall is the outcome of a kind=grpc run. RunRecord.Status holds the
+// gRPC status code rather than an HTTP status.
+type GRPCCall struct {
+	Service  string            `json:"service"`
+	Method   string            `json:"method"`
+	Code     int               `json:"code"`
+	CodeName string            `json:"code_name"`
+	Message  string            `json:"message,omitempty"`
+	Trailers map[string]string `json:"trailers,omitempty"`
+	Messages int               `json:"messages"`
+}
+
+// CookieLog lists the cookies a run sent and received. Values are redacted.
+type CookieLog struct {
+	Sent     []Cookie `json:"sent,omitempty"`
+	Received []Cookie `json:"received,omitempty"`
+}
+
+type Cookie struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Domain   string `json:"domain,omitempty"`
+	Path     string `json:"path,omitempty"`
+	Expires  string `json:"expires,omitempty"`
+	MaxAge   int    `json:"max_age,omitempty"`
+	Secure   bool   `json:"secure,omitempty"`
+	HTTPOnly bool   `json:"http_only,omitempty"`
+}
+
+// Attempt is one try of a request sent under a retry policy.
+type Attempt struct {
+	Attempt    int    `json:"attempt"`
+	StartedAt  string `json:"started_at"`
+	DurationMS int64  `json:"duration_ms"`
+	Status     int    `json:"status,omitempty"`
+	Error      string `json:"error,omitempty"`
+	DelayMS    int64  `json:"delay_ms,omitempty"`
+}
+
+// Timing is the per-phase latency breakdown of a run in milliseconds.
+type Timing struct {
+	DNSMS            float64 `json:"dns_ms"`
+	ConnectMS        float64 `json:"connect_ms"`
+	TLSMS            float64 `json:"tls_ms"`
+	TTFBMS           float64 `json:"ttfb_ms"`
+	TransferMS       float64 `json:"transfer_ms"`
+	TotalMS          float64 `json:"total_ms"`
+	ConnectionReused bool    `json:"connection_reused"`
+}
+
+// Assertions summarizes expectation results; only failures are kept in full.
+type Assertions struct {
+	Passed   int                `json:"passed"`
+	Failed   int                `json:"failed"`
+	Failures []AssertionFailure `json:"failures,omitempty"`
 }
 
+type AssertionFailure struct {
+	Path     string `json:"path"`
+	Operator string `json:"operator"`
+	Expected any    `json:"expected"`
+	Actual   any    `json:"actual"`
+	Message  string `json:"message,omitempty"`
+}
+
+// TLSInfo records the negotiated TLS session of an HTTPS run.
+type TLSInfo struct {
+	Version      string `json:"version"`
+	CipherSuite  string `json:"cipher_suite"`
+	ServerName   string `json:"server_name,omitempty"`
+	CertSubject  string `json:"cert_subject,omitempty"`
+	CertIssuer   string `json:"cert_issuer,omitempty"`
+	CertNotAfter string `json:"cert_not_after,omitempty"`
+}
+
+// TruncateBody returns body cut to at most limit bytes (zero means no limit)
+// without splitting a UTF-8 sequence, and whether anything was dropped.
+func TruncateBody(body []byte, limit int64) (string, bool) {
+	if limit <= 0 || int64(len(body)) <= limit {
+		return string(body), false
+	}
+	cut := body[:limit]
+	for i := 0; i < utf8.UTFMax-1 && len(cut) > 0; i++ {
+		if r, size := utf8.DecodeLastRune(cut); r != utf8.RuneError || size > 1 {
+			break
+		}
+		cut = cut[:len(cut)-1]
+	}
+	return string(cut), true
+}
+
+// issuedRunIDs keeps run IDs unique within a process, where concurrent runs
+// often start in the same second.
+var issuedRunIDs = struct {
+	sync.Mutex
+	ids map[string]bool
+}{ids: make(map[string]bool)}
+
 func NewRunID(now time.Time) string {
+	issuedRunIDs.Lock()
+	defer issuedRunIDs.Unlock()
+
+	var id string
+	for range 16 {
+		id = newRunID(now)
+		if !issuedRunIDs.ids[id] {
+			break
+		}
+	}
+	issuedRunIDs.ids[id] = true
+	return id
+}
+
+func newRunID(now time.Time) string {
 	suffix := make([]byte, 2)
 	if _, err := rand.Read(suffix); err != nil {
 		return now.UTC().Format("2006-01-02T15-04-05Z")
@@ -0,0 +1,55 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const transcriptsDir = ".wirepad/transcripts"
+
+// SSEEntry is one Server-Sent Event line in an NDJSON transcript.
+type SSEEntry struct {
+	TS    string `json:"ts"`
+	ID    string `json:"id,omitempty"`
+	Event string `json:"event,omitempty"`
+	Data  string `json:"data"`
+	Retry int    `json:"retry,omitempty"`
+}
+
+// Transcript appends entries to .wirepad/transcripts/<name>.ndjson as they
+// arrive so an interrupted session still leaves a usable file.
+type Transcript struct {
+	path string
+	file *os.File
+	enc  *json.Encoder
+}
+
+func CreateTranscript(name string) (*Transcript, error) {
+	if err := os.MkdirAll(transcriptsDir, 0o755); err != nil {
+		return nil, fmt.Errorf("create transcripts directory: %w", err)
+	}
+
+	path := filepath.Join(transcriptsDir, name+".ndjson")
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("create transcript: %w", err)
+	}
+	return &Transcript{path: path, file: file, enc: json.NewEncoder(file)}, nil
+}
+
+func (t *Transcript) Path() string {
+	return t.path
+}
+
+func (t *Transcript) Append(entry any) error {
+	if err := t.enc.Encode(entry); err != nil {
+		return fmt.Errorf("write transcript entry: %w", err)
+	}
+	return nil
+}
+
+func (t *Transcript) Close() error {
+	return t.file.Close()
+}
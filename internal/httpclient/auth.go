@@ -0,0 +1,401 @@
+package httpclient
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jaykbpark/wirepad/internal/requestspec"
+)
+
+// now is the signing clock; tests pin it to reproduce SigV4 test vectors.
+var now = time.Now
+
+// authTransport applies request.auth to every request sent to the original
+// host. Credentials are not forwarded when a redirect leaves that host.
+type authTransport struct {
+	base  http.RoundTripper
+	auth  *requestspec.Auth
+	host  string
+	oauth *OAuthSource
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !strings.EqualFold(req.URL.Host, t.host) {
+		return t.base.RoundTrip(req)
+	}
+
+	switch t.auth.Type {
+	case "digest":
+		return t.digestRoundTrip(req)
+	case "oauth2":
+		return t.oauthRoundTrip(req)
+	case "aws_sigv4":
+		signed, err := signSigV4(req, t.auth, now().UTC())
+		if err != nil {
+			return nil, err
+		}
+		return t.base.RoundTrip(signed)
+	default:
+		return t.base.RoundTrip(applyStaticAuth(req, t.auth))
+	}
+}
+
+func applyStaticAuth(req *http.Request, auth *requestspec.Auth) *http.Request {
+	out := req.Clone(req.Context())
+	switch auth.Type {
+	case "basic":
+		out.SetBasicAuth(auth.Username, auth.Password)
+	case "bearer":
+		out.Header.Set("Authorization", "Bearer "+auth.Token)
+	case "api_key":
+		if auth.In == "query" {
+			setQueryParam(out.URL, auth.Name, auth.Value)
+		} else {
+			out.Header.Set(auth.Name, auth.Value)
+		}
+	}
+	return out
+}
+
+// digestRoundTrip sends the request, answers a Digest challenge and resends
+// it with a rewound body.
+func (t *authTransport) digestRoundTrip(req *http.Request) (*http.Response, error) {
+	first := req.Clone(req.Context())
+	resp, err := t.base.RoundTrip(first)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	challenge, ok := parseDigestChallenge(resp.Header.Values("WWW-Authenticate"))
+	if !ok || (req.Body != nil && req.GetBody == nil) {
+		return resp, nil
+	}
+
+	retry := req.Clone(req.Context())
+	var body []byte
+	if req.GetBody != nil {
+		reader, err := req.GetBody()
+		if err != nil {
+			return resp, nil
+		}
+		body, err = io.ReadAll(reader)
+		if err != nil {
+			return resp, nil
+		}
+		retry.Body, _ = req.GetBody()
+	}
+
+	authorization, err := digestAuthorization(challenge, t.auth, req.Method, req.URL.RequestURI(), body)
+	if err != nil {
+		return resp, nil
+	}
+	discardResponse(resp)
+
+	retry.Header.Set("Authorization", authorization)
+	return t.base.RoundTrip(retry)
+}
+
+// oauthRoundTrip sends the request with a cached or fresh access token. A 401
+// forces one token refresh and a resend.
+func (t *authTransport) oauthRoundTrip(req *http.Request) (*http.Response, error) {
+	sent, err := t.oauth.accessToken("")
+	if err != nil {
+		return nil, err
+	}
+
+	first := req.Clone(req.Context())
+	first.Header.Set("Authorization", "Bearer "+sent)
+	resp, err := t.base.RoundTrip(first)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	if req.Body != nil && req.GetBody == nil {
+		return resp, nil
+	}
+
+	token, err := t.oauth.accessToken(sent)
+	if err != nil {
+		return resp, nil
+	}
+	discardResponse(resp)
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, fmt.Errorf("rewind request body: %w", err)
+		}
+	}
+	retry.Header.Set("Authorization", "Bearer "+token)
+	return t.base.RoundTrip(retry)
+}
+
+func parseDigestChallenge(headers []string) (map[string]string, bool) {
+	for _, header := range headers {
+		scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+		if !strings.EqualFold(scheme, "Digest") {
+			continue
+		}
+		params := parseAuthParams(rest)
+		if params["nonce"] == "" {
+			continue
+		}
+		return params, true
+	}
+	return nil, false
+}
+
+// parseAuthParams splits comma separated key=value pairs where values may be
+// quoted strings containing commas.
+func parseAuthParams(input string) map[string]string {
+	params := make(map[string]string)
+	for input != "" {
+		input = strings.TrimLeft(input, " ,")
+		key, rest, ok := strings.Cut(input, "=")
+		if !ok {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		rest = strings.TrimSpace(rest)
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			var b strings.Builder
+			i := 1
+			for ; i < len(rest) && rest[i] != '"'; i++ {
+				if rest[i] == '\\' && i+1 < len(rest) {
+					i++
+				}
+				b.WriteByte(rest[i])
+			}
+			value = b.String()
+			input = rest[min(i+1, len(rest)):]
+		} else {
+			value, input, _ = strings.Cut(rest, ",")
+			value = strings.TrimSpace(value)
+		}
+		params[key] = value
+	}
+	return params
+}
+
+func digestAuthorization(challenge map[string]string, auth *requestspec.Auth, method, uri string, body []byte) (string, error) {
+	algorithm := challenge["algorithm"]
+	if algorithm == "" {
+		algorithm = "MD5"
+	}
+	var newHash func() hash.Hash
+	switch strings.TrimSuffix(strings.ToUpper(algorithm), "-SESS") {
+	case "MD5":
+		newHash = md5.New
+	case "SHA-256":
+		newHash = sha256.New
+	default:
+		return "", fmt.Errorf("unsupported digest algorithm %q", algorithm)
+	}
+	digest := func(parts ...string) string {
+		h := newHash()
+		io.WriteString(h, strings.Join(parts, ":"))
+		return hex.EncodeToString(h.Sum(nil))
+	}
+
+	qop := ""
+	for _, option := range strings.Split(challenge["qop"], ",") {
+		option = strings.TrimSpace(option)
+		if option == "auth" || (option == "auth-int" && qop == "") {
+			qop = option
+		}
+	}
+
+	realm, nonce := challenge["realm"], challenge["nonce"]
+	cnonceBytes := make([]byte, 8)
+	if _, err := rand.Read(cnonceBytes); err != nil {
+		return "", err
+	}
+	cnonce := hex.EncodeToString(cnonceBytes)
+	const nc = "00000001"
+
+	ha1 := digest(auth.Username, realm, auth.Password)
+	if strings.HasSuffix(strings.ToUpper(algorithm), "-SESS") {
+		ha1 = digest(ha1, nonce, cnonce)
+	}
+	ha2 := digest(method, uri)
+	if qop == "auth-int" {
+		h := newHash()
+		h.Write(body)
+		ha2 = digest(method, uri, hex.EncodeToString(h.Sum(nil)))
+	}
+
+	var response string
+	if qop == "" {
+		response = digest(ha1, nonce, ha2)
+	} else {
+		response = digest(ha1, nonce, nc, cnonce, qop, ha2)
+	}
+
+	fields := []string{
+		fmt.Sprintf("username=%q", auth.Username),
+		fmt.Sprintf("realm=%q", realm),
+		fmt.Sprintf("nonce=%q", nonce),
+		fmt.Sprintf("uri=%q", uri),
+		"algorithm=" + algorithm,
+		fmt.Sprintf("response=%q", response),
+	}
+	if qop != "" {
+		fields = append(fields, "qop="+qop, "nc="+nc, fmt.Sprintf("cnonce=%q", cnonce))
+	}
+	if opaque, ok := challenge["opaque"]; ok {
+		fields = append(fields, fmt.Sprintf("opaque=%q", opaque))
+	}
+	return "Digest " + strings.Join(fields, ", "), nil
+}
+
+const sigV4Algorithm = "AWS4-HMAC-SHA256"
+
+// signSigV4 returns a copy of req signed with AWS Signature Version 4.
+func signSigV4(req *http.Request, auth *requestspec.Auth, at time.Time) (*http.Request, error) {
+	out := req.Clone(req.Context())
+
+	var body []byte
+	if req.GetBody != nil {
+		reader, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("read body for aws_sigv4: %w", err)
+		}
+		body, err = io.ReadAll(reader)
+		if err != nil {
+			return nil, fmt.Errorf("read body for aws_sigv4: %w", err)
+		}
+		out.Body, _ = req.GetBody()
+	}
+	payloadHash := sha256Hex(body)
+
+	amzDate := at.Format("20060102T150405Z")
+	date := at.Format("20060102")
+	out.Header.Set("X-Amz-Date", amzDate)
+	if auth.SessionToken != "" {
+		out.Header.Set("X-Amz-Security-Token", auth.SessionToken)
+	}
+	if auth.Service == "s3" {
+		out.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+
+	host := out.Host
+	if host == "" {
+		host = out.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for name, values := range out.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-") || lower == "content-type" {
+			headers[lower] = strings.Join(strings.Fields(strings.Join(values, ",")), " ")
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		out.Method,
+		sigV4Path(out.URL, auth.Service),
+		sigV4Query(out.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{date, auth.Region, auth.Service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{sigV4Algorithm, amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+auth.SecretAccessKey), date)
+	key = hmacSHA256(key, auth.Region)
+	key = hmacSHA256(key, auth.Service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	out.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, auth.AccessKeyID, scope, signedHeaders, signature))
+	return out, nil
+}
+
+// sigV4Path URI-encodes each path segment; every service except S3 expects
+// the already-escaped path to be encoded a second time.
+func sigV4Path(u *url.URL, service string) string {
+	path := u.EscapedPath()
+	if path == "" {
+		return "/"
+	}
+	if service == "s3" {
+		return path
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = sigV4Escape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+func sigV4Query(values url.Values) string {
+	type pair struct{ key, value string }
+	var pairs []pair
+	for key, vals := range values {
+		for _, value := range vals {
+			pairs = append(pairs, pair{sigV4Escape(key), sigV4Escape(value)})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].key != pairs[j].key {
+			return pairs[i].key < pairs[j].key
+		}
+		return pairs[i].value < pairs[j].value
+	})
+
+	encoded := make([]string, len(pairs))
+	for i, p := range pairs {
+		encoded[i] = p.key + "=" + p.value
+	}
+	return strings.Join(encoded, "&")
+}
+
+// sigV4Escape percent-encodes everything except RFC 3986 unreserved characters.
+func sigV4Escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,174 @@
+package httpclient
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaykbpark/wirepad/internal/requestspec"
+)
+
+func TestExecuteHTTP_StaticAuth(t *testing.T) {
+	cases := []struct {
+		name  string
+		auth  requestspec.Auth
+		check func(r *http.Request) string
+	}{
+		{
+			name: "basic",
+			auth: requestspec.Auth{Type: "basic", Username: "alice", Password: "s3cret"},
+			check: func(r *http.Request) string {
+				user, pass, ok := r.BasicAuth()
+				return fmt.Sprintf("%t %s %s", ok, user, pass)
+			},
+		},
+		{
+			name:  "bearer",
+			auth:  requestspec.Auth{Type: "bearer", Token: "tok"},
+			check: func(r *http.Request) string { return r.Header.Get("Authorization") },
+		},
+		{
+			name:  "api_key header",
+			auth:  requestspec.Auth{Type: "api_key", Name: "X-Api-Key", Value: "k1"},
+			check: func(r *http.Request) string { return r.Header.Get("X-Api-Key") },
+		},
+		{
+			name:  "api_key query",
+			auth:  requestspec.Auth{Type: "api_key", Name: "api_key", Value: "k2", In: "query"},
+			check: func(r *http.Request) string { return r.URL.RawQuery },
+		},
+	}
+	want := []string{"true alice s3cret", "Bearer tok", "k1", "page=1&api_key=k2"}
+
+	for i, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = tc.check(r)
+			}))
+			defer server.Close()
+
+			spec := httpSpec(server.URL)
+			spec.Request.Query = map[string]any{"page": 1}
+			spec.Request.Auth = &tc.auth
+			if _, err := ExecuteHTTP(spec, "x.req.yaml"); err != nil {
+				t.Fatalf("ExecuteHTTP returned error: %v", err)
+			}
+			if got != want[i] {
+				t.Fatalf("expected %q, got %q", want[i], got)
+			}
+		})
+	}
+}
+
+func TestExecuteHTTP_DigestAuth(t *testing.T) {
+	const realm, nonce, password = "wirepad", "dcd98b7102dd2f0e8b11d0f600bfb0c093", "Circle Of Life"
+	md5Hex := func(s string) string {
+		sum := md5.Sum([]byte(s))
+		return hex.EncodeToString(sum[:])
+	}
+
+	var attempts int
+	var gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		header := r.Header.Get("Authorization")
+		if !strings.HasPrefix(header, "Digest ") {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Digest realm=%q, qop="auth,auth-int", nonce=%q, opaque="5ccc069c403ebaf9f0171e9517f40e41"`, realm, nonce))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		params := parseAuthParams(strings.TrimPrefix(header, "Digest "))
+		ha1 := md5Hex(params["username"] + ":" + realm + ":" + password)
+		ha2 := md5Hex(r.Method + ":" + params["uri"])
+		expected := md5Hex(strings.Join([]string{ha1, nonce, params["nc"], params["cnonce"], params["qop"], ha2}, ":"))
+		if params["response"] != expected || params["uri"] != r.URL.RequestURI() || params["opaque"] == "" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		payload, _ := io.ReadAll(r.Body)
+		gotBody = string(payload)
+		_, _ = io.WriteString(w, "welcome")
+	}))
+	defer server.Close()
+
+	spec := httpSpec(server.URL + "/dir/index.html?x=1")
+	spec.Request.Method = "POST"
+	spec.Request.Body = &requestspec.Body{Mode: "raw", Raw: "hello"}
+	spec.Request.Auth = &requestspec.Auth{Type: "digest", Username: "Mufasa", Password: password}
+
+	resp, err := ExecuteHTTP(spec, "x.req.yaml")
+	if err != nil {
+		t.Fatalf("ExecuteHTTP returned error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || string(resp.Body) != "welcome" {
+		t.Fatalf("expected digest challenge to be answered, got %d %q", resp.StatusCode, resp.Body)
+	}
+	if attempts != 2 || gotBody != "hello" {
+		t.Fatalf("expected 2 round trips with the body resent, got %d and body %q", attempts, gotBody)
+	}
+}
+
+func TestSignSigV4_GetVanilla(t *testing.T) {
+	req, err := http.NewRequest("GET", "https://example.amazonaws.com/", nil)
+	if err != nil {
+		t.Fatalf("build request: %v", err)
+	}
+	auth := &requestspec.Auth{
+		Type:            "aws_sigv4",
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		Region:          "us-east-1",
+		Service:         "service",
+	}
+
+	signed, err := signSigV4(req, auth, time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("signSigV4 returned error: %v", err)
+	}
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := signed.Header.Get("Authorization"); got != want {
+		t.Fatalf("unexpected Authorization\n got: %s\nwant: %s", got, want)
+	}
+	if req.Header.Get("Authorization") != "" {
+		t.Fatal("expected the original request to be left unsigned")
+	}
+}
+
+func TestExecuteHTTP_SigV4SignsBody(t *testing.T) {
+	previous := now
+	now = func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+	t.Cleanup(func() { now = previous })
+
+	auth := &requestspec.Auth{Type: "aws_sigv4", AccessKeyID: "AKID", SecretAccessKey: "secret", Region: "eu-west-1", Service: "execute-api"}
+
+	var verified bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, _ := io.ReadAll(r.Body)
+		check, _ := http.NewRequest(r.Method, "http://"+r.Host+r.URL.RequestURI(), strings.NewReader(string(payload)))
+		check.Header.Set("Content-Type", r.Header.Get("Content-Type"))
+		signed, err := signSigV4(check, auth, now().UTC())
+		verified = err == nil && string(payload) == `{"a":1}` && signed.Header.Get("Authorization") == r.Header.Get("Authorization")
+	}))
+	defer server.Close()
+
+	spec := httpSpec(server.URL + "/items?b=2&a=1")
+	spec.Request.Method = "PUT"
+	spec.Request.Body = &requestspec.Body{Mode: "json", JSON: map[string]any{"a": 1}}
+	spec.Request.Auth = auth
+
+	if _, err := ExecuteHTTP(spec, "x.req.yaml"); err != nil {
+		t.Fatalf("ExecuteHTTP returned error: %v", err)
+	}
+	if !verified {
+		t.Fatal("expected server to verify the SigV4 signature")
+	}
+}
@@ -0,0 +1,195 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jaykbpark/wirepad/internal/requestspec"
+)
+
+// TLSInfo describes the negotiated TLS session of a response.
+type TLSInfo struct {
+	Version       string
+	CipherSuite   string
+	ServerName    string
+	CertSubject   string
+	CertIssuer    string
+	CertNotAfter  time.Time
+	PeerCertCount int
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// NewTransport builds the transport for a request's proxy, TLS and
+// http_version settings. Relative TLS file paths resolve against baseDir.
+func NewTransport(req *requestspec.Request, baseDir string) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Bodies are decoded by decodingReader so compressed sizes can be reported.
+	transport.DisableCompression = true
+
+	proxy, err := ProxyFunc(req.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	transport.Proxy = proxy
+
+	tlsConfig, err := buildTLSConfig(req.TLS, baseDir)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	if req.HTTPVersion != "" {
+		var protocols http.Protocols
+		switch req.HTTPVersion {
+		case "1.1":
+			protocols.SetHTTP1(true)
+		case "2":
+			protocols.SetHTTP2(true)
+		case "h2c":
+			protocols.SetUnencryptedHTTP2(true)
+		default:
+			return nil, fmt.Errorf("unsupported request.http_version %q", req.HTTPVersion)
+		}
+		transport.Protocols = &protocols
+	}
+
+	return transport, nil
+}
+
+// checkHTTPVersion rejects protocol choices the URL scheme cannot carry:
+// HTTP/2 is negotiated over TLS, h2c is HTTP/2 without it.
+func checkHTTPVersion(version string, u *url.URL) error {
+	switch {
+	case version == "2" && u.Scheme != "https":
+		return fmt.Errorf("request.http_version 2 needs an https URL; use h2c for cleartext HTTP/2")
+	case version == "h2c" && u.Scheme != "http":
+		return fmt.Errorf("request.http_version h2c needs an http URL; use 2 for HTTP/2 over TLS")
+	}
+	return nil
+}
+
+// ProxyFunc returns the proxy selector for a request.proxy setting. An empty
+// setting uses HTTP_PROXY, HTTPS_PROXY and NO_PROXY; "none" or "direct"
+// disables proxies. Explicit http, https, socks5 and socks5h URLs may carry
+// credentials as user:password.
+func ProxyFunc(setting string) (func(*http.Request) (*url.URL, error), error) {
+	setting = strings.TrimSpace(setting)
+	switch strings.ToLower(setting) {
+	case "":
+		return http.ProxyFromEnvironment, nil
+	case "none", "direct", "off":
+		return nil, nil
+	}
+
+	proxyURL, err := url.Parse(setting)
+	if err != nil {
+		return nil, fmt.Errorf("parse request.proxy: %w", err)
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported request.proxy scheme %q (expected http, https, socks5 or socks5h)", proxyURL.Scheme)
+	}
+	if proxyURL.Host == "" {
+		return nil, fmt.Errorf("request.proxy %q is missing a host", proxyURL.Redacted())
+	}
+	return http.ProxyURL(proxyURL), nil
+}
+
+// RedactProxy hides the password of a proxy URL for display.
+func RedactProxy(setting string) string {
+	proxyURL, err := url.Parse(setting)
+	if err != nil || proxyURL.User == nil {
+		return setting
+	}
+	return proxyURL.Redacted()
+}
+
+func buildTLSConfig(cfg *requestspec.TLS, baseDir string) (*tls.Config, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	out := &tls.Config{
+		ServerName: cfg.ServerName,
+	}
+
+	if cfg.InsecureSkipVerify != nil && *cfg.InsecureSkipVerify {
+		out.InsecureSkipVerify = true
+	}
+
+	if cfg.MinVersion != "" {
+		version, ok := tlsVersions[cfg.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("unsupported request.tls.min_version %q", cfg.MinVersion)
+		}
+		out.MinVersion = version
+	}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(resolveFile(baseDir, cfg.CAFile))
+		if err != nil {
+			return nil, fmt.Errorf("read request.tls.ca_file %q: %w", cfg.CAFile, err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("request.tls.ca_file %q contains no PEM certificates", cfg.CAFile)
+		}
+		out.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(resolveFile(baseDir, cfg.CertFile), resolveFile(baseDir, cfg.KeyFile))
+		if err != nil {
+			return nil, fmt.Errorf("load request.tls client certificate: %w", err)
+		}
+		out.Certificates = []tls.Certificate{cert}
+	}
+
+	return out, nil
+}
+
+func tlsInfo(state *tls.ConnectionState) *TLSInfo {
+	if state == nil {
+		return nil
+	}
+
+	info := &TLSInfo{
+		Version:       tls.VersionName(state.Version),
+		CipherSuite:   tls.CipherSuiteName(state.CipherSuite),
+		ServerName:    state.ServerName,
+		PeerCertCount: len(state.PeerCertificates),
+	}
+	if len(state.PeerCertificates) > 0 {
+		leaf := state.PeerCertificates[0]
+		info.CertSubject = leaf.Subject.String()
+		info.CertIssuer = leaf.Issuer.String()
+		info.CertNotAfter = leaf.NotAfter.UTC()
+	}
+	return info
+}
+
+func resolveFile(baseDir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(baseDir, path)
+}
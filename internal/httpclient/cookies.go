@@ -0,0 +1,50 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// recordingJar wraps a jar and remembers the cookies sent and received
+// during one run, including across redirects and retries.
+type recordingJar struct {
+	jar http.CookieJar
+
+	mu       sync.Mutex
+	sent     []*http.Cookie
+	received []*http.Cookie
+}
+
+func (r *recordingJar) Cookies(u *url.URL) []*http.Cookie {
+	cookies := r.jar.Cookies(u)
+	r.mu.Lock()
+	r.sent = mergeCookies(r.sent, cookies)
+	r.mu.Unlock()
+	return cookies
+}
+
+func (r *recordingJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	r.mu.Lock()
+	r.received = mergeCookies(r.received, cookies)
+	r.mu.Unlock()
+	r.jar.SetCookies(u, cookies)
+}
+
+// mergeCookies appends cookies, replacing earlier ones with the same name.
+func mergeCookies(existing, cookies []*http.Cookie) []*http.Cookie {
+	for _, cookie := range cookies {
+		replaced := false
+		for i, prior := range existing {
+			if prior.Name == cookie.Name {
+				existing[i] = cookie
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			existing = append(existing, cookie)
+		}
+	}
+	return existing
+}
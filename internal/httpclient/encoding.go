@@ -0,0 +1,171 @@
+package httpclient
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// acceptEncoding is sent when the request does not set Accept-Encoding. The
+// transport's own gzip handling is disabled so both body sizes are known.
+const acceptEncoding = "gzip, deflate, br, zstd"
+
+// decoders opens a reader for each supported content coding.
+var decoders = map[string]func(io.Reader) (io.Reader, error){
+	"gzip":    openGzip,
+	"deflate": openDeflate,
+	"br":      openBrotli,
+	"zstd":    openZstd,
+}
+
+// contentEncodings lists the codings applied to a body, in the order they
+// were applied, skipping identity.
+func contentEncodings(header string) []string {
+	var out []string
+	for _, part := range strings.Split(header, ",") {
+		coding := strings.ToLower(strings.TrimSpace(part))
+		switch coding {
+		case "", "identity":
+			continue
+		case "x-gzip":
+			coding = "gzip"
+		}
+		out = append(out, coding)
+	}
+	return out
+}
+
+// decodingReader undoes the codings of a response body. It returns ok=false,
+// leaving r untouched, when any coding is not supported.
+func decodingReader(r io.Reader, encodings []string) (io.Reader, bool) {
+	for _, coding := range encodings {
+		if _, ok := decoders[coding]; !ok {
+			return r, false
+		}
+	}
+	for i := len(encodings) - 1; i >= 0; i-- {
+		r = &lazyDecoder{src: r, open: decoders[encodings[i]]}
+	}
+	return r, true
+}
+
+// lazyDecoder opens its decoder on first read so empty bodies, as on HEAD or
+// 204 responses, are not an error.
+type lazyDecoder struct {
+	src  io.Reader
+	open func(io.Reader) (io.Reader, error)
+	r    io.Reader
+}
+
+func (l *lazyDecoder) Read(p []byte) (int, error) {
+	if l.r == nil {
+		r, err := l.open(l.src)
+		if err != nil {
+			return 0, err
+		}
+		l.r = r
+	}
+	return l.r.Read(p)
+}
+
+func openGzip(r io.Reader) (io.Reader, error) {
+	zr, err := gzip.NewReader(r)
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decode gzip body: %w", err)
+	}
+	return zr, nil
+}
+
+// openDeflate accepts zlib-wrapped data, which RFC 9110 specifies, and the
+// raw deflate some servers send instead.
+func openDeflate(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(2)
+	if len(header) == 0 {
+		return nil, err
+	}
+	if len(header) == 2 && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		zr, err := zlib.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("decode deflate body: %w", err)
+		}
+		return zr, nil
+	}
+	return flate.NewReader(br), nil
+}
+
+// openBrotli reads br bodies. Brotli streams have no magic number, so an
+// empty body is checked for explicitly.
+func openBrotli(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	if _, err := br.Peek(1); err != nil {
+		return nil, err
+	}
+	return &decodeErrorReader{r: brotli.NewReader(br), coding: "br"}, nil
+}
+
+func openZstd(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	if _, err := br.Peek(1); err != nil {
+		return nil, err
+	}
+	// A single-threaded decoder keeps no goroutines behind, so the reader
+	// does not need closing when a caller stops early.
+	zr, err := zstd.NewReader(br, zstd.WithDecoderConcurrency(1), zstd.WithDecoderLowmem(true))
+	if err != nil {
+		return nil, fmt.Errorf("decode zstd body: %w", err)
+	}
+	return &decodeErrorReader{r: zr, coding: "zstd"}, nil
+}
+
+// decodeErrorReader names the coding in decode errors, as gzip and deflate
+// errors are named when their readers open.
+type decodeErrorReader struct {
+	r      io.Reader
+	coding string
+}
+
+func (d *decodeErrorReader) Read(p []byte) (int, error) {
+	n, err := d.r.Read(p)
+	if err != nil && err != io.EOF {
+		err = fmt.Errorf("decode %s body: %w", d.coding, err)
+	}
+	return n, err
+}
+
+// compressBody gzips a request body for request.body.compress.
+func compressBody(body io.Reader) (*bytes.Buffer, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if body != nil {
+		if _, err := io.Copy(zw, body); err != nil {
+			return nil, fmt.Errorf("compress request body: %w", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("compress request body: %w", err)
+	}
+	return &buf, nil
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
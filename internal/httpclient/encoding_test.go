@@ -0,0 +1,150 @@
+package httpclient
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/jaykbpark/wirepad/internal/requestspec"
+)
+
+func TestExecuteHTTP_DecodesContentEncoding(t *testing.T) {
+	const payload = `{"message":"hello hello hello hello hello"}`
+	encoders := map[string]func(io.Writer) io.WriteCloser{
+		"gzip":    func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) },
+		"deflate": func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) },
+		"raw-deflate": func(w io.Writer) io.WriteCloser {
+			fw, _ := flate.NewWriter(w, flate.DefaultCompression)
+			return fw
+		},
+		"br": func(w io.Writer) io.WriteCloser { return brotli.NewWriter(w) },
+		"zstd": func(w io.Writer) io.WriteCloser {
+			zw, _ := zstd.NewWriter(w)
+			return zw
+		},
+		"gzip, br": func(w io.Writer) io.WriteCloser {
+			outer := brotli.NewWriter(w)
+			return &stackedWriter{WriteCloser: gzip.NewWriter(outer), outer: outer}
+		},
+	}
+
+	var acceptEncoding string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		acceptEncoding = r.Header.Get("Accept-Encoding")
+		coding := r.URL.Query().Get("coding")
+		if coding == "compress" {
+			w.Header().Set("Content-Encoding", "compress")
+			_, _ = w.Write([]byte{0x1f, 0x9d, 0x90})
+			return
+		}
+		var buf bytes.Buffer
+		zw := encoders[coding](&buf)
+		_, _ = io.WriteString(zw, payload)
+		_ = zw.Close()
+		if coding == "raw-deflate" {
+			coding = "deflate"
+		}
+		w.Header().Set("Content-Encoding", coding)
+		_, _ = w.Write(buf.Bytes())
+	}))
+	defer server.Close()
+
+	for _, coding := range []string{"gzip", "deflate", "raw-deflate", "br", "zstd", "gzip, br"} {
+		resp, err := ExecuteHTTP(httpSpec(server.URL+"/?coding="+url.QueryEscape(coding)), "x.req.yaml")
+		if err != nil {
+			t.Fatalf("%s: ExecuteHTTP returned error: %v", coding, err)
+		}
+		if string(resp.Body) != payload || !resp.Decoded {
+			t.Fatalf("%s: expected decoded body, got %q (decoded=%v)", coding, resp.Body, resp.Decoded)
+		}
+		if resp.BodySize != int64(len(payload)) || resp.EncodedSize == 0 || resp.EncodedSize == resp.BodySize {
+			t.Fatalf("%s: unexpected sizes decoded=%d encoded=%d", coding, resp.BodySize, resp.EncodedSize)
+		}
+	}
+	if acceptEncoding != "gzip, deflate, br, zstd" {
+		t.Fatalf("expected default Accept-Encoding, got %q", acceptEncoding)
+	}
+
+	spec := httpSpec(server.URL + "/?coding=compress")
+	spec.Request.Headers = map[string]any{"Accept-Encoding": "compress"}
+	resp, err := ExecuteHTTP(spec, "x.req.yaml")
+	if err != nil {
+		t.Fatalf("compress: ExecuteHTTP returned error: %v", err)
+	}
+	if acceptEncoding != "compress" {
+		t.Fatalf("expected user Accept-Encoding to be kept, got %q", acceptEncoding)
+	}
+	if resp.Decoded || resp.ContentEncoding != "compress" || len(resp.Body) != 3 {
+		t.Fatalf("expected compress body left encoded, got %+v", resp)
+	}
+}
+
+// stackedWriter closes an inner encoder, then the encoder it writes into.
+type stackedWriter struct {
+	io.WriteCloser
+	outer io.Closer
+}
+
+func (s *stackedWriter) Close() error {
+	if err := s.WriteCloser.Close(); err != nil {
+		return err
+	}
+	return s.outer.Close()
+}
+
+func TestDecodingReader_ZstdCLIFrame(t *testing.T) {
+	// printf 'abab...' (80 bytes) | zstd -c --no-check
+	frame := []byte("\x28\xb5\x2f\xfd\x00\x58\x45\x00\x00\x10\x61\x62\x01\x00\x1b\xea\xb0")
+	r, ok := decodingReader(bytes.NewReader(frame), []string{"zstd"})
+	if !ok {
+		t.Fatal("expected zstd to be supported")
+	}
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("decode zstd frame: %v", err)
+	}
+	if string(got) != strings.Repeat("ab", 40) {
+		t.Fatalf("unexpected zstd payload %q", got)
+	}
+
+	r, _ = decodingReader(bytes.NewReader([]byte("not brotli at all")), []string{"br"})
+	if _, err := io.ReadAll(r); err == nil || !strings.Contains(err.Error(), "decode br body") {
+		t.Fatalf("expected a named br decode error, got %v", err)
+	}
+}
+
+func TestExecuteHTTP_CompressesRequestBody(t *testing.T) {
+	var encoding, received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding = r.Header.Get("Content-Encoding")
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		payload, _ := io.ReadAll(zr)
+		received = string(payload)
+	}))
+	defer server.Close()
+
+	spec := httpSpec(server.URL)
+	spec.Request.Method = "POST"
+	spec.Request.Body = &requestspec.Body{Mode: "raw", Raw: "compress me", Compress: "gzip"}
+	resp, err := ExecuteHTTP(spec, "x.req.yaml")
+	if err != nil {
+		t.Fatalf("ExecuteHTTP returned error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || encoding != "gzip" || received != "compress me" {
+		t.Fatalf("expected gzip request body, got status=%d encoding=%q body=%q", resp.StatusCode, encoding, received)
+	}
+}
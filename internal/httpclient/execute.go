@@ -2,6 +2,7 @@ package httpclient
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -22,11 +23,70 @@ type Response struct {
 	Duration   time.Duration
 	Status     string
 	StatusCode int
+	Proto      string
 	Headers    http.Header
 	Body       []byte
+	// BodySize is the number of decoded body bytes; it exceeds len(Body)
+	// when BodyTruncated is set.
+	BodySize      int64
+	BodyTruncated bool
+	TLS           *TLSInfo
+	Timing        Timing
+	// Attempts lists every try, including the final one.
+	Attempts []Attempt
+	// CookiesSent and CookiesReceived are only set when Options.Jar is used.
+	CookiesSent     []*http.Cookie
+	CookiesReceived []*http.Cookie
+	// ContentEncoding is the response Content-Encoding. Body is decoded
+	// unless Decoded is false, and EncodedSize counts bytes on the wire.
+	ContentEncoding string
+	Decoded         bool
+	EncodedSize     int64
+}
+
+// Options control how a response body is consumed.
+type Options struct {
+	// Context cancels the request. When streaming, cancellation ends the body
+	// without an error so the partial response can still be recorded.
+	Context context.Context
+	// Stream lifts the overall request timeout for long-lived responses;
+	// timeout_ms then only bounds the wait for response headers.
+	Stream bool
+	// Output receives the raw response body as it arrives.
+	Output io.Writer
+	// OnEvent, when set, receives text/event-stream bodies event by event
+	// instead of Output. Returning false stops reading.
+	OnEvent func(SSEEvent) bool
+	// Progress reports received body bytes; total is -1 when unknown.
+	Progress func(received, total int64)
+	// MaxBodyBytes caps Response.Body. Zero keeps the whole body.
+	MaxBodyBytes int64
+	// OAuthCachePath is the JSON file OAuth2 tokens are cached in between
+	// runs. Empty keeps tokens in memory only.
+	OAuthCachePath string
+	// OAuth, when set, supplies the OAuth2 token instead of a source built
+	// from the request, so repeated calls share one token. Build it with
+	// NewOAuthSource from the same request.
+	OAuth *OAuthSource
+	// OAuthSources, when set and OAuth is not, shares token sources between
+	// calls whose requests use the same oauth2 client, such as the requests
+	// of a test suite sent concurrently.
+	OAuthSources *OAuthSources
+	// Jar, when set, sends and stores cookies for the run.
+	Jar http.CookieJar
+	// Transport, when set, is used instead of one built from the request so
+	// repeated calls share its connection pool. Build it with NewTransport
+	// from the same request.
+	Transport *http.Transport
 }
 
 func ExecuteHTTP(spec *requestspec.Spec, requestPath string) (*Response, error) {
+	return ExecuteHTTPWithOptions(spec, requestPath, Options{})
+}
+
+// ExecuteHTTPWithOptions sends the request and consumes the body as opts
+// describes.
+func ExecuteHTTPWithOptions(spec *requestspec.Spec, requestPath string, opts Options) (*Response, error) {
 	if spec == nil || spec.Request == nil {
 		return nil, fmt.Errorf("missing request block")
 	}
@@ -43,28 +103,49 @@ func ExecuteHTTP(spec *requestspec.Spec, requestPath string) (*Response, error)
 	if err != nil {
 		return nil, fmt.Errorf("parse request url: %w", err)
 	}
-	addQuery(parsedURL, spec.Request.Query)
-
-	bodyReader, contentType, err := buildBody(spec, requestPath)
-	if err != nil {
+	addQuery(parsedURL, spec.Request)
+	if err := checkHTTPVersion(spec.Request.HTTPVersion, parsedURL); err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest(method, parsedURL.String(), bodyReader)
-	if err != nil {
-		return nil, fmt.Errorf("build http request: %w", err)
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
 	}
 
-	setHeaders(req, spec.Request.Headers)
-	if contentType != "" && !hasHeader(req.Header, "Content-Type") {
-		req.Header.Set("Content-Type", contentType)
+	transport := opts.Transport
+	if transport == nil {
+		transport, err = NewTransport(spec.Request, filepath.Dir(requestPath))
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	client := &http.Client{}
-	if spec.Request.TimeoutMS > 0 {
-		client.Timeout = time.Duration(spec.Request.TimeoutMS) * time.Millisecond
+	timeout := requestTimeout(spec.Request)
+	client := &http.Client{Transport: transport}
+	var jar *recordingJar
+	if opts.Jar != nil {
+		jar = &recordingJar{jar: opts.Jar}
+		client.Jar = jar
+	}
+	if auth := spec.Request.Auth; auth != nil {
+		authed := &authTransport{base: transport, auth: auth, host: parsedURL.Host}
+		if auth.Type == "oauth2" {
+			switch {
+			case opts.OAuth != nil:
+				authed.oauth = opts.OAuth
+			case opts.OAuthSources != nil:
+				authed.oauth = opts.OAuthSources.source(auth, transport, timeout, opts.OAuthCachePath)
+			default:
+				authed.oauth = newOAuthSource(auth, transport, timeout, opts.OAuthCachePath)
+			}
+		}
+		client.Transport = authed
+	}
+	if opts.Stream || opts.Output != nil {
+		transport.ResponseHeaderTimeout = timeout
 	} else {
-		client.Timeout = 30 * time.Second
+		client.Timeout = timeout
 	}
 	if spec.Request.FollowRedirects != nil && !*spec.Request.FollowRedirects {
 		client.CheckRedirect = func(_ *http.Request, _ []*http.Request) error {
@@ -72,39 +153,178 @@ func ExecuteHTTP(spec *requestspec.Spec, requestPath string) (*Response, error)
 		}
 	}
 
+	policy := newRetryPolicy(spec.Request.Retry)
 	start := time.Now().UTC()
-	resp, err := client.Do(req)
+	var attempts []Attempt
+	var resp *http.Response
+	var trace *timingTrace
+	for number := 1; ; number++ {
+		// Bodies are rebuilt for every attempt since readers cannot be rewound.
+		req, err := newRequest(ctx, spec, method, parsedURL, requestPath)
+		if err != nil {
+			return nil, err
+		}
+
+		attempt := Attempt{Number: number, StartedAt: time.Now().UTC()}
+		trace = newTimingTrace(attempt.StartedAt)
+		resp, err = client.Do(trace.withContext(req))
+		attempt.Duration = time.Since(attempt.StartedAt)
+		if err != nil {
+			attempt.Error = err.Error()
+		} else {
+			attempt.Status = resp.StatusCode
+		}
+
+		delay, retry := policy.next(number, resp, err)
+		if !retry || ctx.Err() != nil {
+			attempts = append(attempts, attempt)
+			if err != nil {
+				if number > 1 {
+					return nil, &RetryError{Attempts: attempts, Err: err}
+				}
+				return nil, fmt.Errorf("execute http request: %w", err)
+			}
+			break
+		}
+
+		attempt.Delay = delay
+		attempts = append(attempts, attempt)
+		if resp != nil {
+			discardResponse(resp)
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return nil, &RetryError{Attempts: attempts, Err: err}
+		}
+	}
 	duration := time.Since(start)
+	defer resp.Body.Close()
+
+	wire := &countingReader{r: resp.Body}
+	encodings := contentEncodings(resp.Header.Get("Content-Encoding"))
+	source, decoded := decodingReader(wire, encodings)
+	if opts.Progress != nil {
+		// Progress follows the wire bytes, which Content-Length describes.
+		wire.r = &progressReader{r: resp.Body, total: resp.ContentLength, report: opts.Progress}
+	}
+
+	body := &cappedBuffer{limit: opts.MaxBodyBytes}
+	if err := readBody(source, resp.Header.Get("Content-Type"), body, opts); err != nil {
+		if !opts.Stream || ctx.Err() == nil {
+			return nil, fmt.Errorf("read response body: %w", err)
+		}
+	}
+	timing := trace.finish(time.Now())
+
+	out := &Response{
+		StartedAt:     start,
+		Duration:      duration,
+		Status:        resp.Status,
+		StatusCode:    resp.StatusCode,
+		Proto:         resp.Proto,
+		Headers:       resp.Header.Clone(),
+		Body:          body.buf.Bytes(),
+		BodySize:      body.size,
+		BodyTruncated: body.truncated,
+		TLS:           tlsInfo(resp.TLS),
+		Timing:        timing,
+		Attempts:      attempts,
+	}
+	if len(encodings) > 0 {
+		out.ContentEncoding = strings.Join(encodings, ", ")
+		out.Decoded = decoded
+		out.EncodedSize = wire.n
+	}
+	if jar != nil {
+		out.CookiesSent = jar.sent
+		out.CookiesReceived = jar.received
+	}
+	return out, nil
+}
+
+func newRequest(ctx context.Context, spec *requestspec.Spec, method string, target *url.URL, requestPath string) (*http.Request, error) {
+	bodyReader, contentType, err := buildBody(spec, requestPath)
 	if err != nil {
-		return nil, fmt.Errorf("execute http request: %w", err)
+		return nil, err
+	}
+	compress := spec.Request.Body != nil && spec.Request.Body.Compress == "gzip"
+	if compress {
+		compressed, err := compressBody(bodyReader)
+		if err != nil {
+			return nil, err
+		}
+		bodyReader = compressed
 	}
-	defer resp.Body.Close()
 
-	respBody, err := io.ReadAll(resp.Body)
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), bodyReader)
 	if err != nil {
-		return nil, fmt.Errorf("read response body: %w", err)
+		return nil, fmt.Errorf("build http request: %w", err)
+	}
+
+	setHeaders(req, spec.Request.Headers)
+	if contentType != "" && !hasHeader(req.Header, "Content-Type") {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if compress {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	if spec.Request.Body != nil && spec.Request.Body.Mode == "graphql" && !hasHeader(req.Header, "Accept") {
+		req.Header.Set("Accept", graphqlAccept)
+	}
+	if !hasHeader(req.Header, "Accept-Encoding") && !hasHeader(req.Header, "Range") && method != http.MethodHead {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
 	}
+	return req, nil
+}
+
+func readBody(source io.Reader, contentType string, body *cappedBuffer, opts Options) error {
+	if opts.OnEvent != nil && IsEventStream(contentType) {
+		return readSSE(io.TeeReader(source, body), opts.OnEvent)
+	}
+
+	var dst io.Writer = body
+	if opts.Output != nil {
+		dst = io.MultiWriter(opts.Output, body)
+	}
+	_, err := io.Copy(dst, source)
+	return err
+}
 
-	return &Response{
-		StartedAt:  start,
-		Duration:   duration,
-		Status:     resp.Status,
-		StatusCode: resp.StatusCode,
-		Headers:    resp.Header.Clone(),
-		Body:       respBody,
-	}, nil
+// cappedBuffer keeps the first limit bytes written and counts the rest.
+type cappedBuffer struct {
+	buf       bytes.Buffer
+	limit     int64
+	size      int64
+	truncated bool
 }
 
-func addQuery(u *url.URL, query map[string]any) {
-	if len(query) == 0 {
-		return
+func (c *cappedBuffer) Write(p []byte) (int, error) {
+	c.size += int64(len(p))
+	keep := p
+	if c.limit > 0 {
+		room := c.limit - int64(c.buf.Len())
+		if room < int64(len(keep)) {
+			keep = keep[:max(room, 0)]
+			c.truncated = true
+		}
 	}
+	c.buf.Write(keep)
+	return len(p), nil
+}
+
+type progressReader struct {
+	r        io.Reader
+	total    int64
+	received int64
+	report   func(received, total int64)
+}
 
-	values := u.Query()
-	for key, value := range query {
-		values.Set(key, fmt.Sprint(value))
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.received += int64(n)
+		p.report(p.received, p.total)
 	}
-	u.RawQuery = values.Encode()
+	return n, err
 }
 
 func buildBody(spec *requestspec.Spec, requestPath string) (io.Reader, string, error) {
@@ -195,17 +415,17 @@ func buildBody(spec *requestspec.Spec, requestPath string) (io.Reader, string, e
 			return nil, "", fmt.Errorf("close multipart body: %w", err)
 		}
 		return buf, writer.FormDataContentType(), nil
+	case "graphql":
+		payload, err := graphqlPayload(body, baseDir)
+		if err != nil {
+			return nil, "", err
+		}
+		return bytes.NewReader(payload), "application/json", nil
 	default:
 		return nil, "", fmt.Errorf("unsupported request.body.mode %q", body.Mode)
 	}
 }
 
-func setHeaders(req *http.Request, headers map[string]any) {
-	for key, value := range headers {
-		req.Header.Set(key, fmt.Sprint(value))
-	}
-}
-
 func hasHeader(header http.Header, key string) bool {
 	for headerKey := range header {
 		if strings.EqualFold(headerKey, key) {
@@ -214,3 +434,11 @@ func hasHeader(header http.Header, key string) bool {
 	}
 	return false
 }
+
+// requestTimeout is request.timeout_ms, defaulting to 30s.
+func requestTimeout(req *requestspec.Request) time.Duration {
+	if req.TimeoutMS > 0 {
+		return time.Duration(req.TimeoutMS) * time.Millisecond
+	}
+	return 30 * time.Second
+}
@@ -0,0 +1,41 @@
+package httpclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jaykbpark/wirepad/internal/requestspec"
+)
+
+// graphqlAccept prefers the GraphQL-over-HTTP media type and falls back to
+// plain JSON for older servers.
+const graphqlAccept = "application/graphql-response+json, application/json"
+
+// graphqlPayload builds the standard {query, variables, operationName} POST
+// body. A query_path file is sent as-is, without {{var}} interpolation.
+func graphqlPayload(body *requestspec.Body, baseDir string) ([]byte, error) {
+	query := body.Query
+	if body.QueryPath != "" {
+		fullPath := body.QueryPath
+		if !filepath.IsAbs(fullPath) {
+			fullPath = filepath.Join(baseDir, body.QueryPath)
+		}
+		data, err := os.ReadFile(fullPath)
+		if err != nil {
+			return nil, fmt.Errorf("read request.body.query_path %q: %w", body.QueryPath, err)
+		}
+		query = string(data)
+	}
+
+	payload, err := json.Marshal(struct {
+		Query         string `json:"query"`
+		Variables     any    `json:"variables,omitempty"`
+		OperationName string `json:"operationName,omitempty"`
+	}{query, body.Variables, body.OperationName})
+	if err != nil {
+		return nil, fmt.Errorf("encode graphql request: %w", err)
+	}
+	return payload, nil
+}
@@ -0,0 +1,277 @@
+package httpclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jaykbpark/wirepad/internal/requestspec"
+)
+
+// expirySkew refreshes tokens slightly early so they do not expire in flight.
+const expirySkew = 30 * time.Second
+
+type oauthToken struct {
+	AccessToken  string    `json:"access_token"`
+	TokenType    string    `json:"token_type,omitempty"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at,omitempty"`
+}
+
+func (t *oauthToken) valid(at time.Time) bool {
+	if t == nil || t.AccessToken == "" {
+		return false
+	}
+	return t.ExpiresAt.IsZero() || at.Add(expirySkew).Before(t.ExpiresAt)
+}
+
+type oauthCacheFile struct {
+	Tokens map[string]*oauthToken `json:"tokens"`
+}
+
+// OAuthSource fetches, caches and refreshes the access token for one
+// request.auth block. The cache file is shared by every request in an env,
+// keyed by token endpoint, client, grant, user and scopes. It is safe for
+// concurrent use, so repeated calls can share one through Options.OAuth.
+type OAuthSource struct {
+	auth      *requestspec.Auth
+	client    *http.Client
+	cachePath string
+	key       string
+
+	mu    sync.Mutex
+	token *oauthToken
+}
+
+// NewOAuthSource returns the token source for a request with oauth2 auth, or
+// nil for any other request. Token requests go through transport.
+func NewOAuthSource(req *requestspec.Request, transport *http.Transport, cachePath string) *OAuthSource {
+	if req.Auth == nil || req.Auth.Type != "oauth2" {
+		return nil
+	}
+	return newOAuthSource(req.Auth, transport, requestTimeout(req), cachePath)
+}
+
+func newOAuthSource(auth *requestspec.Auth, transport http.RoundTripper, timeout time.Duration, cachePath string) *OAuthSource {
+	scopes := append([]string(nil), auth.Scopes...)
+	sort.Strings(scopes)
+	key := strings.Join([]string{auth.Grant, auth.TokenURL, auth.ClientID, auth.Username, strings.Join(scopes, " ")}, "|")
+
+	return &OAuthSource{
+		auth:      auth,
+		client:    &http.Client{Transport: transport, Timeout: timeout},
+		cachePath: cachePath,
+		key:       key,
+	}
+}
+
+// OAuthSources hands out one OAuthSource per cache file and cache key, so
+// requests from different specs that share an oauth2 client fetch one token.
+// It is safe for concurrent use.
+type OAuthSources struct {
+	mu      sync.Mutex
+	sources map[string]*OAuthSource
+}
+
+func NewOAuthSources() *OAuthSources {
+	return &OAuthSources{sources: make(map[string]*OAuthSource)}
+}
+
+// source returns the shared source for auth, building it with transport on
+// first use.
+func (p *OAuthSources) source(auth *requestspec.Auth, transport http.RoundTripper, timeout time.Duration, cachePath string) *OAuthSource {
+	built := newOAuthSource(auth, transport, timeout, cachePath)
+	id := cachePath + "\x00" + built.key
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if existing, ok := p.sources[id]; ok {
+		return existing
+	}
+	p.sources[id] = built
+	return built
+}
+
+// Token returns a usable access token, fetching one if none is cached.
+func (s *OAuthSource) Token() (string, error) {
+	return s.accessToken("")
+}
+
+// accessToken returns a usable token. rejected is a token the server answered
+// with 401; it is replaced unless another caller has already done so.
+func (s *OAuthSource) accessToken(rejected string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token == nil {
+		s.token = s.loadCached()
+	}
+	if s.token.valid(now()) && (rejected == "" || s.token.AccessToken != rejected) {
+		return s.token.AccessToken, nil
+	}
+
+	var token *oauthToken
+	var err error
+	refreshToken := s.auth.RefreshToken
+	if s.token != nil && s.token.RefreshToken != "" {
+		refreshToken = s.token.RefreshToken
+	}
+	if refreshToken != "" {
+		token, err = s.request(url.Values{"grant_type": {"refresh_token"}, "refresh_token": {refreshToken}})
+		// A rejected refresh token falls back to the configured grant.
+		if err != nil && s.auth.Grant != "refresh_token" {
+			token, err = s.grant()
+		}
+	} else {
+		token, err = s.grant()
+	}
+	if err != nil {
+		return "", err
+	}
+	if token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
+	}
+
+	s.token = token
+	if err := s.saveCached(token); err != nil {
+		return "", err
+	}
+	return token.AccessToken, nil
+}
+
+func (s *OAuthSource) grant() (*oauthToken, error) {
+	form := url.Values{"grant_type": {s.auth.Grant}}
+	switch s.auth.Grant {
+	case "password":
+		form.Set("username", s.auth.Username)
+		form.Set("password", s.auth.Password)
+	case "refresh_token":
+		form.Set("refresh_token", s.auth.RefreshToken)
+	}
+	return s.request(form)
+}
+
+func (s *OAuthSource) request(form url.Values) (*oauthToken, error) {
+	if len(s.auth.Scopes) > 0 {
+		form.Set("scope", strings.Join(s.auth.Scopes, " "))
+	}
+	if s.auth.ClientAuth == "body" {
+		form.Set("client_id", s.auth.ClientID)
+		if s.auth.ClientSecret != "" {
+			form.Set("client_secret", s.auth.ClientSecret)
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.auth.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("build oauth2 token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if s.auth.ClientAuth != "body" {
+		req.SetBasicAuth(url.QueryEscape(s.auth.ClientID), url.QueryEscape(s.auth.ClientSecret))
+	}
+
+	issuedAt := now()
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oauth2 token request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	payload, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("read oauth2 token response: %w", err)
+	}
+
+	var body struct {
+		AccessToken      string `json:"access_token"`
+		TokenType        string `json:"token_type"`
+		RefreshToken     string `json:"refresh_token"`
+		ExpiresIn        int64  `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	decodeErr := json.Unmarshal(payload, &body)
+
+	if resp.StatusCode/100 != 2 || body.Error != "" {
+		if body.Error != "" {
+			return nil, fmt.Errorf("oauth2 token request failed: %s: %s %s", resp.Status, body.Error, body.ErrorDescription)
+		}
+		return nil, fmt.Errorf("oauth2 token request failed: %s", resp.Status)
+	}
+	if decodeErr != nil {
+		return nil, fmt.Errorf("decode oauth2 token response: %w", decodeErr)
+	}
+	if body.AccessToken == "" {
+		return nil, fmt.Errorf("oauth2 token response has no access_token")
+	}
+
+	token := &oauthToken{
+		AccessToken:  body.AccessToken,
+		TokenType:    body.TokenType,
+		RefreshToken: body.RefreshToken,
+	}
+	if body.ExpiresIn > 0 {
+		token.ExpiresAt = issuedAt.Add(time.Duration(body.ExpiresIn) * time.Second).UTC()
+	}
+	return token, nil
+}
+
+func (s *OAuthSource) loadCached() *oauthToken {
+	if s.cachePath == "" {
+		return nil
+	}
+	cache, err := readOAuthCache(s.cachePath)
+	if err != nil {
+		return nil
+	}
+	return cache.Tokens[s.key]
+}
+
+func (s *OAuthSource) saveCached(token *oauthToken) error {
+	if s.cachePath == "" {
+		return nil
+	}
+
+	cache, err := readOAuthCache(s.cachePath)
+	if err != nil {
+		cache = &oauthCacheFile{}
+	}
+	if cache.Tokens == nil {
+		cache.Tokens = make(map[string]*oauthToken)
+	}
+	cache.Tokens[s.key] = token
+
+	payload, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode oauth2 token cache: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.cachePath), 0o700); err != nil {
+		return fmt.Errorf("create oauth2 token cache directory: %w", err)
+	}
+	if err := os.WriteFile(s.cachePath, append(payload, '\n'), 0o600); err != nil {
+		return fmt.Errorf("write oauth2 token cache: %w", err)
+	}
+	return nil
+}
+
+func readOAuthCache(path string) (*oauthCacheFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cache oauthCacheFile
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil, err
+	}
+	return &cache, nil
+}
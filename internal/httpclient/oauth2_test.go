@@ -0,0 +1,223 @@
+package httpclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jaykbpark/wirepad/internal/requestspec"
+)
+
+// tokenStub issues tok-1, tok-2, ... and records the grant of every call.
+type tokenStub struct {
+	server *httptest.Server
+	calls  atomic.Int32
+	grants []string
+	forms  []map[string]string
+}
+
+func newTokenStub(t *testing.T, expiresIn int) *tokenStub {
+	t.Helper()
+	stub := &tokenStub{}
+	stub.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		form := map[string]string{}
+		for key := range r.PostForm {
+			form[key] = r.PostForm.Get(key)
+		}
+		if id, secret, ok := r.BasicAuth(); ok {
+			form["basic"] = id + ":" + secret
+		}
+		stub.grants = append(stub.grants, form["grant_type"])
+		stub.forms = append(stub.forms, form)
+
+		n := stub.calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token":  fmt.Sprintf("tok-%d", n),
+			"token_type":    "Bearer",
+			"expires_in":    expiresIn,
+			"refresh_token": fmt.Sprintf("refresh-%d", n),
+		})
+	}))
+	t.Cleanup(stub.server.Close)
+	return stub
+}
+
+func apiRequiring(t *testing.T, accepted ...string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, token := range accepted {
+			if r.Header.Get("Authorization") == "Bearer "+token {
+				return
+			}
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestExecuteHTTP_OAuth2ClientCredentialsCachesToken(t *testing.T) {
+	stub := newTokenStub(t, 3600)
+	api := apiRequiring(t, "tok-1")
+	cachePath := filepath.Join(t.TempDir(), "oauth", "dev.json")
+
+	spec := httpSpec(api.URL)
+	spec.Request.Auth = &requestspec.Auth{
+		Type:         "oauth2",
+		Grant:        "client_credentials",
+		TokenURL:     stub.server.URL,
+		ClientID:     "app",
+		ClientSecret: "shh",
+		Scopes:       []string{"read", "write"},
+	}
+
+	for i := 0; i < 2; i++ {
+		resp, err := ExecuteHTTPWithOptions(spec, "x.req.yaml", Options{OAuthCachePath: cachePath})
+		if err != nil {
+			t.Fatalf("run %d: ExecuteHTTP returned error: %v", i+1, err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("run %d: expected 200, got %d", i+1, resp.StatusCode)
+		}
+	}
+
+	if stub.calls.Load() != 1 {
+		t.Fatalf("expected the cached token to be reused, got %d token requests", stub.calls.Load())
+	}
+	if form := stub.forms[0]; form["basic"] != "app:shh" || form["scope"] != "read write" {
+		t.Fatalf("unexpected token request %v", form)
+	}
+
+	info, err := os.Stat(cachePath)
+	if err != nil {
+		t.Fatalf("expected token cache file: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Fatalf("expected token cache to be private, got %v", info.Mode().Perm())
+	}
+}
+
+func TestExecuteHTTP_OAuth2RefreshesExpiredToken(t *testing.T) {
+	current := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	previous := now
+	now = func() time.Time { return current }
+	t.Cleanup(func() { now = previous })
+
+	stub := newTokenStub(t, 60)
+	api := apiRequiring(t, "tok-1", "tok-2")
+	cachePath := filepath.Join(t.TempDir(), "dev.json")
+
+	spec := httpSpec(api.URL)
+	spec.Request.Auth = &requestspec.Auth{
+		Type:       "oauth2",
+		Grant:      "password",
+		TokenURL:   stub.server.URL,
+		ClientID:   "cli",
+		ClientAuth: "body",
+		Username:   "alice",
+		Password:   "pw",
+	}
+
+	if _, err := ExecuteHTTPWithOptions(spec, "x.req.yaml", Options{OAuthCachePath: cachePath}); err != nil {
+		t.Fatalf("first run: %v", err)
+	}
+	current = current.Add(2 * time.Minute)
+	if _, err := ExecuteHTTPWithOptions(spec, "x.req.yaml", Options{OAuthCachePath: cachePath}); err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+
+	if len(stub.grants) != 2 || stub.grants[0] != "password" || stub.grants[1] != "refresh_token" {
+		t.Fatalf("expected password grant then refresh, got %v", stub.grants)
+	}
+	if form := stub.forms[0]; form["client_id"] != "cli" || form["username"] != "alice" || form["basic"] != "" {
+		t.Fatalf("expected client credentials in the body, got %v", form)
+	}
+	if stub.forms[1]["refresh_token"] != "refresh-1" {
+		t.Fatalf("expected cached refresh token to be used, got %v", stub.forms[1])
+	}
+}
+
+func TestExecuteHTTP_OAuth2UnauthorizedForcesRefresh(t *testing.T) {
+	stub := newTokenStub(t, 3600)
+	api := apiRequiring(t, "tok-2")
+
+	spec := httpSpec(api.URL)
+	spec.Request.Method = "POST"
+	spec.Request.Body = &requestspec.Body{Mode: "raw", Raw: "payload"}
+	spec.Request.Auth = &requestspec.Auth{
+		Type:         "oauth2",
+		Grant:        "refresh_token",
+		TokenURL:     stub.server.URL,
+		ClientID:     "app",
+		RefreshToken: "seed",
+	}
+
+	resp, err := ExecuteHTTP(spec, "x.req.yaml")
+	if err != nil {
+		t.Fatalf("ExecuteHTTP returned error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected retry with refreshed token to succeed, got %d", resp.StatusCode)
+	}
+	if stub.calls.Load() != 2 || stub.forms[0]["refresh_token"] != "seed" || stub.forms[1]["refresh_token"] != "refresh-1" {
+		t.Fatalf("expected seed refresh then forced refresh, got %v", stub.forms)
+	}
+}
+
+func TestExecuteHTTP_SharedOAuthSourceRefreshesOnce(t *testing.T) {
+	stub := newTokenStub(t, 3600)
+	api := apiRequiring(t, "tok-2")
+
+	spec := httpSpec(api.URL)
+	spec.Request.Auth = &requestspec.Auth{
+		Type:         "oauth2",
+		Grant:        "client_credentials",
+		TokenURL:     stub.server.URL,
+		ClientID:     "app",
+		ClientSecret: "shh",
+	}
+	transport, err := NewTransport(spec.Request, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := NewOAuthSource(spec.Request, transport, "")
+	if token, err := source.Token(); err != nil || token != "tok-1" {
+		t.Fatalf("Token() = %q, %v", token, err)
+	}
+
+	var wg sync.WaitGroup
+	var failed atomic.Int32
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := ExecuteHTTPWithOptions(spec, "x.req.yaml", Options{Transport: transport, OAuth: source})
+			if err != nil || resp.StatusCode != http.StatusOK {
+				failed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if failed.Load() != 0 {
+		t.Fatalf("expected every request to succeed after the refresh, %d failed", failed.Load())
+	}
+	if stub.calls.Load() != 2 {
+		t.Fatalf("expected one shared refresh after the 401s, got %d token requests", stub.calls.Load())
+	}
+	if NewOAuthSource(httpSpec(api.URL).Request, transport, "") != nil {
+		t.Fatal("expected no source for a request without oauth2 auth")
+	}
+}
@@ -0,0 +1,60 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jaykbpark/wirepad/internal/requestspec"
+)
+
+func TestExecuteHTTP_HTTPVersionOverTLS(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Proto))
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	insecure := true
+	for version, want := range map[string]string{"2": "HTTP/2.0", "1.1": "HTTP/1.1"} {
+		spec := httpSpec(server.URL)
+		spec.Request.HTTPVersion = version
+		spec.Request.TLS = &requestspec.TLS{InsecureSkipVerify: &insecure}
+
+		resp, err := ExecuteHTTP(spec, "x.req.yaml")
+		if err != nil {
+			t.Fatalf("http_version %s: ExecuteHTTP returned error: %v", version, err)
+		}
+		if resp.Proto != want || string(resp.Body) != want {
+			t.Fatalf("http_version %s: expected %s, got proto=%s server saw %s", version, want, resp.Proto, resp.Body)
+		}
+	}
+}
+
+func TestExecuteHTTP_H2C(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Proto))
+	}))
+	server.Config.Protocols = new(http.Protocols)
+	server.Config.Protocols.SetHTTP1(true)
+	server.Config.Protocols.SetUnencryptedHTTP2(true)
+	server.Start()
+	defer server.Close()
+
+	spec := httpSpec(server.URL)
+	spec.Request.HTTPVersion = "h2c"
+	resp, err := ExecuteHTTP(spec, "x.req.yaml")
+	if err != nil {
+		t.Fatalf("ExecuteHTTP returned error: %v", err)
+	}
+	if resp.Proto != "HTTP/2.0" || string(resp.Body) != "HTTP/2.0" {
+		t.Fatalf("expected cleartext HTTP/2, got proto=%s server saw %s", resp.Proto, resp.Body)
+	}
+
+	spec.Request.HTTPVersion = "2"
+	if _, err := ExecuteHTTP(spec, "x.req.yaml"); err == nil || !strings.Contains(err.Error(), "use h2c") {
+		t.Fatalf("expected http_version 2 over http:// to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+package httpclient
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestExecuteHTTP_HTTPProxyWithAuth(t *testing.T) {
+	var gotTarget string
+	var gotAuth string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotTarget = r.RequestURI
+		gotAuth = r.Header.Get("Proxy-Authorization")
+		_, _ = io.WriteString(w, "via proxy")
+	}))
+	defer proxy.Close()
+
+	spec := httpSpec("http://api.wirepad.test/users")
+	spec.Request.Proxy = "http://alice:s3cret@" + proxy.Listener.Addr().String()
+
+	resp, err := ExecuteHTTP(spec, "x.req.yaml")
+	if err != nil {
+		t.Fatalf("ExecuteHTTP returned error: %v", err)
+	}
+	if string(resp.Body) != "via proxy" {
+		t.Fatalf("expected proxied response, got %q", resp.Body)
+	}
+	if gotTarget != "http://api.wirepad.test/users" {
+		t.Fatalf("expected absolute-form request URI at proxy, got %q", gotTarget)
+	}
+	wantAuth := "Basic " + base64.StdEncoding.EncodeToString([]byte("alice:s3cret"))
+	if gotAuth != wantAuth {
+		t.Fatalf("expected proxy credentials %q, got %q", wantAuth, gotAuth)
+	}
+}
+
+func TestExecuteHTTP_SOCKS5Proxy(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "via socks")
+	}))
+	defer target.Close()
+
+	socksAddr, connects := startSOCKS5Server(t)
+
+	spec := httpSpec(target.URL)
+	spec.Request.Proxy = "socks5://" + socksAddr
+
+	resp, err := ExecuteHTTP(spec, "x.req.yaml")
+	if err != nil {
+		t.Fatalf("ExecuteHTTP returned error: %v", err)
+	}
+	if string(resp.Body) != "via socks" {
+		t.Fatalf("expected response through SOCKS5, got %q", resp.Body)
+	}
+	if got := <-connects; got != target.Listener.Addr().String() {
+		t.Fatalf("expected SOCKS5 CONNECT to %q, got %q", target.Listener.Addr().String(), got)
+	}
+}
+
+func TestProxyFunc_RejectsUnknownScheme(t *testing.T) {
+	if _, err := ProxyFunc("ftp://proxy:21"); err == nil {
+		t.Fatal("expected unsupported scheme error")
+	}
+	if proxy, err := ProxyFunc("none"); err != nil || proxy != nil {
+		t.Fatalf("expected none to disable proxies, got non-nil=%t, err=%v", proxy != nil, err)
+	}
+}
+
+// startSOCKS5Server runs a minimal no-auth SOCKS5 CONNECT proxy and reports
+// every requested destination on the returned channel.
+func startSOCKS5Server(t *testing.T) (string, <-chan string) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	connects := make(chan string, 4)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveSOCKS5(conn, connects)
+		}
+	}()
+	return listener.Addr().String(), connects
+}
+
+func serveSOCKS5(conn net.Conn, connects chan<- string) {
+	defer conn.Close()
+
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return
+	}
+	if _, err := io.ReadFull(conn, make([]byte, header[1])); err != nil {
+		return
+	}
+	if _, err := conn.Write([]byte{5, 0}); err != nil {
+		return
+	}
+
+	request := make([]byte, 4)
+	if _, err := io.ReadFull(conn, request); err != nil {
+		return
+	}
+
+	var host string
+	switch request[3] {
+	case 1:
+		ip := make([]byte, 4)
+		if _, err := io.ReadFull(conn, ip); err != nil {
+			return
+		}
+		host = net.IP(ip).String()
+	case 3:
+		size := make([]byte, 1)
+		if _, err := io.ReadFull(conn, size); err != nil {
+			return
+		}
+		name := make([]byte, size[0])
+		if _, err := io.ReadFull(conn, name); err != nil {
+			return
+		}
+		host = string(name)
+	default:
+		return
+	}
+	portBytes := make([]byte, 2)
+	if _, err := io.ReadFull(conn, portBytes); err != nil {
+		return
+	}
+	addr := net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(portBytes))))
+	connects <- addr
+
+	upstream, err := net.Dial("tcp", addr)
+	if err != nil {
+		_, _ = conn.Write([]byte{5, 1, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer upstream.Close()
+	if _, err := conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0}); err != nil {
+		return
+	}
+
+	go func() { _, _ = io.Copy(upstream, conn) }()
+	_, _ = io.Copy(conn, upstream)
+}
@@ -0,0 +1,129 @@
+package httpclient
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/jaykbpark/wirepad/internal/requestspec"
+)
+
+// addQuery appends request.query to u in declaration order. Parameters already
+// in the URL keep their position unless request.query sets the same key.
+func addQuery(u *url.URL, req *requestspec.Request) {
+	if len(req.Query) == 0 {
+		return
+	}
+
+	var pairs []string
+	for _, key := range orderedKeys(req.Query, req.QueryOrder) {
+		values := paramValues(req.Query[key])
+		switch {
+		case len(values) == 0:
+			continue
+		case req.ArrayFormat == "comma" && isList(req.Query[key]):
+			escaped := make([]string, len(values))
+			for i, value := range values {
+				escaped[i] = url.QueryEscape(value)
+			}
+			pairs = append(pairs, url.QueryEscape(key)+"="+strings.Join(escaped, ","))
+		case req.ArrayFormat == "brackets" && isList(req.Query[key]):
+			for _, value := range values {
+				pairs = append(pairs, url.QueryEscape(key+"[]")+"="+url.QueryEscape(value))
+			}
+		default:
+			for _, value := range values {
+				pairs = append(pairs, url.QueryEscape(key)+"="+url.QueryEscape(value))
+			}
+		}
+	}
+
+	u.RawQuery = mergeQuery(u.RawQuery, req.Query, pairs)
+}
+
+// setQueryParam replaces key in u's query, appending it after the others.
+func setQueryParam(u *url.URL, key, value string) {
+	u.RawQuery = mergeQuery(u.RawQuery, map[string]any{key: nil}, []string{url.QueryEscape(key) + "=" + url.QueryEscape(value)})
+}
+
+// mergeQuery drops the parameters of raw whose key is in replaced and appends
+// pairs, leaving the rest of raw untouched.
+func mergeQuery(raw string, replaced map[string]any, pairs []string) string {
+	var kept []string
+	for _, part := range strings.Split(raw, "&") {
+		if part == "" {
+			continue
+		}
+		name, _, _ := strings.Cut(part, "=")
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+		if _, ok := replaced[name]; ok {
+			continue
+		}
+		kept = append(kept, part)
+	}
+	return strings.Join(append(kept, pairs...), "&")
+}
+
+func setHeaders(req *http.Request, headers map[string]any) {
+	for key, value := range headers {
+		req.Header.Del(key)
+		for _, item := range paramValues(value) {
+			req.Header.Add(key, item)
+		}
+	}
+}
+
+// orderedKeys lists the keys of values in declared order, then any keys the
+// order does not know about sorted.
+func orderedKeys(values map[string]any, order []string) []string {
+	keys := make([]string, 0, len(values))
+	seen := make(map[string]bool, len(values))
+	for _, key := range order {
+		if _, ok := values[key]; ok && !seen[key] {
+			keys = append(keys, key)
+			seen[key] = true
+		}
+	}
+	var rest []string
+	for key := range values {
+		if !seen[key] {
+			rest = append(rest, key)
+		}
+	}
+	sort.Strings(rest)
+	return append(keys, rest...)
+}
+
+// paramValues flattens a query or header value; lists repeat the parameter.
+func paramValues(value any) []string {
+	switch v := value.(type) {
+	case nil:
+		return []string{""}
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if item == nil {
+				out = append(out, "")
+				continue
+			}
+			out = append(out, fmt.Sprint(item))
+		}
+		return out
+	case []string:
+		return v
+	default:
+		return []string{fmt.Sprint(v)}
+	}
+}
+
+func isList(value any) bool {
+	switch value.(type) {
+	case []any, []string:
+		return true
+	}
+	return false
+}
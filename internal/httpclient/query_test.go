@@ -0,0 +1,102 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/jaykbpark/wirepad/internal/requestspec"
+)
+
+func TestExecuteHTTP_QueryListsKeepDeclaredOrder(t *testing.T) {
+	var rawQuery string
+	var tags []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rawQuery = r.URL.RawQuery
+		tags = r.Header.Values("X-Tag")
+	}))
+	defer server.Close()
+
+	cases := []struct {
+		format string
+		want   string
+	}{
+		{"", "keep=1&z=last&ids=1&ids=2&ids=3&a=x+y"},
+		{"repeat", "keep=1&z=last&ids=1&ids=2&ids=3&a=x+y"},
+		{"comma", "keep=1&z=last&ids=1,2,3&a=x+y"},
+		{"brackets", "keep=1&z=last&ids%5B%5D=1&ids%5B%5D=2&ids%5B%5D=3&a=x+y"},
+	}
+	for _, tc := range cases {
+		spec := httpSpec(server.URL + "/items?keep=1&a=old")
+		spec.Request.Query = map[string]any{"z": "last", "ids": []any{1, 2, 3}, "a": "x y"}
+		spec.Request.QueryOrder = []string{"z", "ids", "a"}
+		spec.Request.ArrayFormat = tc.format
+		spec.Request.Headers = map[string]any{"X-Tag": []any{"blue", "green"}}
+
+		if _, err := ExecuteHTTP(spec, "x.req.yaml"); err != nil {
+			t.Fatalf("%s: ExecuteHTTP returned error: %v", tc.format, err)
+		}
+		if rawQuery != tc.want {
+			t.Fatalf("%s: expected query %q, got %q", tc.format, tc.want, rawQuery)
+		}
+		if !slices.Equal(tags, []string{"blue", "green"}) {
+			t.Fatalf("expected repeated X-Tag headers, got %v", tags)
+		}
+	}
+}
+
+func TestExecuteHTTP_APIKeyQueryKeepsOrder(t *testing.T) {
+	var rawQuery string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rawQuery = r.URL.RawQuery
+	}))
+	defer server.Close()
+
+	spec := httpSpec(server.URL)
+	spec.Request.Query = map[string]any{"b": 2, "a": 1}
+	spec.Request.QueryOrder = []string{"b", "a"}
+	spec.Request.Auth = &requestspec.Auth{Type: "api_key", In: "query", Name: "key", Value: "k1"}
+
+	if _, err := ExecuteHTTP(spec, "x.req.yaml"); err != nil {
+		t.Fatalf("ExecuteHTTP returned error: %v", err)
+	}
+	if rawQuery != "b=2&a=1&key=k1" {
+		t.Fatalf("unexpected query %q", rawQuery)
+	}
+}
+
+func TestExecuteHTTP_SendsPlainScalarsAsWritten(t *testing.T) {
+	var rawQuery, version string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rawQuery = r.URL.RawQuery
+		version = r.Header.Get("Accept-Version")
+	}))
+	defer server.Close()
+
+	spec, _, err := requestspec.Parse([]byte(`
+version: 1
+kind: http
+request:
+  method: GET
+  url: ` + server.URL + `/items
+  headers:
+    Accept-Version: 1.0
+  query:
+    v: 1.10
+    id: 12345678901234567890
+    hex: 0x1F
+`))
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if _, err := ExecuteHTTP(spec, "x.req.yaml"); err != nil {
+		t.Fatalf("ExecuteHTTP returned error: %v", err)
+	}
+	if want := "v=1.10&id=12345678901234567890&hex=0x1F"; rawQuery != want {
+		t.Fatalf("expected query %q, got %q", want, rawQuery)
+	}
+	if version != "1.0" {
+		t.Fatalf("expected Accept-Version 1.0, got %q", version)
+	}
+}
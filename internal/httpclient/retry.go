@@ -0,0 +1,193 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/jaykbpark/wirepad/internal/requestspec"
+)
+
+const (
+	defaultRetryDelay    = 500 * time.Millisecond
+	defaultRetryMaxDelay = 30 * time.Second
+	// drainLimit bounds how much of a discarded response is read so the
+	// connection can be reused.
+	drainLimit = 64 << 10
+)
+
+var (
+	defaultRetryStatuses = []int{429, 502, 503, 504}
+	defaultRetryErrors   = []string{"timeout", "connection_reset", "connection_refused"}
+)
+
+// sleep waits between attempts; tests replace it to avoid real delays.
+var sleep = func(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Attempt is one try of a request under a retry policy.
+type Attempt struct {
+	Number    int
+	StartedAt time.Time
+	Duration  time.Duration
+	Status    int
+	Error     string
+	// Delay is the wait before the next attempt; zero on the last one.
+	Delay time.Duration
+}
+
+// RetryError is returned when every attempt failed without a response.
+type RetryError struct {
+	Attempts []Attempt
+	Err      error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("execute http request: gave up after %d attempts: %v", len(e.Attempts), e.Err)
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+type retryPolicy struct {
+	attempts          int
+	exponential       bool
+	delay             time.Duration
+	maxDelay          time.Duration
+	jitter            bool
+	onStatus          []int
+	onError           []string
+	respectRetryAfter bool
+}
+
+func newRetryPolicy(cfg *requestspec.Retry) retryPolicy {
+	if cfg == nil || cfg.Attempts <= 1 {
+		return retryPolicy{attempts: 1}
+	}
+
+	policy := retryPolicy{
+		attempts:          cfg.Attempts,
+		exponential:       cfg.Backoff == "exponential",
+		delay:             defaultRetryDelay,
+		maxDelay:          defaultRetryMaxDelay,
+		onStatus:          cfg.OnStatus,
+		onError:           cfg.OnError,
+		respectRetryAfter: cfg.RespectRetryAfter == nil || *cfg.RespectRetryAfter,
+	}
+	if cfg.DelayMS > 0 {
+		policy.delay = time.Duration(cfg.DelayMS) * time.Millisecond
+	}
+	if cfg.MaxDelayMS > 0 {
+		policy.maxDelay = time.Duration(cfg.MaxDelayMS) * time.Millisecond
+	}
+	policy.jitter = policy.exponential
+	if cfg.Jitter != nil {
+		policy.jitter = *cfg.Jitter
+	}
+	if len(policy.onStatus) == 0 && len(policy.onError) == 0 {
+		policy.onStatus = defaultRetryStatuses
+		policy.onError = defaultRetryErrors
+	}
+	return policy
+}
+
+// next reports whether attempt should be retried and how long to wait first.
+func (p retryPolicy) next(attempt int, resp *http.Response, err error) (time.Duration, bool) {
+	if attempt >= p.attempts {
+		return 0, false
+	}
+
+	if err != nil {
+		kind := classifyError(err)
+		if kind == "" || !slices.Contains(p.onError, kind) {
+			return 0, false
+		}
+		return p.backoff(attempt), true
+	}
+
+	if !slices.Contains(p.onStatus, resp.StatusCode) {
+		return 0, false
+	}
+	if p.respectRetryAfter {
+		if wait, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			return min(wait, p.maxDelay), true
+		}
+	}
+	return p.backoff(attempt), true
+}
+
+func (p retryPolicy) backoff(attempt int) time.Duration {
+	delay := p.delay
+	if p.exponential {
+		for i := 1; i < attempt && delay < p.maxDelay; i++ {
+			delay *= 2
+		}
+	}
+	delay = min(delay, p.maxDelay)
+	if p.jitter && delay > 0 {
+		// Equal jitter: keep half the delay and randomize the rest.
+		half := delay / 2
+		delay = half + rand.N(half+1)
+	}
+	return delay
+}
+
+// classifyError maps a transport error to a request.retry.on_error kind, or
+// "" when the error is not retryable.
+func classifyError(err error) string {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.Canceled):
+		return ""
+	case errors.As(err, &dnsErr):
+		return "dns"
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return "connection_refused"
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return "connection_reset"
+	default:
+		return ""
+	}
+}
+
+// parseRetryAfter accepts both forms of the header: delay seconds or an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+func discardResponse(resp *http.Response) {
+	_, _ = io.CopyN(io.Discard, resp.Body, drainLimit)
+	_ = resp.Body.Close()
+}
@@ -0,0 +1,150 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jaykbpark/wirepad/internal/requestspec"
+)
+
+func stubSleep(t *testing.T) *[]time.Duration {
+	t.Helper()
+	var delays []time.Duration
+	previous := sleep
+	sleep = func(_ context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return nil
+	}
+	t.Cleanup(func() { sleep = previous })
+	return &delays
+}
+
+func TestExecuteHTTP_RetriesStatusAndRebuildsBody(t *testing.T) {
+	delays := stubSleep(t)
+
+	var calls atomic.Int32
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(payload))
+		switch calls.Add(1) {
+		case 1:
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			_, _ = io.WriteString(w, "ok")
+		}
+	}))
+	defer server.Close()
+
+	jitter := false
+	spec := httpSpec(server.URL)
+	spec.Request.Method = "POST"
+	spec.Request.Body = &requestspec.Body{Mode: "raw", Raw: "payload"}
+	spec.Request.Retry = &requestspec.Retry{Attempts: 4, Backoff: "fixed", DelayMS: 100, Jitter: &jitter, OnStatus: []int{502, 503}}
+
+	resp, err := ExecuteHTTP(spec, "x.req.yaml")
+	if err != nil {
+		t.Fatalf("ExecuteHTTP returned error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || string(resp.Body) != "ok" {
+		t.Fatalf("expected final 200 ok, got %d %q", resp.StatusCode, resp.Body)
+	}
+	if len(resp.Attempts) != 3 || resp.Attempts[0].Status != 503 || resp.Attempts[1].Status != 502 || resp.Attempts[2].Status != 200 {
+		t.Fatalf("unexpected attempts %+v", resp.Attempts)
+	}
+	for i, body := range bodies {
+		if body != "payload" {
+			t.Fatalf("attempt %d sent body %q, expected rebuilt payload", i+1, body)
+		}
+	}
+	if len(*delays) != 2 || (*delays)[0] != 2*time.Second || (*delays)[1] != 100*time.Millisecond {
+		t.Fatalf("expected Retry-After then fixed delay, got %v", *delays)
+	}
+}
+
+func TestExecuteHTTP_RetriesConnectionReset(t *testing.T) {
+	stubSleep(t)
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+			return
+		}
+		_, _ = io.WriteString(w, "recovered")
+	}))
+	defer server.Close()
+
+	spec := httpSpec(server.URL)
+	spec.Request.Retry = &requestspec.Retry{Attempts: 2, OnError: []string{"connection_reset"}}
+
+	resp, err := ExecuteHTTP(spec, "x.req.yaml")
+	if err != nil {
+		t.Fatalf("ExecuteHTTP returned error: %v", err)
+	}
+	if string(resp.Body) != "recovered" || len(resp.Attempts) != 2 || resp.Attempts[0].Error == "" {
+		t.Fatalf("expected recovery on second attempt, got body=%q attempts=%+v", resp.Body, resp.Attempts)
+	}
+}
+
+func TestExecuteHTTP_RetryErrorKeepsAttempts(t *testing.T) {
+	stubSleep(t)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	spec := httpSpec("http://" + addr)
+	spec.Request.Retry = &requestspec.Retry{Attempts: 3, OnError: []string{"connection_refused"}}
+
+	_, err = ExecuteHTTP(spec, "x.req.yaml")
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) {
+		t.Fatalf("expected RetryError, got %v", err)
+	}
+	if len(retryErr.Attempts) != 3 || retryErr.Attempts[2].Delay != 0 {
+		t.Fatalf("expected 3 recorded attempts, got %+v", retryErr.Attempts)
+	}
+}
+
+func TestRetryPolicy_ExponentialBackoff(t *testing.T) {
+	jitter := false
+	policy := newRetryPolicy(&requestspec.Retry{Attempts: 6, Backoff: "exponential", DelayMS: 100, MaxDelayMS: 500, Jitter: &jitter})
+
+	var got []time.Duration
+	for attempt := 1; attempt <= 4; attempt++ {
+		got = append(got, policy.backoff(attempt))
+	}
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 500 * time.Millisecond}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected delays %v, got %v", want, got)
+		}
+	}
+
+	jittered := newRetryPolicy(&requestspec.Retry{Attempts: 2, Backoff: "exponential", DelayMS: 100})
+	if d := jittered.backoff(1); d < 50*time.Millisecond || d > 100*time.Millisecond {
+		t.Fatalf("expected jittered delay within [50ms, 100ms], got %v", d)
+	}
+
+	resp := &http.Response{StatusCode: http.StatusNotFound, Header: http.Header{}}
+	if _, retry := policy.next(1, resp, nil); retry {
+		t.Fatal("expected 404 not to be retried by default")
+	}
+}
@@ -0,0 +1,94 @@
+package httpclient
+
+import (
+	"bufio"
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SSEEvent is one dispatched Server-Sent Event.
+type SSEEvent struct {
+	ID         string
+	Event      string
+	Data       string
+	Retry      int
+	ReceivedAt time.Time
+}
+
+// IsEventStream reports whether a Content-Type header is text/event-stream.
+func IsEventStream(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == "text/event-stream"
+}
+
+// readSSE parses an event stream and calls fn for every dispatched event
+// until the stream ends or fn returns false. It follows the WHATWG parsing
+// rules: data lines are joined with newlines, a single space after the colon
+// is dropped and lines starting with a colon are comments.
+func readSSE(r io.Reader, fn func(SSEEvent) bool) error {
+	reader := bufio.NewReader(r)
+
+	var event SSEEvent
+	var data strings.Builder
+	hasData := false
+	first := true
+
+	for {
+		line, err := reader.ReadString('\n')
+		if line == "" && err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		line = strings.TrimSuffix(line, "\n")
+		line = strings.TrimSuffix(line, "\r")
+		if first {
+			line = strings.TrimPrefix(line, "\ufeff")
+			first = false
+		}
+
+		if line == "" {
+			if hasData {
+				event.Data = strings.TrimSuffix(data.String(), "\n")
+				event.ReceivedAt = time.Now().UTC()
+				if !fn(event) {
+					return nil
+				}
+			}
+			// The last event ID persists across events, everything else resets.
+			event = SSEEvent{ID: event.ID}
+			data.Reset()
+			hasData = false
+		} else if !strings.HasPrefix(line, ":") {
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "data":
+				data.WriteString(value)
+				data.WriteByte('\n')
+				hasData = true
+			case "event":
+				event.Event = value
+			case "id":
+				if !strings.Contains(value, "\x00") {
+					event.ID = value
+				}
+			case "retry":
+				if retry, err := strconv.Atoi(value); err == nil {
+					event.Retry = retry
+				}
+			}
+		}
+
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
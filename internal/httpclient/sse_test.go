@@ -0,0 +1,109 @@
+package httpclient
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadSSE(t *testing.T) {
+	stream := "\ufeff: keep-alive\n" +
+		"event: update\nid: 7\ndata: {\"a\":1}\n\n" +
+		"data: line one\r\ndata:line two\nretry: 1500\n\n" +
+		"data: incomplete"
+
+	var events []SSEEvent
+	if err := readSSE(strings.NewReader(stream), func(event SSEEvent) bool {
+		events = append(events, event)
+		return true
+	}); err != nil {
+		t.Fatalf("readSSE returned error: %v", err)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("expected 2 dispatched events, got %+v", events)
+	}
+	if events[0].Event != "update" || events[0].ID != "7" || events[0].Data != `{"a":1}` {
+		t.Fatalf("unexpected first event %+v", events[0])
+	}
+	if events[1].Data != "line one\nline two" || events[1].Retry != 1500 {
+		t.Fatalf("unexpected second event %+v", events[1])
+	}
+	if events[1].ID != "7" || events[1].Event != "" {
+		t.Fatalf("expected id to persist and event type to reset, got %+v", events[1])
+	}
+}
+
+func TestExecuteHTTPWithOptions_StopsEndlessEventStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+		for i := 0; ; i++ {
+			if _, err := fmt.Fprintf(w, "data: %d\n\n", i); err != nil {
+				return
+			}
+			flusher.Flush()
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(5 * time.Millisecond):
+			}
+		}
+	}))
+	defer server.Close()
+
+	var got []string
+	resp, err := ExecuteHTTPWithOptions(httpSpec(server.URL), "x.req.yaml", Options{
+		Stream: true,
+		OnEvent: func(event SSEEvent) bool {
+			got = append(got, event.Data)
+			return len(got) < 3
+		},
+		MaxBodyBytes: 8,
+	})
+	if err != nil {
+		t.Fatalf("ExecuteHTTPWithOptions returned error: %v", err)
+	}
+	if strings.Join(got, ",") != "0,1,2" {
+		t.Fatalf("expected three events, got %v", got)
+	}
+	if len(resp.Body) != 8 || !resp.BodyTruncated || resp.BodySize <= 8 {
+		t.Fatalf("expected body capped at 8 bytes, got %d of %d (truncated=%t)", len(resp.Body), resp.BodySize, resp.BodyTruncated)
+	}
+}
+
+func TestExecuteHTTPWithOptions_StreamsBodyToOutput(t *testing.T) {
+	payload := bytes.Repeat([]byte("wirepad"), 10000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(len(payload)))
+		_, _ = w.Write(payload)
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	var lastReceived, lastTotal int64
+	resp, err := ExecuteHTTPWithOptions(httpSpec(server.URL), "x.req.yaml", Options{
+		Output: &out,
+		Progress: func(received, total int64) {
+			lastReceived, lastTotal = received, total
+		},
+		MaxBodyBytes: 16,
+	})
+	if err != nil {
+		t.Fatalf("ExecuteHTTPWithOptions returned error: %v", err)
+	}
+	if !bytes.Equal(out.Bytes(), payload) {
+		t.Fatalf("expected full body in output, got %d bytes", out.Len())
+	}
+	if lastReceived != int64(len(payload)) || lastTotal != int64(len(payload)) {
+		t.Fatalf("expected progress %d/%d, got %d/%d", len(payload), len(payload), lastReceived, lastTotal)
+	}
+	if len(resp.Body) != 16 || resp.BodySize != int64(len(payload)) {
+		t.Fatalf("expected 16 buffered bytes of %d, got %d of %d", len(payload), len(resp.Body), resp.BodySize)
+	}
+}
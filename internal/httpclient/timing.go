@@ -0,0 +1,123 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timing is the phase breakdown of one HTTP exchange. DNS, Connect and TLS
+// are zero when a pooled connection was reused. TTFB is measured from the
+// start of the request, Transfer from the first response byte to the end of
+// the body.
+type Timing struct {
+	DNS        time.Duration
+	Connect    time.Duration
+	TLS        time.Duration
+	TTFB       time.Duration
+	Transfer   time.Duration
+	Total      time.Duration
+	ConnReused bool
+}
+
+// Milliseconds returns the phases keyed by their expect.timing names.
+func (t Timing) Milliseconds() map[string]float64 {
+	return map[string]float64{
+		"dns_ms":      durationMS(t.DNS),
+		"connect_ms":  durationMS(t.Connect),
+		"tls_ms":      durationMS(t.TLS),
+		"ttfb_ms":     durationMS(t.TTFB),
+		"transfer_ms": durationMS(t.Transfer),
+		"total_ms":    durationMS(t.Total),
+	}
+}
+
+func durationMS(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+type timingTrace struct {
+	mu sync.Mutex
+
+	start        time.Time
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+	firstByte    time.Time
+
+	timing Timing
+}
+
+func newTimingTrace(start time.Time) *timingTrace {
+	return &timingTrace{start: start}
+}
+
+func (t *timingTrace) withContext(req *http.Request) *http.Request {
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.mu.Lock()
+			t.dnsStart = time.Now()
+			t.mu.Unlock()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.mu.Lock()
+			if !t.dnsStart.IsZero() {
+				t.timing.DNS = time.Since(t.dnsStart)
+			}
+			t.mu.Unlock()
+		},
+		ConnectStart: func(string, string) {
+			t.mu.Lock()
+			// Happy-eyeballs may dial several addresses; keep the first start.
+			if t.connectStart.IsZero() {
+				t.connectStart = time.Now()
+			}
+			t.mu.Unlock()
+		},
+		ConnectDone: func(_, _ string, err error) {
+			t.mu.Lock()
+			if err == nil && !t.connectStart.IsZero() {
+				t.timing.Connect = time.Since(t.connectStart)
+			}
+			t.mu.Unlock()
+		},
+		TLSHandshakeStart: func() {
+			t.mu.Lock()
+			t.tlsStart = time.Now()
+			t.mu.Unlock()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.mu.Lock()
+			if !t.tlsStart.IsZero() {
+				t.timing.TLS = time.Since(t.tlsStart)
+			}
+			t.mu.Unlock()
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			t.timing.ConnReused = info.Reused
+			t.mu.Unlock()
+		},
+		GotFirstResponseByte: func() {
+			t.mu.Lock()
+			t.firstByte = time.Now()
+			t.timing.TTFB = t.firstByte.Sub(t.start)
+			t.mu.Unlock()
+		},
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+}
+
+// finish records the end of the body transfer and returns the breakdown.
+func (t *timingTrace) finish(end time.Time) Timing {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.firstByte.IsZero() {
+		t.timing.Transfer = end.Sub(t.firstByte)
+	}
+	t.timing.Total = end.Sub(t.start)
+	return t.timing
+}
@@ -0,0 +1,157 @@
+package httpclient
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaykbpark/wirepad/internal/requestspec"
+)
+
+func TestExecuteHTTP_TLSWithPrivateCA(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	caPath := filepath.Join(dir, "ca.pem")
+	writePEM(t, caPath, "CERTIFICATE", server.Certificate().Raw)
+
+	spec := httpSpec(server.URL)
+	if _, err := ExecuteHTTP(spec, filepath.Join(dir, "x.req.yaml")); err == nil {
+		t.Fatal("expected unknown authority error without ca_file")
+	}
+
+	spec.Request.TLS = &requestspec.TLS{CAFile: "ca.pem", MinVersion: "1.2"}
+	resp, err := ExecuteHTTP(spec, filepath.Join(dir, "x.req.yaml"))
+	if err != nil {
+		t.Fatalf("ExecuteHTTP returned error: %v", err)
+	}
+	if resp.TLS == nil {
+		t.Fatal("expected negotiated TLS details")
+	}
+	if !strings.HasPrefix(resp.TLS.Version, "TLS 1.") || resp.TLS.CipherSuite == "" {
+		t.Fatalf("unexpected TLS details: %+v", resp.TLS)
+	}
+	if resp.TLS.CertNotAfter.IsZero() || resp.TLS.CertSubject == "" {
+		t.Fatalf("expected certificate subject and expiry, got %+v", resp.TLS)
+	}
+}
+
+func TestExecuteHTTP_TLSInsecureSkipVerify(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	insecure := true
+	spec := httpSpec(server.URL)
+	spec.Request.TLS = &requestspec.TLS{InsecureSkipVerify: &insecure}
+
+	if _, err := ExecuteHTTP(spec, "x.req.yaml"); err != nil {
+		t.Fatalf("ExecuteHTTP returned error: %v", err)
+	}
+}
+
+func TestExecuteHTTP_MutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "client.pem")
+	keyPath := filepath.Join(dir, "client-key.pem")
+	clientCert := writeClientCert(t, certPath, keyPath)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+
+	var gotCN string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotCN = r.TLS.PeerCertificates[0].Subject.CommonName
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	defer server.Close()
+
+	caPath := filepath.Join(dir, "ca.pem")
+	writePEM(t, caPath, "CERTIFICATE", server.Certificate().Raw)
+
+	spec := httpSpec(server.URL)
+	spec.Request.TLS = &requestspec.TLS{CAFile: caPath}
+	if _, err := ExecuteHTTP(spec, "x.req.yaml"); err == nil {
+		t.Fatal("expected handshake failure without client certificate")
+	}
+
+	spec.Request.TLS.CertFile = certPath
+	spec.Request.TLS.KeyFile = keyPath
+	if _, err := ExecuteHTTP(spec, "x.req.yaml"); err != nil {
+		t.Fatalf("ExecuteHTTP returned error: %v", err)
+	}
+	if gotCN != "wirepad-client" {
+		t.Fatalf("expected server to see client certificate, got CN %q", gotCN)
+	}
+}
+
+func httpSpec(url string) *requestspec.Spec {
+	return &requestspec.Spec{
+		Kind: requestspec.KindHTTP,
+		Name: "test",
+		Request: &requestspec.Request{
+			Method: "GET",
+			URL:    url,
+		},
+	}
+}
+
+func writeClientCert(t *testing.T, certPath, keyPath string) *x509.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "wirepad-client"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+
+	writePEM(t, certPath, "CERTIFICATE", der)
+	writePEM(t, keyPath, "PRIVATE KEY", keyDER)
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse certificate: %v", err)
+	}
+	return cert
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
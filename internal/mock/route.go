@@ -0,0 +1,113 @@
+package mock
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([a-zA-Z0-9_.-]+)\s*\}\}`)
+
+// segment is one path element of a route: a literal, a whole-segment
+// {{param}}, or a pattern for segments such as v{{version}}.
+type segment struct {
+	literal string
+	param   string
+	pattern *regexp.Regexp
+}
+
+func normalizePath(path string) string {
+	return "/" + strings.Trim(path, "/")
+}
+
+// parsePattern splits a route path into segments and counts its literal
+// segments, which rank routes when several match. A parameter name may
+// appear only once, since a second capture would overwrite the first.
+func parsePattern(path string) ([]segment, int, error) {
+	var segments []segment
+	literals := 0
+	seen := make(map[string]bool)
+	for _, part := range splitPath(path) {
+		locs := placeholderPattern.FindAllStringSubmatchIndex(part, -1)
+		for _, loc := range locs {
+			name := part[loc[2]:loc[3]]
+			if seen[name] {
+				return nil, 0, fmt.Errorf("path parameter {{%s}} appears more than once", name)
+			}
+			seen[name] = true
+		}
+		switch {
+		case len(locs) == 0:
+			segments = append(segments, segment{literal: part})
+			literals++
+		case len(locs) == 1 && locs[0][0] == 0 && locs[0][1] == len(part):
+			segments = append(segments, segment{param: part[locs[0][2]:locs[0][3]]})
+		default:
+			var expr strings.Builder
+			expr.WriteString("^")
+			last := 0
+			for _, loc := range locs {
+				expr.WriteString(regexp.QuoteMeta(part[last:loc[0]]))
+				expr.WriteString("(?P<" + sanitizeGroup(part[loc[2]:loc[3]]) + ">.+?)")
+				last = loc[1]
+			}
+			expr.WriteString(regexp.QuoteMeta(part[last:]))
+			expr.WriteString("$")
+			segments = append(segments, segment{pattern: regexp.MustCompile(expr.String())})
+		}
+	}
+	return segments, literals, nil
+}
+
+// displayPattern renders segments as /users/:id.
+func displayPattern(path string) string {
+	return placeholderPattern.ReplaceAllStringFunc(path, func(match string) string {
+		return ":" + placeholderPattern.FindStringSubmatch(match)[1]
+	})
+}
+
+// match reports whether path fits segments and returns the captured params.
+func match(segments []segment, path string) (map[string]string, bool) {
+	parts := splitPath(path)
+	if len(parts) != len(segments) {
+		return nil, false
+	}
+	params := make(map[string]string)
+	for i, seg := range segments {
+		part := parts[i]
+		switch {
+		case seg.pattern != nil:
+			groups := seg.pattern.FindStringSubmatch(part)
+			if groups == nil {
+				return nil, false
+			}
+			for j, name := range seg.pattern.SubexpNames() {
+				if name != "" {
+					params[restoreGroup(name)] = groups[j]
+				}
+			}
+		case seg.param != "":
+			params[seg.param] = part
+		case seg.literal != part:
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+// Regexp group names cannot hold "." or "-", which variable names can.
+func sanitizeGroup(name string) string {
+	return strings.NewReplacer(".", "_DOT_", "-", "_DASH_").Replace(name)
+}
+
+func restoreGroup(name string) string {
+	return strings.NewReplacer("_DOT_", ".", "_DASH_", "-").Replace(name)
+}
@@ -0,0 +1,358 @@
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jaykbpark/wirepad/internal/config"
+	"github.com/jaykbpark/wirepad/internal/history"
+	"github.com/jaykbpark/wirepad/internal/requestspec"
+)
+
+// Options apply to every route; a spec's mock block overrides the latency
+// and error settings for its own route.
+type Options struct {
+	// Vars interpolate {{var}} in mock blocks, alongside path params.
+	Vars map[string]string
+	// Latency delays every response; a larger LatencyMax makes the delay
+	// random between the two.
+	Latency    time.Duration
+	LatencyMax time.Duration
+	// ErrorRate is the fraction of requests answered with ErrorStatus
+	// (default 500) instead of the mock response.
+	ErrorRate   float64
+	ErrorStatus int
+	// Log receives one line per request when set.
+	Log io.Writer
+}
+
+// Route serves the response of one request spec.
+type Route struct {
+	Method string
+	// Pattern is the spec's URL path, e.g. /users/:id.
+	Pattern string
+	Name    string
+	Path    string
+	// Source is "mock" for a mock block, or the run ID of the replayed
+	// history record.
+	Source string
+
+	segments []segment
+	literals int
+	mock     *requestspec.Mock
+	record   *history.RunRecord
+}
+
+// Server routes requests to the specs it was built from.
+type Server struct {
+	routes []*Route
+	opts   Options
+	random func() float64
+
+	logMu sync.Mutex
+}
+
+// servableRun reports whether a run record can answer mock requests.
+func servableRun(record history.RunRecord) bool {
+	return record.OK && record.GRPC == nil && record.Status >= 200 && record.Status < 400
+}
+
+// NewServer builds routes from request spec files. Specs that cannot be
+// served are skipped with a warning.
+func NewServer(files []string, opts Options) (*Server, []string, error) {
+	server := &Server{opts: opts, random: rand.Float64}
+	var warnings []string
+	seen := make(map[string]*Route)
+	// runs is read from history once, for the first spec without a mock block.
+	var runs map[string]*history.RunRecord
+
+	for _, path := range files {
+		loaded, err := requestspec.LoadFile(path, requestspec.LoadOptions{})
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s: skipped: %v", path, err))
+			continue
+		}
+		spec := loaded.Spec
+		if spec.Kind != requestspec.KindHTTP {
+			continue
+		}
+
+		urlPath := requestspec.RoutePath(spec.Request.URL)
+		route := &Route{
+			Method:  strings.ToUpper(spec.Request.Method),
+			Pattern: displayPattern(urlPath),
+			Name:    spec.Name,
+			Path:    path,
+			mock:    spec.Mock,
+		}
+		route.segments, route.literals, err = parsePattern(urlPath)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s: skipped: %v", path, err))
+			continue
+		}
+
+		if spec.Mock != nil {
+			route.Source = "mock"
+		} else {
+			if runs == nil {
+				if runs, err = history.LatestRuns(servableRun); err != nil {
+					return nil, nil, err
+				}
+			}
+			record := runs[filepath.Clean(path)]
+			if record == nil {
+				warnings = append(warnings, fmt.Sprintf("%s: skipped: no mock block and no successful run in history", path))
+				continue
+			}
+			if record.ResponseBodyTruncated || record.ResponseBodyEncoded {
+				warnings = append(warnings, fmt.Sprintf("%s: run %s has an incomplete body in history; add a mock block to serve the full response", path, record.RunID))
+			}
+			route.record = record
+			route.Source = record.RunID
+		}
+
+		key := route.Method + " " + route.Pattern
+		if previous, ok := seen[key]; ok {
+			warnings = append(warnings, fmt.Sprintf("%s: skipped: %s is already served by %s", path, key, previous.Path))
+			continue
+		}
+		seen[key] = route
+		server.routes = append(server.routes, route)
+	}
+
+	// More literal segments win, so /users/me beats /users/:id.
+	slices.SortStableFunc(server.routes, func(a, b *Route) int {
+		if a.literals != b.literals {
+			return b.literals - a.literals
+		}
+		return strings.Compare(a.Pattern, b.Pattern)
+	})
+	return server, warnings, nil
+}
+
+// Routes lists the served routes in match order.
+func (s *Server) Routes() []*Route {
+	return s.routes
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	started := time.Now()
+	path := normalizePath(r.URL.Path)
+
+	// Browsers calling from a dev server on another port need CORS.
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+		w.Header().Set("Access-Control-Allow-Methods", r.Header.Get("Access-Control-Request-Method"))
+		if headers := r.Header.Get("Access-Control-Request-Headers"); headers != "" {
+			w.Header().Set("Access-Control-Allow-Headers", headers)
+		}
+		w.WriteHeader(http.StatusNoContent)
+		s.logf("%s %s -> preflight 204", r.Method, path)
+		return
+	}
+
+	route, params, allowed := s.match(r.Method, path)
+	if route == nil {
+		status := http.StatusNotFound
+		message := fmt.Sprintf("no mock route for %s %s", r.Method, path)
+		if len(allowed) > 0 {
+			status = http.StatusMethodNotAllowed
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+		}
+		writeJSONError(w, status, message)
+		s.logf("%s %s -> %d", r.Method, path, status)
+		return
+	}
+
+	if delay := s.latency(route); delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	status := 0
+	source := route.Source
+	if rate, errorStatus := s.errorRate(route); rate > 0 && s.random() < rate {
+		status = errorStatus
+		source = "simulated error"
+		writeJSONError(w, status, "simulated failure")
+	} else if route.mock != nil {
+		var err error
+		status, err = s.writeMock(w, route, params)
+		if err != nil {
+			status = http.StatusInternalServerError
+			writeJSONError(w, status, fmt.Sprintf("mock %s: %v", route.Name, err))
+		}
+	} else {
+		status = writeRecord(w, route.record)
+	}
+
+	s.logf("%s %s -> %s %d (%s, %dms)", r.Method, path, route.Name, status, source, time.Since(started).Milliseconds())
+}
+
+// match finds the route for method and path. When only the method differs
+// it returns the methods that would have matched.
+func (s *Server) match(method, path string) (*Route, map[string]string, []string) {
+	var allowed []string
+	for _, try := range []string{method, http.MethodGet} {
+		for _, route := range s.routes {
+			params, ok := match(route.segments, path)
+			if !ok {
+				continue
+			}
+			if route.Method == try {
+				return route, params, nil
+			}
+			if try == method && !slices.Contains(allowed, route.Method) {
+				allowed = append(allowed, route.Method)
+			}
+		}
+		// HEAD falls back to GET routes; net/http drops the body.
+		if method != http.MethodHead {
+			break
+		}
+	}
+	return nil, nil, allowed
+}
+
+func (s *Server) latency(route *Route) time.Duration {
+	low, high := s.opts.Latency, s.opts.LatencyMax
+	if m := route.mock; m != nil && (m.LatencyMS > 0 || m.LatencyMaxMS > 0) {
+		low = time.Duration(m.LatencyMS) * time.Millisecond
+		high = time.Duration(m.LatencyMaxMS) * time.Millisecond
+	}
+	if high > low {
+		return low + time.Duration(s.random()*float64(high-low))
+	}
+	return low
+}
+
+func (s *Server) errorRate(route *Route) (float64, int) {
+	rate, status := s.opts.ErrorRate, s.opts.ErrorStatus
+	if m := route.mock; m != nil {
+		if m.ErrorRate > 0 {
+			rate = m.ErrorRate
+		}
+		if m.ErrorStatus != 0 {
+			status = m.ErrorStatus
+		}
+	}
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+	return rate, status
+}
+
+func (s *Server) writeMock(w http.ResponseWriter, route *Route, params map[string]string) (int, error) {
+	m := route.mock
+	vars := maps.Clone(s.opts.Vars)
+	if vars == nil {
+		vars = make(map[string]string)
+	}
+	maps.Copy(vars, params)
+
+	var body []byte
+	contentType := ""
+	switch {
+	case m.JSON != nil:
+		// Round-trip through JSON for a copy the interpolation can change.
+		encoded, err := json.Marshal(m.JSON)
+		if err != nil {
+			return 0, err
+		}
+		var value any
+		if err := json.Unmarshal(encoded, &value); err != nil {
+			return 0, err
+		}
+		if err := config.InterpolateAny(&value, vars); err != nil {
+			return 0, err
+		}
+		if body, err = json.Marshal(value); err != nil {
+			return 0, err
+		}
+		contentType = "application/json"
+	case m.BodyPath != "":
+		path := m.BodyPath
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(route.Path), path)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return 0, fmt.Errorf("read body_path: %w", err)
+		}
+		body = data
+	case m.Body != "":
+		text, err := config.InterpolateString(m.Body, vars)
+		if err != nil {
+			return 0, err
+		}
+		body = []byte(text)
+	}
+
+	headers := make(map[string]string, len(m.Headers))
+	for key, value := range m.Headers {
+		text, err := config.InterpolateString(fmt.Sprint(value), vars)
+		if err != nil {
+			return 0, fmt.Errorf("headers.%s: %w", key, err)
+		}
+		headers[key] = text
+	}
+
+	for key, value := range headers {
+		w.Header().Set(key, value)
+	}
+	if contentType != "" && w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	status := m.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+	return status, nil
+}
+
+// skippedHeaders describe the original transfer rather than the body that
+// history kept.
+var skippedHeaders = []string{"Content-Length", "Content-Encoding", "Transfer-Encoding", "Connection", "Keep-Alive", "Date"}
+
+func writeRecord(w http.ResponseWriter, record *history.RunRecord) int {
+	for key, value := range record.ResponseHeaders {
+		if slices.ContainsFunc(skippedHeaders, func(skip string) bool { return strings.EqualFold(skip, key) }) {
+			continue
+		}
+		w.Header().Set(key, value)
+	}
+	w.WriteHeader(record.Status)
+	_, _ = io.WriteString(w, record.ResponseBody)
+	return record.Status
+}
+
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	payload, _ := json.Marshal(map[string]string{"error": message})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(append(payload, '\n'))
+}
+
+func (s *Server) logf(format string, args ...any) {
+	if s.opts.Log == nil {
+		return
+	}
+	s.logMu.Lock()
+	defer s.logMu.Unlock()
+	fmt.Fprintf(s.opts.Log, format+"\n", args...)
+}
@@ -0,0 +1,258 @@
+package mock
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaykbpark/wirepad/internal/history"
+)
+
+func writeSpec(t *testing.T, path, content string) string {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func get(t *testing.T, client *http.Client, method, url string) (*http.Response, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, url, err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp, string(body)
+}
+
+func TestServer_RoutesMockBlocksAndHistory(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	files := []string{
+		writeSpec(t, "requests/users/get.req.yaml", `
+version: 1
+kind: http
+name: users.get
+request:
+  method: GET
+  url: "{{base_url}}/users/{{id}}?expand=true"
+mock:
+  status: 200
+  headers:
+    X-Region: "{{region}}"
+  json:
+    id: "{{id}}"
+    name: Ada
+`),
+		writeSpec(t, "requests/users/me.req.yaml", `
+version: 1
+kind: http
+name: users.me
+request:
+  method: GET
+  url: "https://api.example.com/users/me"
+mock:
+  body: "it's me"
+  latency_ms: 50
+`),
+		writeSpec(t, "requests/users/create.req.yaml", `
+version: 1
+kind: http
+name: users.create
+request:
+  method: POST
+  url: "{{base_url}}/users"
+`),
+		writeSpec(t, "requests/files/get.req.yaml", `
+version: 1
+kind: http
+name: files.get
+request:
+  method: GET
+  url: "{{base_url}}/files/{{name}}.json"
+mock:
+  json: {file: "{{name}}"}
+  error_rate: 1
+  error_status: 503
+`),
+		writeSpec(t, "requests/never.req.yaml", `
+version: 1
+kind: http
+name: never
+request:
+  method: DELETE
+  url: "{{base_url}}/never"
+`),
+	}
+
+	for _, record := range []history.RunRecord{
+		{RunID: "2026-03-01T10-00-00Z_aaaa", RequestPath: "requests/users/create.req.yaml", StartedAt: "2026-03-01T10:00:00Z", OK: true, Status: 201,
+			ResponseHeaders: map[string]string{"Content-Type": "application/json", "Content-Length": "99"}, ResponseBody: `{"id":"new"}`},
+		{RunID: "2026-03-01T11-00-00Z_bbbb", RequestPath: "requests/users/create.req.yaml", StartedAt: "2026-03-01T11:00:00Z", OK: false, Status: 500},
+		{RunID: "2026-03-01T09-00-00Z_cccc", RequestPath: "requests/users/create.req.yaml", StartedAt: "2026-03-01T09:00:00Z", OK: true, Status: 201, ResponseBody: `{"id":"old"}`},
+	} {
+		if _, err := history.SaveRun(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	server, warnings, err := NewServer(files, Options{Vars: map[string]string{"region": "eu"}})
+	if err != nil {
+		t.Fatalf("NewServer returned error: %v", err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "never.req.yaml: skipped: no mock block") {
+		t.Fatalf("unexpected warnings %v", warnings)
+	}
+	var patterns []string
+	for _, route := range server.Routes() {
+		patterns = append(patterns, route.Method+" "+route.Pattern+" "+route.Source)
+	}
+	if got := strings.Join(patterns, "; "); got != "GET /users/me mock; GET /files/:name.json mock; POST /users 2026-03-01T10-00-00Z_aaaa; GET /users/:id mock" {
+		t.Fatalf("unexpected routes %s", got)
+	}
+
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	client := ts.Client()
+
+	resp, body := get(t, client, http.MethodGet, ts.URL+"/users/42/")
+	if resp.StatusCode != 200 || body != `{"id":"42","name":"Ada"}` || resp.Header.Get("X-Region") != "eu" || resp.Header.Get("Content-Type") != "application/json" {
+		t.Fatalf("unexpected mock response %d %q %v", resp.StatusCode, body, resp.Header)
+	}
+
+	started := time.Now()
+	resp, body = get(t, client, http.MethodGet, ts.URL+"/users/me")
+	if body != "it's me" || time.Since(started) < 50*time.Millisecond {
+		t.Fatalf("expected literal route with latency, got %q after %s", body, time.Since(started))
+	}
+
+	resp, body = get(t, client, http.MethodPost, ts.URL+"/users")
+	if resp.StatusCode != 201 || body != `{"id":"new"}` || resp.Header.Get("Content-Length") == "99" {
+		t.Fatalf("expected latest successful run, got %d %q %v", resp.StatusCode, body, resp.Header)
+	}
+
+	resp, body = get(t, client, http.MethodGet, ts.URL+"/files/report.json")
+	if resp.StatusCode != 503 || !strings.Contains(body, "simulated failure") {
+		t.Fatalf("expected simulated error, got %d %q", resp.StatusCode, body)
+	}
+
+	resp, _ = get(t, client, http.MethodDelete, ts.URL+"/users/42")
+	if resp.StatusCode != http.StatusMethodNotAllowed || resp.Header.Get("Allow") != "GET" {
+		t.Fatalf("expected 405 with Allow, got %d %v", resp.StatusCode, resp.Header)
+	}
+	resp, _ = get(t, client, http.MethodGet, ts.URL+"/orders")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", resp.StatusCode)
+	}
+	resp, body = get(t, client, http.MethodHead, ts.URL+"/users/me")
+	if resp.StatusCode != 200 || body != "" {
+		t.Fatalf("expected HEAD to use the GET route, got %d %q", resp.StatusCode, body)
+	}
+}
+
+func TestServer_OptionsLatencyErrorRateAndCORS(t *testing.T) {
+	t.Chdir(t.TempDir())
+	files := []string{writeSpec(t, "ping.req.yaml", `
+version: 1
+kind: http
+name: ping
+request:
+  method: GET
+  url: "{{base_url}}/ping"
+mock:
+  body: pong
+`)}
+
+	server, _, err := NewServer(files, Options{Latency: 10 * time.Millisecond, LatencyMax: 30 * time.Millisecond, ErrorRate: 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// One latency draw, then a latency and an error draw per request.
+	draws := []float64{0.9, 0.5, 0.9, 0.5, 0.1}
+	server.random = func() float64 {
+		value := draws[0]
+		draws = draws[1:]
+		return value
+	}
+	if delay := server.latency(server.routes[0]); delay != 28*time.Millisecond {
+		t.Fatalf("expected 28ms latency, got %s", delay)
+	}
+
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	resp, body := get(t, ts.Client(), http.MethodGet, ts.URL+"/ping")
+	if resp.StatusCode != 200 || body != "pong" || resp.Header.Get("Access-Control-Allow-Origin") != "*" {
+		t.Fatalf("unexpected response %d %q", resp.StatusCode, body)
+	}
+	resp, _ = get(t, ts.Client(), http.MethodGet, ts.URL+"/ping")
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("expected simulated 500, got %d", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest(http.MethodOptions, ts.URL+"/ping", nil)
+	req.Header.Set("Origin", "http://localhost:3000")
+	req.Header.Set("Access-Control-Request-Method", "GET")
+	req.Header.Set("Access-Control-Request-Headers", "authorization")
+	preflight, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	preflight.Body.Close()
+	if preflight.StatusCode != http.StatusNoContent || preflight.Header.Get("Access-Control-Allow-Headers") != "authorization" {
+		t.Fatalf("unexpected preflight %d %v", preflight.StatusCode, preflight.Header)
+	}
+}
+
+func TestServer_SkipsRoutesWithRepeatedParams(t *testing.T) {
+	t.Chdir(t.TempDir())
+	files := []string{
+		writeSpec(t, "repeated.req.yaml", `
+version: 1
+kind: http
+name: repeated
+request:
+  method: GET
+  url: "{{base_url}}/a/{{id}}/b/{{id}}"
+mock:
+  body: ambiguous
+`),
+		writeSpec(t, "in-segment.req.yaml", `
+version: 1
+kind: http
+name: in-segment
+request:
+  method: GET
+  url: "{{base_url}}/files/{{name}}.{{name}}"
+mock:
+  body: ambiguous
+`),
+	}
+
+	server, warnings, err := NewServer(files, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(server.routes) != 0 || len(warnings) != 2 {
+		t.Fatalf("expected both routes to be skipped, got routes %v and warnings %v", server.routes, warnings)
+	}
+	for i, want := range []string{"repeated.req.yaml: skipped: path parameter {{id}} appears more than once", "in-segment.req.yaml: skipped: path parameter {{name}} appears more than once"} {
+		if warnings[i] != want {
+			t.Fatalf("warning %d = %q, want %q", i, warnings[i], want)
+		}
+	}
+}
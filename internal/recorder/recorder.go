@@ -0,0 +1,322 @@
+// Package recorder proxies traffic to an upstream API and writes each new
+// route it sees as a request spec, with a history run per exchange.
+package recorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/jaykbpark/wirepad/internal/config"
+	"github.com/jaykbpark/wirepad/internal/history"
+	"github.com/jaykbpark/wirepad/internal/requestspec"
+)
+
+type Options struct {
+	Upstream *url.URL
+	// Dir receives the generated specs; requests/ when empty.
+	Dir string
+	// MaxBodyBytes caps the response body kept in history; 0 keeps it whole.
+	MaxBodyBytes int64
+	// Log receives one line per exchange; nil discards.
+	Log io.Writer
+}
+
+// Recorder is an http.Handler forwarding every request to Options.Upstream.
+type Recorder struct {
+	opts  Options
+	proxy *httputil.ReverseProxy
+
+	mu sync.Mutex
+	// routes maps a route key to the spec that describes it.
+	routes map[string]route
+}
+
+type route struct {
+	path string
+	name string
+}
+
+// New indexes the specs already under opts.Dir so routes they cover are not
+// written again.
+func New(opts Options) (*Recorder, error) {
+	if opts.Upstream == nil || opts.Upstream.Scheme == "" || opts.Upstream.Host == "" {
+		return nil, fmt.Errorf("upstream must be an absolute URL")
+	}
+	if opts.Dir == "" {
+		opts.Dir = requestspec.RequestsDir
+	}
+	if opts.Log == nil {
+		opts.Log = io.Discard
+	}
+
+	r := &Recorder{opts: opts, routes: make(map[string]route)}
+	if err := r.index(); err != nil {
+		return nil, err
+	}
+
+	upstream := opts.Upstream
+	r.proxy = &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(upstream)
+			pr.SetXForwarded()
+			// Ask for an identity body so the recorded response is readable.
+			pr.Out.Header.Del("Accept-Encoding")
+		},
+		ErrorHandler: func(w http.ResponseWriter, req *http.Request, err error) {
+			if capture, ok := w.(*captureWriter); ok {
+				capture.failed = true
+			}
+			fmt.Fprintf(r.opts.Log, "  %-6s %s: upstream error: %v\n", req.Method, req.URL.Path, err)
+			http.Error(w, "wirepad record: upstream error: "+err.Error(), http.StatusBadGateway)
+		},
+	}
+	return r, nil
+}
+
+func (r *Recorder) index() error {
+	files, err := requestspec.FindFiles(r.opts.Dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("index %s: %w", r.opts.Dir, err)
+	}
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		spec, _, err := requestspec.Parse(data)
+		if err != nil || spec.Request == nil {
+			continue
+		}
+		key := routeKey(spec.Request.Method, requestspec.RoutePath(spec.Request.URL))
+		if _, ok := r.routes[key]; !ok {
+			r.routes[key] = route{path: path, name: spec.Name}
+		}
+	}
+	return nil
+}
+
+func (r *Recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, "read request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	started := time.Now()
+	capture := &captureWriter{ResponseWriter: w, limit: r.opts.MaxBodyBytes}
+	r.proxy.ServeHTTP(capture, req)
+	if capture.failed || capture.status == 0 {
+		return
+	}
+
+	spec, created, err := r.save(req, body, capture.status)
+	if err != nil {
+		fmt.Fprintf(r.opts.Log, "  %-6s %s -> %d  (not recorded: %v)\n", req.Method, req.URL.Path, capture.status, err)
+		return
+	}
+
+	record := history.RunRecord{
+		RunID:           history.NewRunID(started.UTC()),
+		RequestName:     spec.name,
+		RequestPath:     spec.path,
+		StartedAt:       started.UTC().Format(time.RFC3339),
+		DurationMS:      time.Since(started).Milliseconds(),
+		OK:              true,
+		Status:          capture.status,
+		Protocol:        req.Proto,
+		ResponseHeaders: config.RedactHeaders(flattenHeaders(capture.Header())),
+	}
+	// Credentials in the response only need hiding in this record, so they
+	// are not added to the process-wide registry of a long-running proxy.
+	record.ResponseBody = config.RedactValues(config.RedactString(capture.body.String()), responseSecrets(capture.body.Bytes()))
+	if capture.truncated {
+		record.ResponseBodyBytes = capture.size
+		record.ResponseBodyTruncated = true
+	}
+	if _, err := history.SaveRun(record); err != nil {
+		fmt.Fprintf(r.opts.Log, "  %-6s %s -> %d  (history: %v)\n", req.Method, req.URL.Path, capture.status, err)
+		return
+	}
+
+	note := ""
+	if created {
+		note = "  new"
+	}
+	fmt.Fprintf(r.opts.Log, "  %-6s %s -> %d  %s%s\n", req.Method, req.URL.Path, capture.status, filepath.ToSlash(spec.path), note)
+}
+
+// save returns the spec for the request's route, writing one when the route
+// has not been seen.
+func (r *Recorder) save(req *http.Request, body []byte, status int) (route, bool, error) {
+	d, err := buildDraft(req, body, status, r.opts.Upstream)
+	if err != nil {
+		return route{}, false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.routes[d.key]; ok {
+		return existing, false, nil
+	}
+
+	path := specPath(r.opts.Dir, d, 1)
+	for i := 2; fileExists(path); i++ {
+		path = specPath(r.opts.Dir, d, i)
+		d.rename(fmt.Sprintf("%s-%d", strings.Join(append(d.dir, d.action), "."), i))
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return route{}, false, fmt.Errorf("create spec directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(d.yaml), 0o644); err != nil {
+		return route{}, false, fmt.Errorf("write spec: %w", err)
+	}
+	r.routes[d.key] = route{path: path, name: d.name}
+	return r.routes[d.key], true, nil
+}
+
+// credentialFields are the JSON keys, in snake_case, whose string values are
+// credentials when an upstream response carries them.
+var credentialFields = map[string]bool{
+	"access_token":  true,
+	"refresh_token": true,
+	"id_token":      true,
+	"session_token": true,
+	"auth_token":    true,
+	"token":         true,
+	"api_key":       true,
+	"apikey":        true,
+	"client_secret": true,
+	"secret":        true,
+	"password":      true,
+	"private_key":   true,
+}
+
+// minCredentialLength is the shortest response value treated as a
+// credential. Shorter values are flags and types such as "yes" or "Bearer".
+const minCredentialLength = 8
+
+// responseSecrets returns the credentials in a JSON response body, such as
+// the token returned by a login.
+func responseSecrets(body []byte) []string {
+	var value any
+	if json.Unmarshal(body, &value) != nil {
+		return nil
+	}
+	var secrets []string
+	var walk func(any)
+	walk = func(value any) {
+		switch v := value.(type) {
+		case map[string]any:
+			for key, item := range v {
+				if s, ok := item.(string); ok && credentialFields[snakeKey(key)] {
+					if len(s) >= minCredentialLength {
+						secrets = append(secrets, s)
+					}
+					continue
+				}
+				walk(item)
+			}
+		case []any:
+			for _, item := range v {
+				walk(item)
+			}
+		}
+	}
+	walk(value)
+	return secrets
+}
+
+// snakeKey lowercases accessToken, access-token and ACCESS_TOKEN alike to
+// access_token.
+func snakeKey(key string) string {
+	var b strings.Builder
+	prev := '_'
+	for _, r := range key {
+		next := r
+		switch {
+		case r == '-':
+			next = '_'
+		case unicode.IsUpper(r):
+			if prev != '_' && prev != '-' && !unicode.IsUpper(prev) {
+				b.WriteByte('_')
+			}
+			next = unicode.ToLower(r)
+		}
+		b.WriteRune(next)
+		prev = r
+	}
+	return b.String()
+}
+
+// captureWriter passes a response through while keeping its status and the
+// first limit bytes of its body, or all of it when limit is 0.
+type captureWriter struct {
+	http.ResponseWriter
+	limit     int64
+	failed    bool
+	status    int
+	body      bytes.Buffer
+	size      int64
+	truncated bool
+}
+
+func (c *captureWriter) WriteHeader(status int) {
+	if c.status == 0 {
+		c.status = status
+	}
+	c.ResponseWriter.WriteHeader(status)
+}
+
+func (c *captureWriter) Write(p []byte) (int, error) {
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+	c.size += int64(len(p))
+	switch room := c.limit - int64(c.body.Len()); {
+	case c.limit <= 0:
+		c.body.Write(p)
+	case room > 0:
+		c.body.Write(p[:min(int64(len(p)), room)])
+	}
+	if c.limit > 0 && c.size > c.limit {
+		c.truncated = true
+	}
+	return c.ResponseWriter.Write(p)
+}
+
+func (c *captureWriter) Flush() {
+	if flusher, ok := c.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func flattenHeaders(headers http.Header) map[string]string {
+	out := make(map[string]string, len(headers))
+	for key, values := range headers {
+		if len(values) > 0 {
+			out[strings.ToLower(key)] = values[0]
+		}
+	}
+	return out
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
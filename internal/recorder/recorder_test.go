@@ -0,0 +1,244 @@
+package recorder
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/jaykbpark/wirepad/internal/config"
+	"github.com/jaykbpark/wirepad/internal/history"
+	"github.com/jaykbpark/wirepad/internal/requestspec"
+)
+
+func newRecorder(t *testing.T) (*httptest.Server, *strings.Builder) {
+	t.Helper()
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/login":
+			_, _ = io.WriteString(w, `{"access_token":"tok-from-login"}`)
+		case r.Method == http.MethodPost:
+			w.WriteHeader(http.StatusCreated)
+			_, _ = io.Copy(w, r.Body)
+		default:
+			_, _ = io.WriteString(w, `{"path":"`+r.URL.Path+`"}`)
+		}
+	}))
+	t.Cleanup(upstream.Close)
+
+	target, _ := url.Parse(upstream.URL)
+	log := &strings.Builder{}
+	rec, err := New(Options{Upstream: target, Log: log})
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxy := httptest.NewServer(rec)
+	t.Cleanup(proxy.Close)
+	return proxy, log
+}
+
+func loadSpec(t *testing.T, path string) *requestspec.Spec {
+	t.Helper()
+	result, err := requestspec.LoadFile(path, requestspec.LoadOptions{Strict: true})
+	if err != nil {
+		t.Fatalf("load %s: %v", path, err)
+	}
+	return result.Spec
+}
+
+func send(t *testing.T, method, url, contentType, body string, header http.Header) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return resp
+}
+
+func TestRecorder_WritesParameterizedSpecsOncePerRoute(t *testing.T) {
+	t.Chdir(t.TempDir())
+	proxy, log := newRecorder(t)
+
+	auth := http.Header{"Authorization": {"Bearer s3cr3t-token"}}
+	send(t, http.MethodGet, proxy.URL+"/users/42?expand=true", "", "", auth)
+	send(t, http.MethodGet, proxy.URL+"/users/7?expand=false", "", "", auth)
+	send(t, http.MethodGet, proxy.URL+"/users", "", "", nil)
+	resp := send(t, http.MethodPost, proxy.URL+"/users", "application/json", `{"name":"Ada","password":"hunter22","tags":["a","b"]}`, nil)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected proxied 201, got %d", resp.StatusCode)
+	}
+	send(t, http.MethodPost, proxy.URL+"/login", "application/x-www-form-urlencoded", "user=ada&password=hunter22", nil)
+
+	for _, path := range []string{"requests/users/get.req.yaml", "requests/users/list.req.yaml", "requests/users/create.req.yaml", "requests/login/create.req.yaml"} {
+		loadSpec(t, path)
+	}
+	files, _ := requestspec.FindFiles("requests")
+	if len(files) != 4 {
+		t.Fatalf("expected 4 specs, got %v", files)
+	}
+
+	spec := loadSpec(t, "requests/users/get.req.yaml")
+	if spec.Name != "users.get" || spec.Request.URL != "{{base_url}}/users/{{user_id}}" {
+		t.Fatalf("unexpected spec: %+v %+v", spec, spec.Request)
+	}
+	if spec.Request.Headers["Authorization"] != "Bearer {{token}}" || spec.Request.Query["expand"] != "true" {
+		t.Fatalf("unexpected request: %+v", spec.Request)
+	}
+	if spec.Expect["status"] != 200 {
+		t.Fatalf("expected status expectation, got %+v", spec.Expect)
+	}
+
+	create := loadSpec(t, "requests/users/create.req.yaml")
+	body := create.Request.Body
+	if body.Mode != "json" {
+		t.Fatalf("expected json body, got %+v", body)
+	}
+	payload, _ := json.Marshal(body.JSON)
+	if string(payload) != `{"name":"Ada","password":"{{password}}","tags":["a","b"]}` {
+		t.Fatalf("unexpected json body: %s", payload)
+	}
+
+	login := loadSpec(t, "requests/login/create.req.yaml")
+	if login.Request.Body.Mode != "form" || login.Request.Body.Form["password"] != "{{password}}" {
+		t.Fatalf("unexpected form body: %+v", login.Request.Body)
+	}
+
+	runs, _ := filepath.Glob(".wirepad/history/runs/*.json")
+	if len(runs) != 5 {
+		t.Fatalf("expected a run per exchange, got %d", len(runs))
+	}
+	var all strings.Builder
+	for _, path := range append(files, runs...) {
+		data, _ := os.ReadFile(path)
+		all.Write(data)
+	}
+	for _, secret := range []string{"s3cr3t-token", "hunter22", "tok-from-login"} {
+		if strings.Contains(all.String(), secret) {
+			t.Fatalf("secret %q leaked into recorded files", secret)
+		}
+	}
+	if config.RedactString("tok-from-login") != "tok-from-login" {
+		t.Fatal("expected response credentials to be redacted per exchange, not registered process-wide")
+	}
+
+	latest, err := history.LatestRun("requests/users/get.req.yaml", nil)
+	if err != nil || latest == nil || latest.RequestName != "users.get" || latest.Status != 200 {
+		t.Fatalf("expected history for users.get, got %+v (%v)", latest, err)
+	}
+	if strings.Count(log.String(), "  new") != 4 {
+		t.Fatalf("expected four new specs in log:\n%s", log.String())
+	}
+}
+
+func TestRecorder_SkipsRoutesAlreadyCovered(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := os.MkdirAll("requests/accounts", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	existing := "version: 1\nkind: http\nname: accounts.show\nrequest:\n  method: GET\n  url: \"{{base_url}}/accounts/{{account}}\"\n"
+	if err := os.WriteFile("requests/accounts/show.req.yaml", []byte(existing), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("requests/accounts/list.req.yaml", []byte("taken"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	proxy, _ := newRecorder(t)
+
+	send(t, http.MethodGet, proxy.URL+"/accounts/9f8e7d6c-5b4a-3c2d-1e0f-a9b8c7d6e5f4", "", "", nil)
+	send(t, http.MethodGet, proxy.URL+"/accounts", "", "", nil)
+
+	files, _ := requestspec.FindFiles("requests")
+	if len(files) != 3 {
+		t.Fatalf("expected one new spec, got %v", files)
+	}
+	if spec := loadSpec(t, "requests/accounts/list-2.req.yaml"); spec.Name != "accounts.list-2" {
+		t.Fatalf("expected accounts.list-2, got %q", spec.Name)
+	}
+	latest, _ := history.LatestRun("requests/accounts/show.req.yaml", nil)
+	if latest == nil || latest.RequestName != "accounts.show" {
+		t.Fatalf("expected run against the existing spec, got %+v", latest)
+	}
+}
+
+func TestBuildDraft_FallsBackToRawForUnsupportedJSON(t *testing.T) {
+	upstream, _ := url.Parse("https://api.example.com/")
+	build := func(body string) (*draft, *requestspec.Spec) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPut, "/items/0123456789abcdef0123", strings.NewReader(""))
+		req.Header.Set("Content-Type", "application/json")
+		d, err := buildDraft(req, []byte(body), 200, upstream)
+		if err != nil {
+			t.Fatal(err)
+		}
+		spec, _, err := requestspec.Parse([]byte(d.yaml))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d, spec
+	}
+
+	d, spec := build(`{"price":1.5,"total":1e400}`)
+	if spec.Name != "items.update" || spec.Request.URL != "{{base_url}}/items/{{item_id}}" {
+		t.Fatalf("unexpected draft: %s", d.yaml)
+	}
+	if spec.Request.Body.Mode != "raw" || spec.Request.Body.Raw != `{"price":1.5,"total":1e400}` {
+		t.Fatalf("expected raw fallback, got %+v", spec.Request.Body)
+	}
+	if !strings.Contains(d.yaml, "#   base_url=https://api.example.com\n#   item_id=0123456789abcdef0123\n") {
+		t.Fatalf("expected example values in header:\n%s", d.yaml)
+	}
+
+	_, spec = build(`{"price":1.5,"meta":{}}`)
+	if spec.Request.Body.Mode != "json" || !reflect.DeepEqual(spec.Request.Body.JSON, map[string]any{"price": 1.5, "meta": map[string]any{}}) {
+		t.Fatalf("expected floats and empty objects to stay json, got %+v", spec.Request.Body)
+	}
+}
+
+func TestResponseSecrets_OnlyCredentialFields(t *testing.T) {
+	body := `{
+  "accessToken": "eyJhbGciOi.payload.sig",
+  "token_type": "Bearer",
+  "has_password": "yes",
+  "password_hint": "first pet's name",
+  "token": "abc",
+  "expires_in": 3600,
+  "data": [{"refresh-token": "r-1234567890"}, {"secret_question": "favourite colour?"}]
+}`
+	got := responseSecrets([]byte(body))
+	sort.Strings(got)
+	want := []string{"eyJhbGciOi.payload.sig", "r-1234567890"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("responseSecrets = %q, want %q", got, want)
+	}
+	if got := responseSecrets([]byte("not json")); got != nil {
+		t.Fatalf("expected no secrets from a non-JSON body, got %q", got)
+	}
+}
+
+func TestSingular(t *testing.T) {
+	for word, want := range map[string]string{"users": "user", "categories": "category", "addresses": "address", "boxes": "box", "access": "access", "v1": "v1"} {
+		if got := singular(word); got != want {
+			t.Fatalf("singular(%q) = %q, want %q", word, got, want)
+		}
+	}
+}
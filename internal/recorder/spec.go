@@ -0,0 +1,373 @@
+package recorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jaykbpark/wirepad/internal/config"
+	"github.com/jaykbpark/wirepad/internal/requestspec"
+)
+
+// idSegment matches path segments that identify a record rather than name a
+// resource: integers, UUIDs and long hex IDs.
+var idSegment = regexp.MustCompile(`^(\d+|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}|[0-9a-fA-F]{16,})$`)
+
+var nonIdent = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// droppedHeaders are transport or client details, not part of the request a
+// spec should describe. Cookie is dropped as a secret.
+var droppedHeaders = []string{
+	"Accept-Encoding", "Connection", "Content-Length", "Cookie", "Forwarded", "Host",
+	"Keep-Alive", "Proxy-Authorization", "Proxy-Connection", "Te", "Trailer",
+	"Transfer-Encoding", "Upgrade", "User-Agent", "Via",
+}
+
+// draft is a request spec generated from one exchange.
+type draft struct {
+	// key identifies the route: method and path with parameters as "*".
+	key    string
+	name   string
+	dir    []string
+	action string
+	yaml   string
+}
+
+// params names the variables a draft introduces, keeping names unique.
+type params struct {
+	names    map[string]bool
+	examples mapping
+}
+
+func (p *params) add(base string, example string, secret bool) string {
+	name := identifier(base)
+	if name == "" {
+		name = "value"
+	}
+	for i := 2; p.names[name]; i++ {
+		name = fmt.Sprintf("%s_%d", identifier(base), i)
+	}
+	p.names[name] = true
+	if secret {
+		config.MarkSecret(example)
+	} else {
+		p.examples = append(p.examples, field{key: name, value: example})
+	}
+	return "{{" + name + "}}"
+}
+
+// buildDraft turns a request into a spec with IDs in the path, secret
+// headers, query values and body fields replaced by {{var}} placeholders.
+func buildDraft(req *http.Request, body []byte, status int, upstream *url.URL) (*draft, error) {
+	vars := &params{names: map[string]bool{"base_url": true}}
+	vars.examples = mapping{{key: "base_url", value: strings.TrimSuffix(upstream.String(), "/")}}
+
+	var segments, literals []string
+	for _, part := range strings.Split(strings.Trim(req.URL.Path, "/"), "/") {
+		if part == "" {
+			continue
+		}
+		if idSegment.MatchString(part) {
+			base := "id"
+			if len(literals) > 0 {
+				base = singular(literals[len(literals)-1]) + "_id"
+			}
+			segments = append(segments, vars.add(base, part, false))
+			continue
+		}
+		segments = append(segments, part)
+		literals = append(literals, part)
+	}
+	urlPath := "/" + strings.Join(segments, "/")
+
+	d := &draft{key: routeKey(req.Method, urlPath)}
+	d.dir, d.action = specLocation(req.Method, segments, literals)
+	d.name = strings.Join(append(slices.Clone(d.dir), d.action), ".")
+
+	request := mapping{
+		{key: "method", value: req.Method},
+		{key: "url", value: "{{base_url}}" + strings.TrimSuffix(urlPath, "/")},
+	}
+	if query := recordQuery(req.URL.Query(), vars); len(query) > 0 {
+		request = append(request, field{key: "query", value: query})
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	bodyField, keepContentType := recordBody(mediaType, req.Header.Get("Content-Type"), body, vars)
+	if headers := recordHeaders(req.Header, keepContentType, vars); len(headers) > 0 {
+		request = append(request, field{key: "headers", value: headers})
+	}
+	if bodyField != nil {
+		request = append(request, field{key: "body", value: bodyField})
+	}
+
+	doc := mapping{
+		{key: "version", value: 1},
+		{key: "kind", value: "http"},
+		{key: "name", value: d.name},
+		{key: "tags", value: []any{"recorded"}},
+		{key: "request", value: request},
+		{key: "expect", value: mapping{{key: "status", value: status}}},
+	}
+	d.yaml = renderDraft(doc, vars.examples)
+
+	if ok, err := roundTrips(d.yaml, bodyField); err != nil {
+		return nil, err
+	} else if !ok {
+		// Some JSON does not survive as YAML (numbers out of float range,
+		// keys read as merges), so keep its text instead.
+		raw := mapping{
+			{key: "mode", value: "raw"},
+			{key: "content_type", value: req.Header.Get("Content-Type")},
+			{key: "raw", value: compactJSON(bodyField[1].value)},
+		}
+		for i := range request {
+			if request[i].key == "body" {
+				request[i].value = raw
+			}
+		}
+		d.yaml = renderDraft(doc, vars.examples)
+		if _, err := roundTrips(d.yaml, nil); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// rename changes the spec name written in the draft.
+func (d *draft) rename(name string) {
+	d.yaml = strings.Replace(d.yaml, "\nname: "+strconv.Quote(d.name)+"\n", "\nname: "+strconv.Quote(name)+"\n", 1)
+	d.name = name
+}
+
+func renderDraft(doc mapping, examples mapping) string {
+	var b strings.Builder
+	b.WriteString("# Recorded by wirepad record. Example values:\n")
+	for _, example := range examples {
+		fmt.Fprintf(&b, "#   %s=%s\n", example.key, example.value)
+	}
+	b.WriteString(writeYAML(doc))
+	return b.String()
+}
+
+// roundTrips parses yaml back and reports whether a JSON body survived.
+func roundTrips(yaml string, body mapping) (bool, error) {
+	spec, _, err := requestspec.Parse([]byte(yaml))
+	if err != nil {
+		return false, fmt.Errorf("generated spec does not parse: %w", err)
+	}
+	if len(body) < 2 || body[0].value != "json" {
+		return true, nil
+	}
+	if spec.Request == nil || spec.Request.Body == nil {
+		return false, nil
+	}
+	want, err := json.Marshal(body[1].value)
+	if err != nil {
+		return false, err
+	}
+	got, err := json.Marshal(spec.Request.Body.JSON)
+	if err != nil {
+		return false, nil
+	}
+	var a, b any
+	_ = json.Unmarshal(want, &a)
+	_ = json.Unmarshal(got, &b)
+	return reflect.DeepEqual(a, b), nil
+}
+
+func recordQuery(values url.Values, vars *params) map[string]any {
+	out := make(map[string]any, len(values))
+	for key, list := range values {
+		if config.IsSensitiveKey(key) {
+			for _, value := range list {
+				config.MarkSecret(value)
+			}
+			out[key] = vars.add(key, list[0], true)
+			continue
+		}
+		if len(list) == 1 {
+			out[key] = list[0]
+			continue
+		}
+		items := make([]any, len(list))
+		for i, value := range list {
+			items[i] = value
+		}
+		out[key] = items
+	}
+	return out
+}
+
+func recordHeaders(header http.Header, keepContentType bool, vars *params) map[string]any {
+	out := make(map[string]any)
+	for key, values := range header {
+		canonical := http.CanonicalHeaderKey(key)
+		if slices.Contains(droppedHeaders, canonical) || strings.HasPrefix(canonical, "X-Forwarded-") {
+			continue
+		}
+		if canonical == "Content-Type" && !keepContentType {
+			continue
+		}
+		value := strings.Join(values, ", ")
+		if !config.IsSensitiveKey(canonical) {
+			out[canonical] = value
+			continue
+		}
+		config.MarkSecret(value)
+		scheme, credentials, found := strings.Cut(value, " ")
+		switch {
+		case found && strings.EqualFold(scheme, "bearer"):
+			config.MarkSecret(credentials)
+			out[canonical] = scheme + " " + vars.add("token", credentials, true)
+		case found && strings.EqualFold(scheme, "basic"):
+			config.MarkSecret(credentials)
+			out[canonical] = scheme + " " + vars.add("basic_credentials", credentials, true)
+		default:
+			out[canonical] = vars.add(canonical, value, true)
+		}
+	}
+	return out
+}
+
+// recordBody returns the spec body block and whether the Content-Type header
+// must be kept because the body mode does not set it.
+func recordBody(mediaType, contentType string, body []byte, vars *params) (mapping, bool) {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, true
+	}
+
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		var value any
+		if err := decoder.Decode(&value); err == nil {
+			return mapping{{key: "mode", value: "json"}, {key: "json", value: redactJSON(value, vars)}}, mediaType != "application/json"
+		}
+	case mediaType == "application/x-www-form-urlencoded":
+		if values, err := url.ParseQuery(string(body)); err == nil {
+			form := make(map[string]any, len(values))
+			for key, list := range values {
+				if config.IsSensitiveKey(key) {
+					form[key] = vars.add(key, list[0], true)
+					continue
+				}
+				form[key] = list[0]
+			}
+			return mapping{{key: "mode", value: "form"}, {key: "form", value: form}}, false
+		}
+	}
+
+	if !utf8.Valid(body) {
+		return nil, true
+	}
+	return mapping{
+		{key: "mode", value: "raw"},
+		{key: "content_type", value: contentType},
+		{key: "raw", value: config.RedactString(string(body))},
+	}, false
+}
+
+// redactJSON replaces the values of secret-looking keys with placeholders.
+func redactJSON(value any, vars *params) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			if s, ok := item.(string); ok && config.IsSensitiveKey(key) {
+				v[key] = vars.add(key, s, true)
+				continue
+			}
+			v[key] = redactJSON(item, vars)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = redactJSON(item, vars)
+		}
+	}
+	return value
+}
+
+func compactJSON(value any) string {
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(payload)
+}
+
+// specLocation names a spec after its literal path segments and an action
+// derived from the method: GET /users/{{user_id}} is users/get.
+func specLocation(method string, segments, literals []string) ([]string, string) {
+	dir := make([]string, 0, len(literals))
+	for _, literal := range literals {
+		if name := strings.Trim(nonIdent.ReplaceAllString(strings.ToLower(literal), "-"), "-"); name != "" {
+			dir = append(dir, name)
+		}
+	}
+	if len(dir) == 0 {
+		dir = []string{"root"}
+	}
+
+	endsWithParam := len(segments) > 0 && strings.HasPrefix(segments[len(segments)-1], "{{")
+	action := strings.ToLower(method)
+	switch method {
+	case http.MethodGet:
+		if !endsWithParam && strings.HasSuffix(dir[len(dir)-1], "s") {
+			action = "list"
+		}
+	case http.MethodPost:
+		action = "create"
+	case http.MethodPut, http.MethodPatch:
+		action = "update"
+	}
+	return dir, action
+}
+
+// routeKey identifies a route by method and path, with every parameterized
+// segment as "*".
+func routeKey(method, urlPath string) string {
+	parts := strings.Split(strings.Trim(urlPath, "/"), "/")
+	for i, part := range parts {
+		if strings.Contains(part, "{{") {
+			parts[i] = "*"
+		}
+	}
+	return strings.ToUpper(method) + " /" + strings.Join(parts, "/")
+}
+
+func singular(word string) string {
+	word = strings.ToLower(word)
+	switch {
+	case strings.HasSuffix(word, "ies"):
+		return strings.TrimSuffix(word, "ies") + "y"
+	case strings.HasSuffix(word, "sses"), strings.HasSuffix(word, "xes"):
+		return strings.TrimSuffix(word, "es")
+	case strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss"):
+		return strings.TrimSuffix(word, "s")
+	}
+	return word
+}
+
+func identifier(name string) string {
+	return strings.Trim(nonIdent.ReplaceAllString(strings.ToLower(strings.ReplaceAll(name, "-", "_")), "_"), "_")
+}
+
+// specPath is where a draft is written under dir.
+func specPath(dir string, d *draft, suffix int) string {
+	name := d.action
+	if suffix > 1 {
+		name = fmt.Sprintf("%s-%d", d.action, suffix)
+	}
+	return filepath.Join(append(append([]string{dir}, d.dir...), name+".req.yaml")...)
+}
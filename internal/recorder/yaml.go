@@ -0,0 +1,135 @@
+package recorder
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// field is one key of an ordered YAML mapping.
+type field struct {
+	key   string
+	value any
+}
+
+// mapping is a YAML mapping written in field order; map[string]any values
+// are written with sorted keys instead.
+type mapping []field
+
+var plainKey = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*$`)
+
+// writeYAML renders doc in the block style request specs use. Strings are
+// always double-quoted so no value is mistaken for a number, bool or null.
+func writeYAML(doc mapping) string {
+	var b strings.Builder
+	writeMapping(&b, doc, 0)
+	return b.String()
+}
+
+func writeMapping(b *strings.Builder, m mapping, indent int) {
+	for _, f := range m {
+		writeEntry(b, f.key, f.value, indent)
+	}
+}
+
+func writeEntry(b *strings.Builder, key string, value any, indent int) {
+	pad := strings.Repeat(" ", indent)
+	if !plainKey.MatchString(key) {
+		key = strconv.Quote(key)
+	}
+	switch v := value.(type) {
+	case mapping:
+		if len(v) == 0 {
+			fmt.Fprintf(b, "%s%s: {}\n", pad, key)
+			return
+		}
+		fmt.Fprintf(b, "%s%s:\n", pad, key)
+		writeMapping(b, v, indent+2)
+	case map[string]any:
+		if len(v) == 0 {
+			fmt.Fprintf(b, "%s%s: {}\n", pad, key)
+			return
+		}
+		fmt.Fprintf(b, "%s%s:\n", pad, key)
+		writeMapping(b, sortedMapping(v), indent+2)
+	case []any:
+		if len(v) == 0 {
+			fmt.Fprintf(b, "%s%s: []\n", pad, key)
+			return
+		}
+		fmt.Fprintf(b, "%s%s:\n", pad, key)
+		for _, item := range v {
+			writeItem(b, item, indent+2)
+		}
+	default:
+		fmt.Fprintf(b, "%s%s: %s\n", pad, key, scalar(v))
+	}
+}
+
+func writeItem(b *strings.Builder, item any, indent int) {
+	pad := strings.Repeat(" ", indent)
+	var m mapping
+	switch v := item.(type) {
+	case mapping:
+		m = v
+	case map[string]any:
+		m = sortedMapping(v)
+	case []any:
+		if len(v) == 0 {
+			fmt.Fprintf(b, "%s- []\n", pad)
+			return
+		}
+		fmt.Fprintf(b, "%s-\n", pad)
+		for _, nested := range v {
+			writeItem(b, nested, indent+2)
+		}
+		return
+	default:
+		fmt.Fprintf(b, "%s- %s\n", pad, scalar(v))
+		return
+	}
+	if len(m) == 0 {
+		fmt.Fprintf(b, "%s- {}\n", pad)
+		return
+	}
+	// The first field shares the "- " line; the rest align under it.
+	var first strings.Builder
+	writeEntry(&first, m[0].key, m[0].value, indent+2)
+	b.WriteString(pad + "- " + strings.TrimPrefix(first.String(), pad+"  "))
+	writeMapping(b, m[1:], indent+2)
+}
+
+func sortedMapping(m map[string]any) mapping {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	out := make(mapping, 0, len(keys))
+	for _, key := range keys {
+		out = append(out, field{key: key, value: m[key]})
+	}
+	return out
+}
+
+func scalar(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return strconv.Quote(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case int:
+		return strconv.Itoa(v)
+	case json.Number:
+		return v.String()
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return strconv.Quote(fmt.Sprint(v))
+	}
+}
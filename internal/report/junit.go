@@ -0,0 +1,134 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Errors   int          `xml:"errors,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name      string      `xml:"name,attr"`
+	Tests     int         `xml:"tests,attr"`
+	Failures  int         `xml:"failures,attr"`
+	Errors    int         `xml:"errors,attr"`
+	Skipped   int         `xml:"skipped,attr"`
+	Time      string      `xml:"time,attr"`
+	Timestamp string      `xml:"timestamp,attr"`
+	Cases     []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string         `xml:"name,attr"`
+	Classname string         `xml:"classname,attr"`
+	Time      string         `xml:"time,attr"`
+	Skipped   *junitMessage  `xml:"skipped"`
+	Error     *junitMessage  `xml:"error"`
+	Failures  []junitMessage `xml:"failure"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the suite as JUnit XML. Each assertion failure becomes
+// its own <failure> element; a request that could not be sent is an <error>.
+func WriteJUnit(w io.Writer, suite Suite) error {
+	name := suite.Name
+	if name == "" {
+		name = "wirepad"
+	}
+	ts := junitSuite{
+		Name:      name,
+		Tests:     len(suite.Cases),
+		Time:      seconds(suite.Duration),
+		Timestamp: suite.StartedAt.UTC().Format("2006-01-02T15:04:05"),
+	}
+
+	for _, c := range suite.Cases {
+		tc := junitCase{
+			Name:      c.Name,
+			Classname: classname(c.Path),
+			Time:      seconds(time.Duration(c.DurationMS) * time.Millisecond),
+		}
+		switch {
+		case c.Skipped != "":
+			tc.Skipped = &junitMessage{Message: c.Skipped}
+			ts.Skipped++
+		case c.Error != "":
+			tc.Error = &junitMessage{Message: c.Error}
+			ts.Errors++
+		case len(c.Failures) > 0:
+			for _, f := range c.Failures {
+				tc.Failures = append(tc.Failures, junitMessage{
+					Message: f.String(),
+					Type:    f.Operator,
+					Text:    failureDetail(f),
+				})
+			}
+			ts.Failures++
+		}
+		ts.Cases = append(ts.Cases, tc)
+	}
+
+	doc := junitSuites{
+		Name:     name,
+		Tests:    ts.Tests,
+		Failures: ts.Failures,
+		Errors:   ts.Errors,
+		Skipped:  ts.Skipped,
+		Time:     ts.Time,
+		Suites:   []junitSuite{ts},
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("encode junit report: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func failureDetail(f Failure) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "path: %s\n", f.Path)
+	fmt.Fprintf(&b, "operator: %s\n", f.Operator)
+	fmt.Fprintf(&b, "expected: %s\n", formatValue(f.Expected))
+	fmt.Fprintf(&b, "actual: %s\n", formatValue(f.Actual))
+	if f.Message != "" {
+		fmt.Fprintf(&b, "message: %s\n", f.Message)
+	}
+	return b.String()
+}
+
+// classname turns requests/users/get.req.yaml into requests.users so CI
+// servers group cases by directory.
+func classname(path string) string {
+	dir := filepath.ToSlash(filepath.Dir(path))
+	if dir == "." {
+		return "wirepad"
+	}
+	return strings.ReplaceAll(strings.Trim(dir, "/"), "/", ".")
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
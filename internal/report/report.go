@@ -0,0 +1,113 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Formats lists the report formats Write accepts.
+var Formats = []string{"junit", "tap", "json"}
+
+// Suite is the outcome of one wirepad test run.
+type Suite struct {
+	Name      string
+	StartedAt time.Time
+	Duration  time.Duration
+	Cases     []Case
+}
+
+// Case is one request in a suite. A case is skipped when Skipped is set,
+// errored when Error is set, and failed when it has Failures.
+type Case struct {
+	Name        string    `json:"name"`
+	Path        string    `json:"path"`
+	OK          bool      `json:"ok"`
+	Skipped     string    `json:"skipped,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	Status      int       `json:"status,omitempty"`
+	DurationMS  int64     `json:"duration_ms"`
+	RunID       string    `json:"run_id,omitempty"`
+	HistoryPath string    `json:"history_path,omitempty"`
+	Failures    []Failure `json:"failures,omitempty"`
+}
+
+// Failure is a failed assertion.
+type Failure struct {
+	Path     string `json:"path"`
+	Operator string `json:"operator"`
+	Expected any    `json:"expected"`
+	Actual   any    `json:"actual"`
+	Message  string `json:"message,omitempty"`
+}
+
+func (f Failure) String() string {
+	out := fmt.Sprintf("%s %s: expected %s, got %s", f.Path, f.Operator, formatValue(f.Expected), formatValue(f.Actual))
+	if f.Message != "" {
+		out += " (" + f.Message + ")"
+	}
+	return out
+}
+
+// Counts returns the number of passed, failed (including errored) and
+// skipped cases.
+func (s Suite) Counts() (passed, failed, skipped int) {
+	for _, c := range s.Cases {
+		switch {
+		case c.Skipped != "":
+			skipped++
+		case c.OK:
+			passed++
+		default:
+			failed++
+		}
+	}
+	return passed, failed, skipped
+}
+
+// Write renders suite in format.
+func Write(w io.Writer, format string, suite Suite) error {
+	switch format {
+	case "junit":
+		return WriteJUnit(w, suite)
+	case "tap":
+		return WriteTAP(w, suite)
+	case "json":
+		return WriteJSON(w, suite)
+	default:
+		return fmt.Errorf("unknown report format %q", format)
+	}
+}
+
+// WriteJSON writes the suite as one JSON object with totals and a tests
+// array, the same shape wirepad test --json prints.
+func WriteJSON(w io.Writer, suite Suite) error {
+	passed, failed, skipped := suite.Counts()
+	cases := suite.Cases
+	if cases == nil {
+		cases = []Case{}
+	}
+	payload, err := json.MarshalIndent(map[string]any{
+		"ok":          failed == 0,
+		"passed":      passed,
+		"failed":      failed,
+		"skipped":     skipped,
+		"started_at":  suite.StartedAt.UTC().Format(time.RFC3339),
+		"duration_ms": suite.Duration.Milliseconds(),
+		"tests":       cases,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode json report: %w", err)
+	}
+	_, err = fmt.Fprintln(w, string(payload))
+	return err
+}
+
+func formatValue(value any) string {
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(payload)
+}
@@ -0,0 +1,128 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func sampleSuite() Suite {
+	return Suite{
+		Name:      "wirepad",
+		StartedAt: time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC),
+		Duration:  1500 * time.Millisecond,
+		Cases: []Case{
+			{Name: "users.get", Path: "requests/users/get.req.yaml", OK: true, Status: 200, DurationMS: 12},
+			{Name: "users.broken", Path: "requests/users/broken.req.yaml", Status: 500, DurationMS: 40, Failures: []Failure{
+				{Path: "status", Operator: "equals", Expected: 200, Actual: 500},
+				{Path: "$.name", Operator: "matches", Expected: "^a", Actual: "bob"},
+			}},
+			{Name: "users.down #2", Path: "requests/users/down.req.yaml", Error: "send request: connection refused"},
+			{Name: "users.feed", Path: "requests/users/feed.req.yaml", OK: true, Skipped: "kind=ws is not run by wirepad test"},
+		},
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	var out bytes.Buffer
+	if err := WriteJUnit(&out, sampleSuite()); err != nil {
+		t.Fatalf("WriteJUnit returned error: %v", err)
+	}
+
+	var doc junitSuites
+	if err := xml.Unmarshal(out.Bytes(), &doc); err != nil {
+		t.Fatalf("decode junit %q: %v", out.String(), err)
+	}
+	if doc.Tests != 4 || doc.Failures != 1 || doc.Errors != 1 || doc.Skipped != 1 || doc.Time != "1.500" {
+		t.Fatalf("unexpected totals %+v", doc)
+	}
+	cases := doc.Suites[0].Cases
+	if cases[0].Classname != "requests.users" || cases[0].Time != "0.012" || cases[0].Failures != nil {
+		t.Fatalf("unexpected passing case %+v", cases[0])
+	}
+	if len(cases[1].Failures) != 2 {
+		t.Fatalf("expected one <failure> per assertion, got %+v", cases[1].Failures)
+	}
+	failure := cases[1].Failures[0]
+	if failure.Message != "status equals: expected 200, got 500" || failure.Type != "equals" {
+		t.Fatalf("unexpected failure %+v", failure)
+	}
+	for _, want := range []string{"path: status", "operator: equals", "expected: 200", "actual: 500"} {
+		if !strings.Contains(failure.Text, want) {
+			t.Fatalf("expected %q in failure body %q", want, failure.Text)
+		}
+	}
+	if cases[2].Error == nil || cases[2].Error.Message != "send request: connection refused" {
+		t.Fatalf("expected <error> for unsent request, got %+v", cases[2])
+	}
+	if cases[3].Skipped == nil {
+		t.Fatalf("expected <skipped>, got %+v", cases[3])
+	}
+}
+
+func TestWriteTAP(t *testing.T) {
+	var out bytes.Buffer
+	if err := WriteTAP(&out, sampleSuite()); err != nil {
+		t.Fatalf("WriteTAP returned error: %v", err)
+	}
+
+	want := `TAP version 13
+1..4
+ok 1 - users.get
+not ok 2 - users.broken
+  ---
+  path: requests/users/broken.req.yaml
+  duration_ms: 40
+  failures:
+    - path: status
+      operator: equals
+      expected: 200
+      actual: 500
+    - path: $.name
+      operator: matches
+      expected: "^a"
+      actual: "bob"
+  ...
+not ok 3 - users.down \#2
+  ---
+  path: requests/users/down.req.yaml
+  duration_ms: 0
+  error: "send request: connection refused"
+  ...
+ok 4 - users.feed # SKIP kind=ws is not run by wirepad test
+`
+	if out.String() != want {
+		t.Fatalf("unexpected TAP output:\n%s", out.String())
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var out bytes.Buffer
+	if err := Write(&out, "json", sampleSuite()); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+
+	var payload struct {
+		OK      bool   `json:"ok"`
+		Passed  int    `json:"passed"`
+		Failed  int    `json:"failed"`
+		Skipped int    `json:"skipped"`
+		Tests   []Case `json:"tests"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &payload); err != nil {
+		t.Fatalf("decode json report: %v", err)
+	}
+	if payload.OK || payload.Passed != 1 || payload.Failed != 2 || payload.Skipped != 1 {
+		t.Fatalf("unexpected totals %+v", payload)
+	}
+	if len(payload.Tests[1].Failures) != 2 || payload.Tests[1].Failures[0].Operator != "equals" {
+		t.Fatalf("unexpected failures %+v", payload.Tests[1])
+	}
+
+	if err := Write(&out, "html", sampleSuite()); err == nil {
+		t.Fatalf("expected unknown format error")
+	}
+}
@@ -0,0 +1,59 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteTAP writes the suite as TAP version 13, with failed assertions in a
+// YAML diagnostic block under each failing test.
+func WriteTAP(w io.Writer, suite Suite) error {
+	var b strings.Builder
+	b.WriteString("TAP version 13\n")
+	fmt.Fprintf(&b, "1..%d\n", len(suite.Cases))
+
+	for i, c := range suite.Cases {
+		description := strings.ReplaceAll(c.Name, "#", `\#`)
+		switch {
+		case c.Skipped != "":
+			fmt.Fprintf(&b, "ok %d - %s # SKIP %s\n", i+1, description, c.Skipped)
+			continue
+		case c.OK:
+			fmt.Fprintf(&b, "ok %d - %s\n", i+1, description)
+			continue
+		}
+
+		fmt.Fprintf(&b, "not ok %d - %s\n", i+1, description)
+		b.WriteString("  ---\n")
+		fmt.Fprintf(&b, "  path: %s\n", yamlScalar(c.Path))
+		fmt.Fprintf(&b, "  duration_ms: %d\n", c.DurationMS)
+		if c.Error != "" {
+			fmt.Fprintf(&b, "  error: %s\n", yamlScalar(c.Error))
+		}
+		if len(c.Failures) > 0 {
+			b.WriteString("  failures:\n")
+			for _, f := range c.Failures {
+				fmt.Fprintf(&b, "    - path: %s\n", yamlScalar(f.Path))
+				fmt.Fprintf(&b, "      operator: %s\n", f.Operator)
+				fmt.Fprintf(&b, "      expected: %s\n", formatValue(f.Expected))
+				fmt.Fprintf(&b, "      actual: %s\n", formatValue(f.Actual))
+				if f.Message != "" {
+					fmt.Fprintf(&b, "      message: %s\n", yamlScalar(f.Message))
+				}
+			}
+		}
+		b.WriteString("  ...\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// yamlScalar quotes s when it is not safe as a plain YAML scalar.
+func yamlScalar(s string) string {
+	if s != "" && !strings.ContainsAny(s, ":#{}[]&*!|>'\"%@`,\n") && strings.TrimSpace(s) == s {
+		return s
+	}
+	return formatValue(s)
+}
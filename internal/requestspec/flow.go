@@ -0,0 +1,303 @@
+package requestspec
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FlowSuffix is the extension of flow files.
+const FlowSuffix = ".flow.yaml"
+
+// FlowsDir is the conventional root of flow files.
+const FlowsDir = "flows"
+
+// flowTextFields are the fields read as strings, kept as written.
+var flowTextFields = []string{"vars", "steps.*.vars", "steps.*.export"}
+
+// Flow is a decoded .flow.yaml: request specs run in order, with values
+// exported by one step feeding the variables of the next.
+type Flow struct {
+	Version int
+	Name    string
+	// Vars are flow-level defaults; --var values override them.
+	Vars  map[string]string
+	Steps []FlowStep
+}
+
+type FlowStep struct {
+	// Name identifies the step in output and in steps.<name>.* variables. It
+	// defaults to the last element of Request.
+	Name    string
+	Request string
+	Vars    map[string]string
+	// Export maps a variable name to "$..." (response JSONPath),
+	// "headers.<name>" or "status".
+	Export map[string]string
+	// If is interpolated before the step runs; the step is skipped unless it
+	// is truthy or its "a == b" / "a != b" comparison holds.
+	If string
+	// ForEach runs the step once per item: a list, or a string that
+	// interpolates to a JSON array.
+	ForEach         any
+	ContinueOnError bool
+}
+
+// ResolveFlowPath accepts a flow file path, or a reference under flows/
+// written without the extension.
+func ResolveFlowPath(ref string) (string, error) {
+	ref = filepath.Clean(strings.TrimSpace(ref))
+	if ref == "." || ref == "" {
+		return "", fmt.Errorf("empty flow reference")
+	}
+
+	if path, ok, err := existingFile(ref); err != nil {
+		return "", err
+	} else if ok {
+		return path, nil
+	}
+	if strings.HasSuffix(ref, FlowSuffix) {
+		return "", fmt.Errorf("flow file %q not found", ref)
+	}
+
+	direct := filepath.Join(FlowsDir, filepath.FromSlash(ref)+FlowSuffix)
+	if path, ok, err := existingFile(direct); err != nil {
+		return "", err
+	} else if ok {
+		return path, nil
+	}
+	return "", fmt.Errorf("flow %q not found", ref)
+}
+
+// LoadFlow reads and validates a flow file.
+func LoadFlow(path string, opts LoadOptions) (*Flow, []Issue, error) {
+	if !strings.HasSuffix(path, FlowSuffix) {
+		return nil, nil, fmt.Errorf("invalid flow file %q: expected %s extension", path, FlowSuffix)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read flow file %q: %w", path, err)
+	}
+
+	raw, err := parseYAMLObject(string(data), flowTextFields...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("load flow file %q: parse yaml: %w", path, err)
+	}
+
+	flow, err := decodeFlow(raw)
+	if err != nil {
+		return nil, nil, fmt.Errorf("load flow file %q: %w", path, err)
+	}
+	if flow.Name == "" {
+		flow.Name = strings.TrimSuffix(filepath.Base(path), FlowSuffix)
+	}
+
+	var errs, warnings []Issue
+	for _, issue := range validateFlow(flow, raw, opts.Strict) {
+		if issue.Severity == SeverityError {
+			errs = append(errs, issue)
+			continue
+		}
+		warnings = append(warnings, issue)
+	}
+	if len(errs) > 0 {
+		return nil, nil, &ValidationError{Path: path, Issues: errs}
+	}
+	return flow, warnings, nil
+}
+
+func decodeFlow(raw map[string]any) (*Flow, error) {
+	flow := &Flow{}
+
+	if v, ok := raw["version"]; ok {
+		version, err := asInt(v, "version")
+		if err != nil {
+			return nil, err
+		}
+		flow.Version = version
+	}
+
+	if v, ok := raw["name"]; ok {
+		name, err := asString(v, "name")
+		if err != nil {
+			return nil, err
+		}
+		flow.Name = name
+	}
+
+	if v, ok := raw["vars"]; ok {
+		vars, err := asScalarMap(v, "vars")
+		if err != nil {
+			return nil, err
+		}
+		flow.Vars = vars
+	}
+
+	if v, ok := raw["steps"]; ok {
+		steps, err := asSlice(v, "steps")
+		if err != nil {
+			return nil, err
+		}
+		for i, item := range steps {
+			field := fmt.Sprintf("steps[%d]", i)
+			stepMap, err := asMap(item, field)
+			if err != nil {
+				return nil, err
+			}
+			step, err := decodeFlowStep(stepMap, field)
+			if err != nil {
+				return nil, err
+			}
+			flow.Steps = append(flow.Steps, step)
+		}
+	}
+
+	return flow, nil
+}
+
+func decodeFlowStep(raw map[string]any, prefix string) (FlowStep, error) {
+	step := FlowStep{}
+
+	for _, field := range []struct {
+		key string
+		dst *string
+	}{
+		{"name", &step.Name},
+		{"request", &step.Request},
+		{"if", &step.If},
+	} {
+		v, ok := raw[field.key]
+		if !ok {
+			continue
+		}
+		value, err := asString(v, prefix+"."+field.key)
+		if err != nil {
+			return step, err
+		}
+		*field.dst = value
+	}
+
+	if v, ok := raw["vars"]; ok {
+		vars, err := asScalarMap(v, prefix+".vars")
+		if err != nil {
+			return step, err
+		}
+		step.Vars = vars
+	}
+
+	if v, ok := raw["export"]; ok {
+		exports, err := asScalarMap(v, prefix+".export")
+		if err != nil {
+			return step, err
+		}
+		step.Export = exports
+	}
+
+	if v, ok := raw["for_each"]; ok {
+		step.ForEach = v
+	}
+
+	if v, ok := raw["continue_on_error"]; ok {
+		continueOnError, err := asBool(v, prefix+".continue_on_error")
+		if err != nil {
+			return step, err
+		}
+		step.ContinueOnError = continueOnError
+	}
+
+	if step.Name == "" && step.Request != "" {
+		step.Name = strings.TrimSuffix(filepath.Base(step.Request), ".req.yaml")
+	}
+	return step, nil
+}
+
+// asScalarMap reads a map of scalar values as strings.
+func asScalarMap(value any, field string) (map[string]string, error) {
+	m, err := asMap(value, field)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]string, len(m))
+	for key, item := range m {
+		switch item.(type) {
+		case map[string]any, []any:
+			return nil, fmt.Errorf("%s.%s must be a scalar", field, key)
+		case nil:
+			out[key] = ""
+		default:
+			out[key] = fmt.Sprint(item)
+		}
+	}
+	return out, nil
+}
+
+func validateFlow(flow *Flow, raw map[string]any, strict bool) []Issue {
+	var issues []Issue
+	add := func(severity Severity, field, message, hint string) {
+		issues = append(issues, Issue{Severity: severity, Field: field, Message: message, Hint: hint})
+	}
+
+	if flow.Version == 0 {
+		add(SeverityError, "version", "missing required field", "set version: 1")
+	} else if flow.Version != 1 {
+		add(SeverityError, "version", "unsupported version", "version: 1 is currently supported")
+	}
+
+	if len(flow.Steps) == 0 {
+		add(SeverityError, "steps", "missing required field", "list the requests to run")
+	}
+
+	seen := make(map[string]bool)
+	for i, step := range flow.Steps {
+		prefix := fmt.Sprintf("steps[%d]", i)
+		if strings.TrimSpace(step.Request) == "" {
+			add(SeverityError, prefix+".request", "missing required field", "set a request reference such as users/create")
+		}
+		if step.Name != "" {
+			if seen[step.Name] {
+				add(SeverityError, prefix+".name", "duplicate step name", "give each step a unique name")
+			}
+			seen[step.Name] = true
+		}
+		for name, source := range step.Export {
+			if source != "status" && !strings.HasPrefix(source, "$") && !strings.HasPrefix(source, "headers.") {
+				add(SeverityError, prefix+".export."+name, "invalid export source", "expected a JSONPath ($.id), headers.<name> or status")
+			}
+		}
+		switch step.ForEach.(type) {
+		case nil, []any, string:
+		default:
+			add(SeverityError, prefix+".for_each", "invalid loop", "expected a list or a {{var}} holding a JSON array")
+		}
+	}
+
+	var unknown []Issue
+	walkUnknownFields(raw, flowFileSchema(), "", strict, &unknown)
+	return append(issues, unknown...)
+}
+
+func flowFileSchema() *schemaNode {
+	anyMap := &schemaNode{allowAny: true}
+	stepSchema := &schemaNode{
+		children: map[string]*schemaNode{
+			"name":              scalarSchema(),
+			"request":           scalarSchema(),
+			"vars":              anyMap,
+			"export":            anyMap,
+			"if":                scalarSchema(),
+			"for_each":          anyMap,
+			"continue_on_error": scalarSchema(),
+		},
+	}
+	return &schemaNode{
+		children: map[string]*schemaNode{
+			"version":     scalarSchema(),
+			"name":        scalarSchema(),
+			"description": scalarSchema(),
+			"vars":        anyMap,
+			"steps":       sequenceSchema(stepSchema),
+		},
+	}
+}
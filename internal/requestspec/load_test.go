@@ -62,6 +62,25 @@ request:
 	}
 }
 
+func TestLoadFile_WSProxyWarns(t *testing.T) {
+	path := writeRequestFile(t, "ws-proxy.req.yaml", `
+version: 1
+kind: ws
+name: events.subscribe
+request:
+  url: "wss://api.example.com/events"
+  proxy: "http://127.0.0.1:8080"
+`)
+
+	result, err := LoadFile(path, LoadOptions{})
+	if err != nil {
+		t.Fatalf("LoadFile returned error: %v", err)
+	}
+	if !containsIssue(result.Warnings, "request.proxy", SeverityWarning, "proxy is not used for kind=ws yet") {
+		t.Fatalf("expected request.proxy warning, got %+v", result.Warnings)
+	}
+}
+
 func TestLoadFile_HTTPMissingMethod(t *testing.T) {
 	path := writeRequestFile(t, "missing-method.req.yaml", `
 version: 1
@@ -169,6 +188,38 @@ request:
 	}
 }
 
+func TestLoadFile_InvalidRetry(t *testing.T) {
+	path := writeRequestFile(t, "retry.req.yaml", `
+version: 1
+kind: http
+name: flaky
+request:
+  method: GET
+  url: "https://api.example.com/flaky"
+  retry:
+    attempts: 3
+    backoff: linear
+    on_status: [503]
+    on_error: [timeout, broken_pipe]
+`)
+
+	_, err := LoadFile(path, LoadOptions{Strict: true})
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+
+	if !containsIssue(validationErr.Issues, "request.retry.backoff", SeverityError, "invalid backoff") {
+		t.Fatalf("expected invalid backoff issue, got %+v", validationErr.Issues)
+	}
+	if !containsIssue(validationErr.Issues, "request.retry.on_error[1]", SeverityError, "invalid error kind") {
+		t.Fatalf("expected invalid on_error issue, got %+v", validationErr.Issues)
+	}
+	if len(validationErr.Issues) != 2 {
+		t.Fatalf("expected exactly 2 issues, got %+v", validationErr.Issues)
+	}
+}
+
 func TestLoadFile_RequiresReqYAMLExtension(t *testing.T) {
 	path := writeRequestFile(t, "users-create.yaml", `
 version: 1
@@ -208,3 +259,183 @@ func containsIssue(issues []Issue, field string, severity Severity, message stri
 	}
 	return false
 }
+
+func TestLoadFile_QueryOrderAndArrayFormat(t *testing.T) {
+	path := writeRequestFile(t, "search.req.yaml", `
+version: 1
+kind: http
+name: search
+request:
+  method: GET
+  url: "https://api.example.com/search"
+  query:
+    zeta: 1
+    ids: [1, 2]
+    alpha:
+      - a
+      - b
+  headers:
+    X-Filter:
+      nested: true
+  array_format: pipes
+`)
+
+	_, err := LoadFile(path, LoadOptions{})
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	if !containsIssue(validationErr.Issues, "request.array_format", SeverityError, "invalid array format") {
+		t.Fatalf("expected invalid array_format issue, got %+v", validationErr.Issues)
+	}
+	if !containsIssue(validationErr.Issues, "request.headers.X-Filter", SeverityError, "value must be a scalar or a list of scalars") {
+		t.Fatalf("expected nested header issue, got %+v", validationErr.Issues)
+	}
+
+	spec, _, err := Parse([]byte(`
+request:
+  query:
+    zeta: 1
+    ids: [1, 2]
+    alpha:
+      - a
+  headers:
+    beta: x
+`))
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if got := strings.Join(spec.Request.QueryOrder, ","); got != "zeta,ids,alpha" {
+		t.Fatalf("expected declared query order, got %q", got)
+	}
+}
+
+func TestLoadFile_GRPCRequest(t *testing.T) {
+	path := writeRequestFile(t, "hello.req.yaml", `
+version: 1
+kind: grpc
+name: hello
+request:
+  url: "http://localhost:50051"
+  method: SayHello
+  proto: greeter.proto
+  descriptor_set: greeter.pb
+  message: hi
+  auth:
+    type: oauth2
+    grant: client_credentials
+    token_url: "http://localhost/token"
+`)
+
+	_, err := LoadFile(path, LoadOptions{Strict: true})
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	for _, want := range []struct{ field, message string }{
+		{"request.service", "missing required field"},
+		{"request.descriptor_set", "conflicts with request.proto"},
+		{"request.message", "must be an object"},
+		{"request.auth.type", "not supported for kind=grpc"},
+	} {
+		if !containsIssue(validationErr.Issues, want.field, SeverityError, want.message) {
+			t.Fatalf("expected %s issue, got %+v", want.field, validationErr.Issues)
+		}
+	}
+
+	path = writeRequestFile(t, "hello.req.yaml", `
+version: 1
+kind: grpc
+name: hello
+request:
+  url: "http://localhost:50051"
+  service: demo.Greeter
+  method: SayHello
+  proto: [greeter.proto, common.proto]
+  import_paths: [protos]
+  auth:
+    type: bearer
+    token: t0k
+  metadata:
+    x-tenant: acme
+  message:
+    name: ada
+    tags:
+      team: core
+`)
+	result, err := LoadFile(path, LoadOptions{Strict: true})
+	if err != nil {
+		t.Fatalf("LoadFile returned error: %v", err)
+	}
+	req := result.Spec.Request
+	if req.Service != "demo.Greeter" || len(req.Proto) != 2 || req.ImportPaths[0] != "protos" || req.Metadata["x-tenant"] != "acme" {
+		t.Fatalf("unexpected grpc request %+v", req)
+	}
+	message, _ := req.Message.(map[string]any)
+	if message["name"] != "ada" {
+		t.Fatalf("unexpected message %#v", req.Message)
+	}
+}
+
+func TestLoadFlow(t *testing.T) {
+	path := writeRequestFile(t, "signup.flow.yaml", `
+version: 1
+vars:
+  username: ada
+steps:
+  - request: auth/login.req.yaml
+    export:
+      token: "$.token"
+  - request: users/create
+    for_each: [1, 2]
+    continue_on_error: true
+    export:
+      user_id: id
+    retries: 3
+`)
+
+	_, _, err := LoadFlow(path, LoadOptions{Strict: true})
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	if !containsIssue(validationErr.Issues, "steps[1].export.user_id", SeverityError, "invalid export source") {
+		t.Fatalf("expected invalid export issue, got %+v", validationErr.Issues)
+	}
+	if !containsIssue(validationErr.Issues, "steps.retries", SeverityError, "unknown field") {
+		t.Fatalf("expected unknown field issue, got %+v", validationErr.Issues)
+	}
+
+	path = writeRequestFile(t, "signup.flow.yaml", `
+version: 1
+vars:
+  username: ada
+  attempts: 2
+  api_version: 1.10
+steps:
+  - request: auth/login.req.yaml
+    export:
+      token: "$.token"
+  - name: create
+    request: users/create
+    for_each: [1, 2]
+    continue_on_error: true
+    vars: {team: 0x1F, seq: 007}
+`)
+	flow, warnings, err := LoadFlow(path, LoadOptions{})
+	if err != nil || len(warnings) != 0 {
+		t.Fatalf("LoadFlow returned error %v, warnings %+v", err, warnings)
+	}
+	if flow.Name != "signup" || flow.Vars["attempts"] != "2" || len(flow.Steps) != 2 {
+		t.Fatalf("unexpected flow %+v", flow)
+	}
+	if flow.Steps[0].Name != "login" || flow.Steps[0].Export["token"] != "$.token" {
+		t.Fatalf("unexpected first step %+v", flow.Steps[0])
+	}
+	if items, _ := flow.Steps[1].ForEach.([]any); len(items) != 2 || !flow.Steps[1].ContinueOnError {
+		t.Fatalf("unexpected second step %+v", flow.Steps[1])
+	}
+	if flow.Vars["api_version"] != "1.10" || flow.Steps[1].Vars["team"] != "0x1F" || flow.Steps[1].Vars["seq"] != "007" {
+		t.Fatalf("expected vars as written, got %v and %v", flow.Vars, flow.Steps[1].Vars)
+	}
+}
@@ -3,18 +3,21 @@ package requestspec
 import (
 	"fmt"
 	"strconv"
-	"strings"
-	"unicode/utf8"
 )
 
-type parsedLine struct {
-	number int
-	indent int
-	text   string
+// specTextFields are the fields whose values are sent as text, kept as
+// written rather than typed.
+var specTextFields = []string{
+	"request.query",
+	"request.headers",
+	"request.body.form",
+	"request.body.multipart",
+	"request.metadata",
+	"mock.headers",
 }
 
 func Parse(data []byte) (*Spec, map[string]any, error) {
-	raw, err := parseYAMLObject(string(data))
+	raw, err := parseYAMLObject(string(data), specTextFields...)
 	if err != nil {
 		return nil, nil, fmt.Errorf("parse yaml: %w", err)
 	}
@@ -23,376 +26,15 @@ func Parse(data []byte) (*Spec, map[string]any, error) {
 	if err != nil {
 		return nil, nil, fmt.Errorf("decode request schema: %w", err)
 	}
-
-	return spec, raw, nil
-}
-
-func parseYAMLObject(input string) (map[string]any, error) {
-	lines, err := preprocessLines(input)
-	if err != nil {
-		return nil, err
-	}
-	if len(lines) == 0 {
-		return nil, fmt.Errorf("empty document")
-	}
-
-	value, next, err := parseBlock(lines, 0, lines[0].indent)
-	if err != nil {
-		return nil, err
-	}
-	if next != len(lines) {
-		return nil, fmt.Errorf("unexpected trailing content at line %d", lines[next].number)
-	}
-
-	root, ok := value.(map[string]any)
-	if !ok {
-		return nil, fmt.Errorf("root must be a mapping")
-	}
-
-	return root, nil
-}
-
-func preprocessLines(input string) ([]parsedLine, error) {
-	rawLines := strings.Split(input, "\n")
-	lines := make([]parsedLine, 0, len(rawLines))
-	for i, raw := range rawLines {
-		lineNo := i + 1
-		line := stripComment(raw)
-		if strings.TrimSpace(line) == "" {
-			continue
-		}
-
-		indent := countIndent(line)
-		if indent%2 != 0 {
-			return nil, fmt.Errorf("line %d: indentation must use multiples of 2 spaces", lineNo)
-		}
-
-		lines = append(lines, parsedLine{
-			number: lineNo,
-			indent: indent,
-			text:   strings.TrimSpace(line),
-		})
-	}
-
-	return lines, nil
-}
-
-func stripComment(line string) string {
-	inSingle := false
-	inDouble := false
-	escaped := false
-	for i, r := range line {
-		switch {
-		case r == '\\' && inDouble && !escaped:
-			escaped = true
-			continue
-		case r == '\'' && !inDouble:
-			inSingle = !inSingle
-		case r == '"' && !inSingle && !escaped:
-			inDouble = !inDouble
-		case r == '#' && !inSingle && !inDouble:
-			return line[:i]
-		}
-		escaped = false
-	}
-	return line
-}
-
-func countIndent(line string) int {
-	indent := 0
-	for _, r := range line {
-		if r != ' ' {
-			break
-		}
-		indent++
-	}
-	return indent
-}
-
-func parseBlock(lines []parsedLine, idx, indent int) (any, int, error) {
-	if idx >= len(lines) {
-		return nil, idx, fmt.Errorf("unexpected end of file")
-	}
-
-	if lines[idx].indent != indent {
-		return nil, idx, fmt.Errorf("line %d: expected indent %d, got %d", lines[idx].number, indent, lines[idx].indent)
-	}
-
-	if strings.HasPrefix(lines[idx].text, "- ") {
-		return parseSequence(lines, idx, indent)
-	}
-	return parseMapping(lines, idx, indent)
-}
-
-func parseMapping(lines []parsedLine, idx, indent int) (map[string]any, int, error) {
-	out := make(map[string]any)
-
-	for idx < len(lines) {
-		line := lines[idx]
-		if line.indent < indent {
-			break
-		}
-		if line.indent > indent {
-			return nil, idx, fmt.Errorf("line %d: unexpected indentation", line.number)
-		}
-		if strings.HasPrefix(line.text, "- ") {
-			break
-		}
-
-		key, rest, ok := splitKeyValue(line.text)
-		if !ok {
-			return nil, idx, fmt.Errorf("line %d: expected key: value pair", line.number)
-		}
-		idx++
-
-		if rest == "" {
-			if idx >= len(lines) || lines[idx].indent <= indent {
-				out[key] = nil
-				continue
-			}
-			child, next, err := parseBlock(lines, idx, lines[idx].indent)
-			if err != nil {
-				return nil, idx, err
-			}
-			out[key] = child
-			idx = next
-			continue
-		}
-
-		value, err := parseScalar(rest)
-		if err != nil {
-			return nil, idx, fmt.Errorf("line %d: %w", line.number, err)
-		}
-		out[key] = value
+	if spec.Request != nil && len(spec.Request.Query) > 0 {
+		spec.Request.QueryOrder = mappingKeys(string(data), "request", "query")
 	}
-
-	return out, idx, nil
-}
-
-func parseSequence(lines []parsedLine, idx, indent int) ([]any, int, error) {
-	var out []any
-
-	for idx < len(lines) {
-		line := lines[idx]
-		if line.indent < indent {
-			break
-		}
-		if line.indent > indent {
-			return nil, idx, fmt.Errorf("line %d: unexpected indentation", line.number)
-		}
-		if !strings.HasPrefix(line.text, "- ") {
-			break
-		}
-
-		itemText := strings.TrimSpace(strings.TrimPrefix(line.text, "- "))
-		idx++
-
-		if itemText == "" {
-			if idx >= len(lines) || lines[idx].indent <= indent {
-				out = append(out, nil)
-				continue
-			}
-			child, next, err := parseBlock(lines, idx, lines[idx].indent)
-			if err != nil {
-				return nil, idx, err
-			}
-			out = append(out, child)
-			idx = next
-			continue
-		}
-
-		if key, rest, ok := splitKeyValue(itemText); ok {
-			itemMap := map[string]any{}
-			if rest == "" {
-				if idx >= len(lines) || lines[idx].indent <= indent {
-					itemMap[key] = nil
-				} else {
-					child, next, err := parseBlock(lines, idx, lines[idx].indent)
-					if err != nil {
-						return nil, idx, err
-					}
-					itemMap[key] = child
-					idx = next
-				}
-			} else {
-				value, err := parseScalar(rest)
-				if err != nil {
-					return nil, idx, fmt.Errorf("line %d: %w", line.number, err)
-				}
-				itemMap[key] = value
-			}
-
-			var err error
-			itemMap, idx, err = parseSequenceMapTail(lines, idx, indent+2, itemMap)
-			if err != nil {
-				return nil, idx, err
-			}
-
-			out = append(out, itemMap)
-			continue
-		}
-
-		value, err := parseScalar(itemText)
-		if err != nil {
-			return nil, idx, fmt.Errorf("line %d: %w", line.number, err)
-		}
-		out = append(out, value)
+	// GraphQL over HTTP is always a POST, so the method may be left out.
+	if spec.Request != nil && spec.Request.Method == "" && spec.Request.Body != nil && spec.Request.Body.Mode == "graphql" {
+		spec.Request.Method = "POST"
 	}
 
-	return out, idx, nil
-}
-
-func parseSequenceMapTail(lines []parsedLine, idx, indent int, itemMap map[string]any) (map[string]any, int, error) {
-	for idx < len(lines) {
-		line := lines[idx]
-		if line.indent < indent {
-			break
-		}
-		if line.indent > indent {
-			return nil, idx, fmt.Errorf("line %d: unexpected indentation", line.number)
-		}
-		if strings.HasPrefix(line.text, "- ") {
-			break
-		}
-
-		key, rest, ok := splitKeyValue(line.text)
-		if !ok {
-			return nil, idx, fmt.Errorf("line %d: expected key: value pair", line.number)
-		}
-		idx++
-
-		if rest == "" {
-			if idx >= len(lines) || lines[idx].indent <= indent {
-				itemMap[key] = nil
-				continue
-			}
-			child, next, err := parseBlock(lines, idx, lines[idx].indent)
-			if err != nil {
-				return nil, idx, err
-			}
-			itemMap[key] = child
-			idx = next
-			continue
-		}
-
-		value, err := parseScalar(rest)
-		if err != nil {
-			return nil, idx, fmt.Errorf("line %d: %w", line.number, err)
-		}
-		itemMap[key] = value
-	}
-
-	return itemMap, idx, nil
-}
-
-func splitKeyValue(text string) (string, string, bool) {
-	inSingle := false
-	inDouble := false
-	escaped := false
-	for i, r := range text {
-		switch {
-		case r == '\\' && inDouble && !escaped:
-			escaped = true
-			continue
-		case r == '\'' && !inDouble:
-			inSingle = !inSingle
-		case r == '"' && !inSingle && !escaped:
-			inDouble = !inDouble
-		case r == ':' && !inSingle && !inDouble:
-			key := strings.TrimSpace(text[:i])
-			if key == "" {
-				return "", "", false
-			}
-			return key, strings.TrimSpace(text[i+1:]), true
-		}
-		escaped = false
-	}
-	return "", "", false
-}
-
-func parseScalar(text string) (any, error) {
-	if text == "" {
-		return "", nil
-	}
-
-	if strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]") {
-		return parseInlineList(text[1 : len(text)-1])
-	}
-
-	if len(text) >= 2 {
-		if strings.HasPrefix(text, "\"") && strings.HasSuffix(text, "\"") {
-			return strconv.Unquote(text)
-		}
-		if strings.HasPrefix(text, "'") && strings.HasSuffix(text, "'") {
-			return strings.ReplaceAll(text[1:len(text)-1], "''", "'"), nil
-		}
-	}
-
-	switch text {
-	case "true":
-		return true, nil
-	case "false":
-		return false, nil
-	case "null", "~":
-		return nil, nil
-	}
-
-	if i, err := strconv.Atoi(text); err == nil {
-		return i, nil
-	}
-
-	return text, nil
-}
-
-func parseInlineList(text string) ([]any, error) {
-	var out []any
-	var part strings.Builder
-	inSingle := false
-	inDouble := false
-	escaped := false
-
-	flush := func() error {
-		item := strings.TrimSpace(part.String())
-		part.Reset()
-		if item == "" {
-			return nil
-		}
-		value, err := parseScalar(item)
-		if err != nil {
-			return err
-		}
-		out = append(out, value)
-		return nil
-	}
-
-	for len(text) > 0 {
-		r, size := utf8.DecodeRuneInString(text)
-		text = text[size:]
-
-		switch {
-		case r == '\\' && inDouble && !escaped:
-			escaped = true
-			part.WriteRune(r)
-			continue
-		case r == '\'' && !inDouble:
-			inSingle = !inSingle
-		case r == '"' && !inSingle && !escaped:
-			inDouble = !inDouble
-		case r == ',' && !inSingle && !inDouble:
-			if err := flush(); err != nil {
-				return nil, err
-			}
-			continue
-		}
-
-		escaped = false
-		part.WriteRune(r)
-	}
-
-	if err := flush(); err != nil {
-		return nil, err
-	}
-	return out, nil
+	return spec, raw, nil
 }
 
 func decodeSpec(raw map[string]any) (*Spec, error) {
@@ -466,6 +108,26 @@ func decodeSpec(raw map[string]any) (*Spec, error) {
 		spec.Hooks = hooks
 	}
 
+	if v, ok := raw["data"]; ok {
+		data, err := asString(v, "data")
+		if err != nil {
+			return nil, err
+		}
+		spec.Data = data
+	}
+
+	if v, ok := raw["mock"]; ok {
+		mockMap, err := asMap(v, "mock")
+		if err != nil {
+			return nil, err
+		}
+		mock, err := decodeMock(mockMap)
+		if err != nil {
+			return nil, err
+		}
+		spec.Mock = mock
+	}
+
 	return spec, nil
 }
 
@@ -568,9 +230,327 @@ func decodeRequest(raw map[string]any) (*Request, error) {
 		req.Messages = messages
 	}
 
+	if v, ok := raw["proxy"]; ok {
+		proxy, err := asString(v, "request.proxy")
+		if err != nil {
+			return nil, err
+		}
+		req.Proxy = proxy
+	}
+
+	if v, ok := raw["tls"]; ok {
+		tlsMap, err := asMap(v, "request.tls")
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig, err := decodeTLS(tlsMap, "request.tls")
+		if err != nil {
+			return nil, err
+		}
+		req.TLS = tlsConfig
+	}
+
+	if v, ok := raw["retry"]; ok {
+		retryMap, err := asMap(v, "request.retry")
+		if err != nil {
+			return nil, err
+		}
+		retry, err := decodeRetry(retryMap)
+		if err != nil {
+			return nil, err
+		}
+		req.Retry = retry
+	}
+
+	if v, ok := raw["auth"]; ok {
+		authMap, err := asMap(v, "request.auth")
+		if err != nil {
+			return nil, err
+		}
+		auth, err := decodeAuth(authMap)
+		if err != nil {
+			return nil, err
+		}
+		req.Auth = auth
+	}
+
+	if v, ok := raw["cookies"]; ok {
+		cookies, err := asBool(v, "request.cookies")
+		if err != nil {
+			return nil, err
+		}
+		req.Cookies = &cookies
+	}
+
+	if v, ok := raw["http_version"]; ok {
+		version, err := asVersion(v, "request.http_version")
+		if err != nil {
+			return nil, err
+		}
+		req.HTTPVersion = version
+	}
+
+	if v, ok := raw["service"]; ok {
+		service, err := asString(v, "request.service")
+		if err != nil {
+			return nil, err
+		}
+		req.Service = service
+	}
+
+	if v, ok := raw["message"]; ok {
+		req.Message = v
+	}
+
+	if v, ok := raw["metadata"]; ok {
+		metadata, err := asMap(v, "request.metadata")
+		if err != nil {
+			return nil, err
+		}
+		req.Metadata = metadata
+	}
+
+	if v, ok := raw["proto"]; ok {
+		if path, isString := v.(string); isString {
+			req.Proto = []string{path}
+		} else {
+			paths, err := asStringSlice(v, "request.proto")
+			if err != nil {
+				return nil, err
+			}
+			req.Proto = paths
+		}
+	}
+
+	if v, ok := raw["import_paths"]; ok {
+		paths, err := asStringSlice(v, "request.import_paths")
+		if err != nil {
+			return nil, err
+		}
+		req.ImportPaths = paths
+	}
+
+	if v, ok := raw["descriptor_set"]; ok {
+		path, err := asString(v, "request.descriptor_set")
+		if err != nil {
+			return nil, err
+		}
+		req.DescriptorSet = path
+	}
+
+	if v, ok := raw["array_format"]; ok {
+		format, err := asString(v, "request.array_format")
+		if err != nil {
+			return nil, err
+		}
+		req.ArrayFormat = format
+	}
+
 	return req, nil
 }
 
+func decodeAuth(raw map[string]any) (*Auth, error) {
+	out := &Auth{}
+
+	fields := map[string]*string{
+		"type":              &out.Type,
+		"username":          &out.Username,
+		"password":          &out.Password,
+		"token":             &out.Token,
+		"name":              &out.Name,
+		"value":             &out.Value,
+		"in":                &out.In,
+		"access_key_id":     &out.AccessKeyID,
+		"secret_access_key": &out.SecretAccessKey,
+		"session_token":     &out.SessionToken,
+		"region":            &out.Region,
+		"service":           &out.Service,
+		"grant":             &out.Grant,
+		"token_url":         &out.TokenURL,
+		"client_id":         &out.ClientID,
+		"client_secret":     &out.ClientSecret,
+		"client_auth":       &out.ClientAuth,
+		"refresh_token":     &out.RefreshToken,
+	}
+	for key, dst := range fields {
+		v, ok := raw[key]
+		if !ok {
+			continue
+		}
+		s, err := asString(v, "request.auth."+key)
+		if err != nil {
+			return nil, err
+		}
+		*dst = s
+	}
+
+	if v, ok := raw["scopes"]; ok {
+		scopes, err := asStringSlice(v, "request.auth.scopes")
+		if err != nil {
+			return nil, err
+		}
+		out.Scopes = scopes
+	}
+
+	return out, nil
+}
+
+func decodeMock(raw map[string]any) (*Mock, error) {
+	out := &Mock{}
+
+	ints := map[string]*int{
+		"status":         &out.Status,
+		"latency_ms":     &out.LatencyMS,
+		"latency_max_ms": &out.LatencyMaxMS,
+		"error_status":   &out.ErrorStatus,
+	}
+	for key, dst := range ints {
+		v, ok := raw[key]
+		if !ok {
+			continue
+		}
+		i, err := asInt(v, "mock."+key)
+		if err != nil {
+			return nil, err
+		}
+		*dst = i
+	}
+
+	strs := map[string]*string{
+		"body":      &out.Body,
+		"body_path": &out.BodyPath,
+	}
+	for key, dst := range strs {
+		v, ok := raw[key]
+		if !ok {
+			continue
+		}
+		str, err := asString(v, "mock."+key)
+		if err != nil {
+			return nil, err
+		}
+		*dst = str
+	}
+
+	if v, ok := raw["headers"]; ok {
+		headers, err := asMap(v, "mock.headers")
+		if err != nil {
+			return nil, err
+		}
+		out.Headers = headers
+	}
+
+	if v, ok := raw["json"]; ok {
+		out.JSON = v
+	}
+
+	if v, ok := raw["error_rate"]; ok {
+		rate, err := asFloat(v, "mock.error_rate")
+		if err != nil {
+			return nil, err
+		}
+		out.ErrorRate = rate
+	}
+
+	return out, nil
+}
+
+func decodeRetry(raw map[string]any) (*Retry, error) {
+	out := &Retry{}
+
+	ints := map[string]*int{
+		"attempts":     &out.Attempts,
+		"delay_ms":     &out.DelayMS,
+		"max_delay_ms": &out.MaxDelayMS,
+	}
+	for key, dst := range ints {
+		v, ok := raw[key]
+		if !ok {
+			continue
+		}
+		i, err := asInt(v, "request.retry."+key)
+		if err != nil {
+			return nil, err
+		}
+		*dst = i
+	}
+
+	if v, ok := raw["backoff"]; ok {
+		backoff, err := asString(v, "request.retry.backoff")
+		if err != nil {
+			return nil, err
+		}
+		out.Backoff = backoff
+	}
+
+	bools := map[string]**bool{
+		"jitter":              &out.Jitter,
+		"respect_retry_after": &out.RespectRetryAfter,
+	}
+	for key, dst := range bools {
+		v, ok := raw[key]
+		if !ok {
+			continue
+		}
+		b, err := asBool(v, "request.retry."+key)
+		if err != nil {
+			return nil, err
+		}
+		*dst = &b
+	}
+
+	if v, ok := raw["on_status"]; ok {
+		statuses, err := asIntSlice(v, "request.retry.on_status")
+		if err != nil {
+			return nil, err
+		}
+		out.OnStatus = statuses
+	}
+
+	if v, ok := raw["on_error"]; ok {
+		kinds, err := asStringSlice(v, "request.retry.on_error")
+		if err != nil {
+			return nil, err
+		}
+		out.OnError = kinds
+	}
+
+	return out, nil
+}
+
+func decodeTLS(raw map[string]any, prefix string) (*TLS, error) {
+	out := &TLS{}
+
+	fields := map[string]*string{
+		"ca_file":     &out.CAFile,
+		"cert_file":   &out.CertFile,
+		"key_file":    &out.KeyFile,
+		"server_name": &out.ServerName,
+		"min_version": &out.MinVersion,
+	}
+	for key, dst := range fields {
+		v, ok := raw[key]
+		if !ok {
+			continue
+		}
+		s, err := asString(v, prefix+"."+key)
+		if err != nil {
+			return nil, err
+		}
+		*dst = s
+	}
+
+	if v, ok := raw["insecure_skip_verify"]; ok {
+		insecure, err := asBool(v, prefix+".insecure_skip_verify")
+		if err != nil {
+			return nil, err
+		}
+		out.InsecureSkipVerify = &insecure
+	}
+
+	return out, nil
+}
+
 func decodeBody(raw map[string]any) (*Body, error) {
 	body := &Body{}
 
@@ -626,6 +606,42 @@ func decodeBody(raw map[string]any) (*Body, error) {
 		body.Multipart = multipart
 	}
 
+	if v, ok := raw["compress"]; ok {
+		compress, err := asString(v, "request.body.compress")
+		if err != nil {
+			return nil, err
+		}
+		body.Compress = compress
+	}
+
+	if v, ok := raw["query"]; ok {
+		query, err := asString(v, "request.body.query")
+		if err != nil {
+			return nil, err
+		}
+		body.Query = query
+	}
+
+	if v, ok := raw["query_path"]; ok {
+		queryPath, err := asString(v, "request.body.query_path")
+		if err != nil {
+			return nil, err
+		}
+		body.QueryPath = queryPath
+	}
+
+	if v, ok := raw["variables"]; ok {
+		body.Variables = v
+	}
+
+	if v, ok := raw["operation_name"]; ok {
+		operationName, err := asString(v, "request.body.operation_name")
+		if err != nil {
+			return nil, err
+		}
+		body.OperationName = operationName
+	}
+
 	return body, nil
 }
 
@@ -694,6 +710,20 @@ func asString(value any, field string) (string, error) {
 	return s, nil
 }
 
+// asVersion accepts version numbers written bare (2, 1.1) or quoted.
+func asVersion(value any, field string) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case int:
+		return strconv.Itoa(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	default:
+		return "", fmt.Errorf("%s must be a string", field)
+	}
+}
+
 func asInt(value any, field string) (int, error) {
 	i, ok := value.(int)
 	if !ok {
@@ -702,6 +732,21 @@ func asInt(value any, field string) (int, error) {
 	return i, nil
 }
 
+// asFloat accepts integers, floats and numeric strings.
+func asFloat(value any, field string) (float64, error) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case string:
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f, nil
+		}
+	}
+	return 0, fmt.Errorf("%s must be a number", field)
+}
+
 func asBool(value any, field string) (bool, error) {
 	b, ok := value.(bool)
 	if !ok {
@@ -710,6 +755,24 @@ func asBool(value any, field string) (bool, error) {
 	return b, nil
 }
 
+func asIntSlice(value any, field string) ([]int, error) {
+	seq, err := asSlice(value, field)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]int, 0, len(seq))
+	for i, item := range seq {
+		n, ok := item.(int)
+		if !ok {
+			return nil, fmt.Errorf("%s[%d] must be an integer", field, i)
+		}
+		out = append(out, n)
+	}
+
+	return out, nil
+}
+
 func asStringSlice(value any, field string) ([]string, error) {
 	seq, err := asSlice(value, field)
 	if err != nil {
@@ -0,0 +1,180 @@
+package requestspec
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ProjectFile holds project-level defaults shared by every request spec.
+const ProjectFile = "wirepad.yaml"
+
+// Project is the decoded wirepad.yaml. Request holds defaults that apply to
+// every spec unless the spec sets the same field.
+type Project struct {
+	Version int
+	Request *Request
+	History *ProjectHistory
+}
+
+// ProjectHistory configures what run records keep.
+type ProjectHistory struct {
+	// MaxBodyBytes caps the response body stored per run; 0 stores it whole.
+	MaxBodyBytes int
+}
+
+// LoadProject reads path, returning an empty project when the file is missing.
+// Relative file paths inside the project are resolved against its directory.
+func LoadProject(path string) (*Project, []Issue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Project{}, nil, nil
+		}
+		return nil, nil, fmt.Errorf("read project file %q: %w", path, err)
+	}
+
+	raw, err := parseYAMLObject(string(data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("load project file %q: parse yaml: %w", path, err)
+	}
+
+	project := &Project{}
+	if v, ok := raw["version"]; ok {
+		version, err := asInt(v, "version")
+		if err != nil {
+			return nil, nil, fmt.Errorf("load project file %q: %w", path, err)
+		}
+		project.Version = version
+	}
+
+	if v, ok := raw["request"]; ok {
+		requestMap, err := asMap(v, "request")
+		if err != nil {
+			return nil, nil, fmt.Errorf("load project file %q: %w", path, err)
+		}
+		request, err := decodeRequest(requestMap)
+		if err != nil {
+			return nil, nil, fmt.Errorf("load project file %q: %w", path, err)
+		}
+		project.Request = request
+	}
+
+	if v, ok := raw["history"]; ok {
+		historyMap, err := asMap(v, "history")
+		if err != nil {
+			return nil, nil, fmt.Errorf("load project file %q: %w", path, err)
+		}
+		settings, err := decodeProjectHistory(historyMap)
+		if err != nil {
+			return nil, nil, fmt.Errorf("load project file %q: %w", path, err)
+		}
+		project.History = settings
+	}
+
+	var issues []Issue
+	add := func(severity Severity, field, message, hint string) {
+		issues = append(issues, Issue{Severity: severity, Field: field, Message: message, Hint: hint})
+	}
+	if project.Request != nil {
+		validateTLS(project.Request.TLS, "request.tls", add)
+		validateRetry(project.Request.Retry, add)
+		validateParams(project.Request, add)
+	}
+
+	var warnings []Issue
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			return nil, nil, &ValidationError{Path: path, Issues: issues}
+		}
+		issue.Field = path + ": " + issue.Field
+		warnings = append(warnings, issue)
+	}
+
+	baseDir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, nil, fmt.Errorf("resolve project directory: %w", err)
+	}
+	if project.Request != nil && project.Request.TLS != nil {
+		tlsConfig := project.Request.TLS
+		tlsConfig.CAFile = absoluteFrom(baseDir, tlsConfig.CAFile)
+		tlsConfig.CertFile = absoluteFrom(baseDir, tlsConfig.CertFile)
+		tlsConfig.KeyFile = absoluteFrom(baseDir, tlsConfig.KeyFile)
+	}
+
+	return project, warnings, nil
+}
+
+func decodeProjectHistory(raw map[string]any) (*ProjectHistory, error) {
+	settings := &ProjectHistory{}
+	if v, ok := raw["max_body_bytes"]; ok {
+		limit, err := asInt(v, "history.max_body_bytes")
+		if err != nil {
+			return nil, err
+		}
+		if limit < 0 {
+			return nil, fmt.Errorf("history.max_body_bytes must be >= 0")
+		}
+		settings.MaxBodyBytes = limit
+	}
+	return settings, nil
+}
+
+// Apply fills fields the spec leaves unset with the project defaults.
+func (p *Project) Apply(spec *Spec) {
+	if p == nil || p.Request == nil || spec == nil || spec.Request == nil {
+		return
+	}
+
+	spec.Request.TLS = mergeTLS(p.Request.TLS, spec.Request.TLS)
+	if spec.Request.Proxy == "" {
+		spec.Request.Proxy = p.Request.Proxy
+	}
+	if spec.Request.Retry == nil {
+		spec.Request.Retry = p.Request.Retry
+	}
+	if spec.Request.Cookies == nil {
+		spec.Request.Cookies = p.Request.Cookies
+	}
+	if spec.Request.HTTPVersion == "" {
+		spec.Request.HTTPVersion = p.Request.HTTPVersion
+	}
+	if spec.Request.ArrayFormat == "" {
+		spec.Request.ArrayFormat = p.Request.ArrayFormat
+	}
+}
+
+func mergeTLS(base, override *TLS) *TLS {
+	if base == nil {
+		return override
+	}
+	merged := *base
+	if override == nil {
+		return &merged
+	}
+
+	if override.CAFile != "" {
+		merged.CAFile = override.CAFile
+	}
+	if override.CertFile != "" {
+		merged.CertFile = override.CertFile
+		merged.KeyFile = override.KeyFile
+	}
+	if override.InsecureSkipVerify != nil {
+		merged.InsecureSkipVerify = override.InsecureSkipVerify
+	}
+	if override.ServerName != "" {
+		merged.ServerName = override.ServerName
+	}
+	if override.MinVersion != "" {
+		merged.MinVersion = override.MinVersion
+	}
+	return &merged
+}
+
+func absoluteFrom(baseDir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(baseDir, path)
+}
@@ -0,0 +1,53 @@
+package requestspec
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadProject_AppliesTLSDefaults(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, ProjectFile)
+	writeFile(t, path, `
+version: 1
+request:
+  tls:
+    ca_file: certs/internal-ca.pem
+    min_version: "1.2"
+    server_name: api.internal
+`)
+
+	project, warnings, err := LoadProject(path)
+	if err != nil {
+		t.Fatalf("LoadProject returned error: %v", err)
+	}
+	if len(warnings) != 0 {
+		t.Fatalf("expected no warnings, got %+v", warnings)
+	}
+
+	spec := &Spec{Request: &Request{TLS: &TLS{ServerName: "override.internal"}}}
+	project.Apply(spec)
+
+	if spec.Request.TLS.CAFile != filepath.Join(root, "certs", "internal-ca.pem") {
+		t.Fatalf("expected project ca_file resolved against project dir, got %q", spec.Request.TLS.CAFile)
+	}
+	if spec.Request.TLS.MinVersion != "1.2" {
+		t.Fatalf("expected project min_version, got %q", spec.Request.TLS.MinVersion)
+	}
+	if spec.Request.TLS.ServerName != "override.internal" {
+		t.Fatalf("expected request-level server_name to win, got %q", spec.Request.TLS.ServerName)
+	}
+}
+
+func TestLoadProject_MissingFileIsEmpty(t *testing.T) {
+	project, _, err := LoadProject(filepath.Join(t.TempDir(), ProjectFile))
+	if err != nil {
+		t.Fatalf("LoadProject returned error: %v", err)
+	}
+
+	spec := &Spec{Request: &Request{}}
+	project.Apply(spec)
+	if spec.Request.TLS != nil {
+		t.Fatalf("expected no TLS defaults, got %+v", spec.Request.TLS)
+	}
+}
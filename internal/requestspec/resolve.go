@@ -8,6 +8,9 @@ import (
 	"strings"
 )
 
+// RequestsDir is the conventional root of the request library.
+const RequestsDir = "requests"
+
 func ResolvePath(ref string) (string, error) {
 	ref = filepath.Clean(strings.TrimSpace(ref))
 	if ref == "." || ref == "" {
@@ -24,7 +27,7 @@ func ResolvePath(ref string) (string, error) {
 		return "", fmt.Errorf("request file %q not found", ref)
 	}
 
-	direct := filepath.Join("requests", filepath.FromSlash(ref)+".req.yaml")
+	direct := filepath.Join(RequestsDir, filepath.FromSlash(ref)+".req.yaml")
 	if path, ok, err := existingFile(direct); err != nil {
 		return "", err
 	} else if ok {
@@ -32,19 +35,7 @@ func ResolvePath(ref string) (string, error) {
 	}
 
 	needle := filepath.FromSlash(ref + ".req.yaml")
-	var matches []string
-	err := filepath.WalkDir("requests", func(path string, d fs.DirEntry, walkErr error) error {
-		if walkErr != nil {
-			return walkErr
-		}
-		if d.IsDir() {
-			return nil
-		}
-		if strings.HasSuffix(path, needle) {
-			matches = append(matches, path)
-		}
-		return nil
-	})
+	files, err := FindFiles(RequestsDir)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return "", fmt.Errorf("request %q not found (requests directory is missing)", ref)
@@ -52,6 +43,13 @@ func ResolvePath(ref string) (string, error) {
 		return "", fmt.Errorf("walk requests directory: %w", err)
 	}
 
+	var matches []string
+	for _, path := range files {
+		if strings.HasSuffix(path, needle) {
+			matches = append(matches, path)
+		}
+	}
+
 	if len(matches) == 0 {
 		return "", fmt.Errorf("request %q not found", ref)
 	}
@@ -61,6 +59,27 @@ func ResolvePath(ref string) (string, error) {
 	return matches[0], nil
 }
 
+// FindFiles walks root and returns every .req.yaml file in lexical order.
+func FindFiles(root string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if strings.HasSuffix(path, ".req.yaml") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
 func existingFile(path string) (string, bool, error) {
 	info, err := os.Stat(path)
 	if err != nil {
@@ -74,3 +93,24 @@ func existingFile(path string) (string, bool, error) {
 	}
 	return path, true, nil
 }
+
+// RoutePath returns the path of a request URL with its {{var}} placeholders
+// kept. A leading {{base_url}}-style placeholder and any scheme and host are
+// dropped, as are the query, the fragment and a trailing slash.
+func RoutePath(rawURL string) string {
+	path := strings.TrimSpace(rawURL)
+	if end := strings.Index(path, "}}"); strings.HasPrefix(path, "{{") && end >= 0 {
+		path = path[end+2:]
+	} else if i := strings.Index(path, "://"); i >= 0 {
+		rest := path[i+3:]
+		if j := strings.Index(rest, "/"); j >= 0 {
+			path = rest[j:]
+		} else {
+			path = "/"
+		}
+	}
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+	return "/" + strings.Trim(path, "/")
+}
@@ -73,3 +73,16 @@ func writeFile(t *testing.T, path, content string) {
 		t.Fatalf("write %s: %v", path, err)
 	}
 }
+
+func TestRoutePath(t *testing.T) {
+	for raw, want := range map[string]string{
+		"{{base_url}}/users/{{id}}?a=1":  "/users/{{id}}",
+		"https://api.example.com":        "/",
+		"http://{{host}}:8080/v1/items/": "/v1/items",
+		"/health#top":                    "/health",
+	} {
+		if got := RoutePath(raw); got != want {
+			t.Fatalf("RoutePath(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
@@ -5,6 +5,7 @@ type Kind string
 const (
 	KindHTTP Kind = "http"
 	KindWS   Kind = "ws"
+	KindGRPC Kind = "grpc"
 )
 
 type Spec struct {
@@ -16,6 +17,11 @@ type Spec struct {
 	Request     *Request       `yaml:"request"`
 	Expect      map[string]any `yaml:"expect,omitempty"`
 	Hooks       map[string]any `yaml:"hooks,omitempty"`
+	// Data is a .csv or .json dataset, relative to the request file; the
+	// request is sent once per row.
+	Data string `yaml:"data,omitempty"`
+	// Mock is the response wirepad mock serves for the request.
+	Mock *Mock `yaml:"mock,omitempty"`
 }
 
 type Request struct {
@@ -29,6 +35,21 @@ type Request struct {
 	ConnectTimeoutMS int            `yaml:"connect_timeout_ms,omitempty"`
 	PingIntervalMS   int            `yaml:"ping_interval_ms,omitempty"`
 	Messages         []WSMessage    `yaml:"messages,omitempty"`
+	TLS              *TLS           `yaml:"tls,omitempty"`
+	Proxy            string         `yaml:"proxy,omitempty"`
+	Retry            *Retry         `yaml:"retry,omitempty"`
+	Auth             *Auth          `yaml:"auth,omitempty"`
+	Cookies          *bool          `yaml:"cookies,omitempty"`
+	ArrayFormat      string         `yaml:"array_format,omitempty"`
+	HTTPVersion      string         `yaml:"http_version,omitempty"`
+	Service          string         `yaml:"service,omitempty"`
+	Message          any            `yaml:"message,omitempty"`
+	Metadata         map[string]any `yaml:"metadata,omitempty"`
+	Proto            []string       `yaml:"proto,omitempty"`
+	ImportPaths      []string       `yaml:"import_paths,omitempty"`
+	DescriptorSet    string         `yaml:"descriptor_set,omitempty"`
+	// QueryOrder is the order request.query keys were declared in.
+	QueryOrder []string `yaml:"-"`
 }
 
 type Body struct {
@@ -39,6 +60,12 @@ type Body struct {
 	ContentType string         `yaml:"content_type,omitempty"`
 	Form        map[string]any `yaml:"form,omitempty"`
 	Multipart   []any          `yaml:"multipart,omitempty"`
+	Compress    string         `yaml:"compress,omitempty"`
+	// Query, QueryPath, Variables and OperationName make up a graphql body.
+	Query         string `yaml:"query,omitempty"`
+	QueryPath     string `yaml:"query_path,omitempty"`
+	Variables     any    `yaml:"variables,omitempty"`
+	OperationName string `yaml:"operation_name,omitempty"`
 }
 
 type WSMessage struct {
@@ -47,3 +74,70 @@ type WSMessage struct {
 	Text string `yaml:"text,omitempty"`
 	Path string `yaml:"path,omitempty"`
 }
+
+type TLS struct {
+	CAFile             string `yaml:"ca_file,omitempty"`
+	CertFile           string `yaml:"cert_file,omitempty"`
+	KeyFile            string `yaml:"key_file,omitempty"`
+	InsecureSkipVerify *bool  `yaml:"insecure_skip_verify,omitempty"`
+	ServerName         string `yaml:"server_name,omitempty"`
+	MinVersion         string `yaml:"min_version,omitempty"`
+}
+
+// Mock is a canned response for wirepad mock. Without one, mock replays the
+// latest successful run of the request.
+type Mock struct {
+	Status   int            `yaml:"status,omitempty"`
+	Headers  map[string]any `yaml:"headers,omitempty"`
+	JSON     any            `yaml:"json,omitempty"`
+	Body     string         `yaml:"body,omitempty"`
+	BodyPath string         `yaml:"body_path,omitempty"`
+	// LatencyMS delays the response; a larger LatencyMaxMS makes the delay
+	// random between the two.
+	LatencyMS    int `yaml:"latency_ms,omitempty"`
+	LatencyMaxMS int `yaml:"latency_max_ms,omitempty"`
+	// ErrorRate is the fraction of requests (0 to 1) answered with
+	// ErrorStatus instead.
+	ErrorRate   float64 `yaml:"error_rate,omitempty"`
+	ErrorStatus int     `yaml:"error_status,omitempty"`
+}
+
+type Retry struct {
+	Attempts          int      `yaml:"attempts"`
+	Backoff           string   `yaml:"backoff,omitempty"`
+	DelayMS           int      `yaml:"delay_ms,omitempty"`
+	MaxDelayMS        int      `yaml:"max_delay_ms,omitempty"`
+	Jitter            *bool    `yaml:"jitter,omitempty"`
+	OnStatus          []int    `yaml:"on_status,omitempty"`
+	OnError           []string `yaml:"on_error,omitempty"`
+	RespectRetryAfter *bool    `yaml:"respect_retry_after,omitempty"`
+}
+
+// Auth configures credentials applied by httpclient just before a request is
+// sent. Which fields apply depends on Type.
+type Auth struct {
+	Type string `yaml:"type"`
+	// basic, digest
+	Username string `yaml:"username,omitempty"`
+	Password string `yaml:"password,omitempty"`
+	// bearer
+	Token string `yaml:"token,omitempty"`
+	// api_key
+	Name  string `yaml:"name,omitempty"`
+	Value string `yaml:"value,omitempty"`
+	In    string `yaml:"in,omitempty"`
+	// aws_sigv4
+	AccessKeyID     string `yaml:"access_key_id,omitempty"`
+	SecretAccessKey string `yaml:"secret_access_key,omitempty"`
+	SessionToken    string `yaml:"session_token,omitempty"`
+	Region          string `yaml:"region,omitempty"`
+	Service         string `yaml:"service,omitempty"`
+	// oauth2; password grant also uses Username and Password
+	Grant        string   `yaml:"grant,omitempty"`
+	TokenURL     string   `yaml:"token_url,omitempty"`
+	ClientID     string   `yaml:"client_id,omitempty"`
+	ClientSecret string   `yaml:"client_secret,omitempty"`
+	ClientAuth   string   `yaml:"client_auth,omitempty"`
+	Scopes       []string `yaml:"scopes,omitempty"`
+	RefreshToken string   `yaml:"refresh_token,omitempty"`
+}
@@ -2,6 +2,7 @@ package requestspec
 
 import (
 	"fmt"
+	"path/filepath"
 	"slices"
 	"strings"
 )
@@ -62,15 +63,23 @@ func Validate(spec *Spec, raw map[string]any, strict bool) []Issue {
 	}
 
 	if strings.TrimSpace(string(spec.Kind)) == "" {
-		add(SeverityError, "kind", "missing required field", "expected one of [http, ws]")
-	} else if spec.Kind != KindHTTP && spec.Kind != KindWS {
-		add(SeverityError, "kind", "invalid kind", "expected one of [http, ws]")
+		add(SeverityError, "kind", "missing required field", "expected one of [http, ws, grpc]")
+	} else if spec.Kind != KindHTTP && spec.Kind != KindWS && spec.Kind != KindGRPC {
+		add(SeverityError, "kind", "invalid kind", "expected one of [http, ws, grpc]")
 	}
 
 	if strings.TrimSpace(spec.Name) == "" {
 		add(SeverityError, "name", "missing required field", "set a stable request name")
 	}
 
+	if spec.Data != "" {
+		if ext := strings.ToLower(filepath.Ext(spec.Data)); ext != ".csv" && ext != ".json" {
+			add(SeverityError, "data", "unsupported data file", "expected a .csv or .json file")
+		} else if spec.Kind == KindWS {
+			add(SeverityError, "data", "data is not supported for kind=ws", "remove data or use kind=http")
+		}
+	}
+
 	if spec.Request == nil {
 		add(SeverityError, "request", "missing required field", "define request block")
 	} else {
@@ -81,10 +90,25 @@ func Validate(spec *Spec, raw map[string]any, strict bool) []Issue {
 		if spec.Kind == KindHTTP && strings.TrimSpace(spec.Request.Method) == "" {
 			add(SeverityError, "request.method", "missing required field", "kind=http requires request.method")
 		}
+		if spec.Request.Body != nil && spec.Request.Body.Mode == "graphql" && !strings.EqualFold(spec.Request.Method, "POST") {
+			add(SeverityError, "request.method", "graphql body requires POST", "set request.method: POST")
+		}
+
+		if spec.Kind == KindGRPC {
+			validateGRPC(spec.Request, add)
+		}
+		if spec.Kind == KindWS && strings.TrimSpace(spec.Request.Proxy) != "" {
+			add(SeverityWarning, "request.proxy", "proxy is not used for kind=ws yet", "wirepad has no WebSocket transport yet; the setting takes effect once it does")
+		}
 
 		validateBody(spec.Request.Body, add)
 		validateWSMessages(spec.Request.Messages, add)
+		validateTLS(spec.Request.TLS, "request.tls", add)
+		validateRetry(spec.Request.Retry, add)
+		validateAuth(spec.Request.Auth, add)
+		validateParams(spec.Request, add)
 	}
+	validateMock(spec, add)
 
 	for _, issue := range unknownFieldIssues(raw, strict) {
 		add(issue.Severity, issue.Field, issue.Message, issue.Hint)
@@ -93,22 +117,55 @@ func Validate(spec *Spec, raw map[string]any, strict bool) []Issue {
 	return issues
 }
 
+func validateGRPC(req *Request, add func(Severity, string, string, string)) {
+	if strings.TrimSpace(req.Service) == "" {
+		add(SeverityError, "request.service", "missing required field", "kind=grpc requires request.service, e.g. helloworld.Greeter")
+	}
+	if strings.TrimSpace(req.Method) == "" {
+		add(SeverityError, "request.method", "missing required field", "kind=grpc requires the rpc name in request.method")
+	}
+	switch {
+	case len(req.Proto) == 0 && req.DescriptorSet == "":
+		add(SeverityError, "request.proto", "missing descriptors", "set request.proto files or request.descriptor_set")
+	case len(req.Proto) > 0 && req.DescriptorSet != "":
+		add(SeverityError, "request.descriptor_set", "conflicts with request.proto", "use either .proto files or a descriptor set")
+	}
+	if req.Message != nil {
+		if _, ok := req.Message.(map[string]any); !ok {
+			add(SeverityError, "request.message", "must be an object", "write the request message as JSON-style fields")
+		}
+	}
+	// Only credentials that fit in a header can travel as gRPC metadata.
+	if auth := req.Auth; auth != nil {
+		switch {
+		case auth.Type == "api_key" && auth.In == "query":
+			add(SeverityError, "request.auth.in", "not supported for kind=grpc", "gRPC has no query string; send the key in a header with in: header")
+		case auth.Type != "basic" && auth.Type != "bearer" && auth.Type != "api_key" && slices.Contains(validAuthTypes, auth.Type):
+			add(SeverityError, "request.auth.type", "not supported for kind=grpc", "use basic, bearer or api_key, or set the header in request.metadata")
+		}
+	}
+}
+
 func validateBody(body *Body, add func(Severity, string, string, string)) {
 	if body == nil {
 		return
 	}
 
 	if strings.TrimSpace(body.Mode) == "" {
-		add(SeverityError, "request.body.mode", "missing required field", "expected one of [json, raw, file, form, multipart]")
+		add(SeverityError, "request.body.mode", "missing required field", "expected one of [json, raw, file, form, multipart, graphql]")
 		return
 	}
 
-	validModes := []string{"json", "raw", "file", "form", "multipart"}
+	validModes := []string{"json", "raw", "file", "form", "multipart", "graphql"}
 	if !slices.Contains(validModes, body.Mode) {
-		add(SeverityError, "request.body.mode", "invalid body mode", "expected one of [json, raw, file, form, multipart]")
+		add(SeverityError, "request.body.mode", "invalid body mode", "expected one of [json, raw, file, form, multipart, graphql]")
 		return
 	}
 
+	if body.Compress != "" && body.Compress != "gzip" {
+		add(SeverityError, "request.body.compress", "unsupported compression", "expected gzip")
+	}
+
 	switch body.Mode {
 	case "json":
 		if body.JSON == nil {
@@ -130,6 +187,18 @@ func validateBody(body *Body, add func(Severity, string, string, string)) {
 		if len(body.Multipart) == 0 {
 			add(SeverityError, "request.body.multipart", "missing parts for multipart mode", "set request.body.multipart")
 		}
+	case "graphql":
+		switch {
+		case strings.TrimSpace(body.Query) == "" && strings.TrimSpace(body.QueryPath) == "":
+			add(SeverityError, "request.body.query", "missing query for graphql mode", "set request.body.query or request.body.query_path")
+		case body.Query != "" && body.QueryPath != "":
+			add(SeverityError, "request.body.query_path", "conflicts with request.body.query", "use either an inline query or a .graphql file")
+		}
+		if body.Variables != nil {
+			if _, ok := body.Variables.(map[string]any); !ok {
+				add(SeverityError, "request.body.variables", "must be an object", "map variable names to values")
+			}
+		}
 	}
 }
 
@@ -165,12 +234,196 @@ func validateWSMessages(messages []WSMessage, add func(Severity, string, string,
 	}
 }
 
+var validTLSVersions = []string{"1.0", "1.1", "1.2", "1.3"}
+
+func validateTLS(cfg *TLS, prefix string, add func(Severity, string, string, string)) {
+	if cfg == nil {
+		return
+	}
+
+	if (cfg.CertFile == "") != (cfg.KeyFile == "") {
+		add(SeverityError, prefix+".cert_file", "cert_file and key_file must be set together", "set both for client certificate (mTLS) authentication")
+	}
+
+	if cfg.MinVersion != "" && !slices.Contains(validTLSVersions, cfg.MinVersion) {
+		add(SeverityError, prefix+".min_version", "invalid TLS version", "expected one of [1.0, 1.1, 1.2, 1.3]")
+	}
+
+	if cfg.InsecureSkipVerify != nil && *cfg.InsecureSkipVerify {
+		add(SeverityWarning, prefix+".insecure_skip_verify", "TLS certificate verification is DISABLED; responses can be intercepted", "use ca_file to trust a private CA instead")
+	}
+}
+
+func validateMock(spec *Spec, add func(Severity, string, string, string)) {
+	mock := spec.Mock
+	if mock == nil {
+		return
+	}
+
+	if spec.Kind != KindHTTP {
+		add(SeverityError, "mock", "mock is only supported for kind=http", "remove the mock block")
+	}
+	if mock.Status != 0 && (mock.Status < 100 || mock.Status > 599) {
+		add(SeverityError, "mock.status", "invalid HTTP status", "expected a code between 100 and 599")
+	}
+	if mock.ErrorStatus != 0 && (mock.ErrorStatus < 100 || mock.ErrorStatus > 599) {
+		add(SeverityError, "mock.error_status", "invalid HTTP status", "expected a code between 100 and 599")
+	}
+	bodies := 0
+	for _, set := range []bool{mock.JSON != nil, mock.Body != "", mock.BodyPath != ""} {
+		if set {
+			bodies++
+		}
+	}
+	if bodies > 1 {
+		add(SeverityError, "mock", "conflicting response bodies", "set only one of json, body or body_path")
+	}
+	if mock.LatencyMS < 0 || mock.LatencyMaxMS < 0 {
+		add(SeverityError, "mock.latency_ms", "latency must not be negative", "")
+	} else if mock.LatencyMaxMS != 0 && mock.LatencyMaxMS < mock.LatencyMS {
+		add(SeverityError, "mock.latency_max_ms", "latency_max_ms is below latency_ms", "set latency_max_ms at or above latency_ms")
+	}
+	if mock.ErrorRate < 0 || mock.ErrorRate > 1 {
+		add(SeverityError, "mock.error_rate", "error_rate out of range", "expected a fraction between 0 and 1, e.g. 0.1")
+	}
+}
+
+// RetryErrorKinds are the transport failures request.retry.on_error accepts.
+var RetryErrorKinds = []string{"timeout", "connection_reset", "connection_refused", "dns"}
+
+func validateRetry(retry *Retry, add func(Severity, string, string, string)) {
+	if retry == nil {
+		return
+	}
+
+	if retry.Attempts < 1 {
+		add(SeverityError, "request.retry.attempts", "attempts must be at least 1", "attempts counts the first try, e.g. attempts: 3")
+	}
+
+	if retry.Backoff != "" && retry.Backoff != "fixed" && retry.Backoff != "exponential" {
+		add(SeverityError, "request.retry.backoff", "invalid backoff", "expected one of [fixed, exponential]")
+	}
+
+	if retry.DelayMS < 0 || retry.MaxDelayMS < 0 {
+		add(SeverityError, "request.retry.delay_ms", "delays must not be negative", "")
+	}
+
+	for i, status := range retry.OnStatus {
+		if status < 100 || status > 599 {
+			add(SeverityError, fmt.Sprintf("request.retry.on_status[%d]", i), "invalid HTTP status", "expected a code between 100 and 599")
+		}
+	}
+
+	for i, kind := range retry.OnError {
+		if !slices.Contains(RetryErrorKinds, kind) {
+			add(SeverityError, fmt.Sprintf("request.retry.on_error[%d]", i), "invalid error kind", "expected one of ["+strings.Join(RetryErrorKinds, ", ")+"]")
+		}
+	}
+}
+
+var (
+	validAuthTypes   = []string{"basic", "bearer", "digest", "api_key", "aws_sigv4", "oauth2"}
+	validOAuthGrants = []string{"client_credentials", "refresh_token", "password"}
+)
+
+func validateAuth(auth *Auth, add func(Severity, string, string, string)) {
+	if auth == nil {
+		return
+	}
+
+	hint := "expected one of [" + strings.Join(validAuthTypes, ", ") + "]"
+	if strings.TrimSpace(auth.Type) == "" {
+		add(SeverityError, "request.auth.type", "missing required field", hint)
+		return
+	}
+	if !slices.Contains(validAuthTypes, auth.Type) {
+		add(SeverityError, "request.auth.type", "invalid auth type", hint)
+		return
+	}
+
+	require := func(field, value string) {
+		if strings.TrimSpace(value) == "" {
+			add(SeverityError, "request.auth."+field, "missing required field", "auth type "+auth.Type+" requires "+field)
+		}
+	}
+
+	switch auth.Type {
+	case "basic", "digest":
+		require("username", auth.Username)
+	case "bearer":
+		require("token", auth.Token)
+	case "api_key":
+		require("name", auth.Name)
+		require("value", auth.Value)
+		if auth.In != "" && auth.In != "header" && auth.In != "query" {
+			add(SeverityError, "request.auth.in", "invalid api_key location", "expected one of [header, query]")
+		}
+	case "aws_sigv4":
+		require("access_key_id", auth.AccessKeyID)
+		require("secret_access_key", auth.SecretAccessKey)
+		require("region", auth.Region)
+		require("service", auth.Service)
+	case "oauth2":
+		require("token_url", auth.TokenURL)
+		require("client_id", auth.ClientID)
+		switch auth.Grant {
+		case "":
+			add(SeverityError, "request.auth.grant", "missing required field", "expected one of ["+strings.Join(validOAuthGrants, ", ")+"]")
+		case "client_credentials":
+		case "refresh_token":
+			require("refresh_token", auth.RefreshToken)
+		case "password":
+			require("username", auth.Username)
+		default:
+			add(SeverityError, "request.auth.grant", "invalid oauth2 grant", "expected one of ["+strings.Join(validOAuthGrants, ", ")+"]")
+		}
+		if auth.ClientAuth != "" && auth.ClientAuth != "basic" && auth.ClientAuth != "body" {
+			add(SeverityError, "request.auth.client_auth", "invalid client_auth", "expected one of [basic, body]")
+		}
+	}
+}
+
 type schemaNode struct {
 	children map[string]*schemaNode
 	elem     *schemaNode
 	allowAny bool
 }
 
+// HTTPVersions are the accepted request.http_version values.
+var HTTPVersions = []string{"1.1", "2", "h2c"}
+
+// ArrayFormats are the accepted request.array_format values.
+var ArrayFormats = []string{"repeat", "comma", "brackets"}
+
+func validateParams(req *Request, add func(Severity, string, string, string)) {
+	if req.HTTPVersion != "" && !slices.Contains(HTTPVersions, req.HTTPVersion) {
+		add(SeverityError, "request.http_version", "unsupported http version", "expected one of [1.1, 2, h2c]")
+	}
+	if req.ArrayFormat != "" && !slices.Contains(ArrayFormats, req.ArrayFormat) {
+		add(SeverityError, "request.array_format", "invalid array format", "expected one of [repeat, comma, brackets]")
+	}
+
+	for _, field := range []struct {
+		name   string
+		values map[string]any
+	}{{"request.query", req.Query}, {"request.headers", req.Headers}} {
+		for key, value := range field.values {
+			items, ok := value.([]any)
+			if !ok {
+				items = []any{value}
+			}
+			for _, item := range items {
+				_, isMap := item.(map[string]any)
+				_, isList := item.([]any)
+				if isMap || isList {
+					add(SeverityError, field.name+"."+key, "value must be a scalar or a list of scalars", "")
+					break
+				}
+			}
+		}
+	}
+}
+
 func unknownFieldIssues(raw map[string]any, strict bool) []Issue {
 	var issues []Issue
 	if raw == nil {
@@ -233,16 +486,51 @@ func requestFileSchema() *schemaNode {
 
 	bodySchema := &schemaNode{
 		children: map[string]*schemaNode{
-			"mode":         scalarSchema(),
-			"json":         anyMap,
-			"raw":          scalarSchema(),
-			"path":         scalarSchema(),
-			"content_type": scalarSchema(),
-			"form":         anyMap,
-			"multipart":    sequenceSchema(anyMap),
+			"mode":           scalarSchema(),
+			"json":           anyMap,
+			"raw":            scalarSchema(),
+			"path":           scalarSchema(),
+			"content_type":   scalarSchema(),
+			"form":           anyMap,
+			"multipart":      sequenceSchema(anyMap),
+			"compress":       scalarSchema(),
+			"query":          scalarSchema(),
+			"query_path":     scalarSchema(),
+			"variables":      anyMap,
+			"operation_name": scalarSchema(),
+		},
+	}
+
+	tlsSchema := &schemaNode{
+		children: map[string]*schemaNode{
+			"ca_file":              scalarSchema(),
+			"cert_file":            scalarSchema(),
+			"key_file":             scalarSchema(),
+			"insecure_skip_verify": scalarSchema(),
+			"server_name":          scalarSchema(),
+			"min_version":          scalarSchema(),
 		},
 	}
 
+	retrySchema := &schemaNode{
+		children: map[string]*schemaNode{
+			"attempts":            scalarSchema(),
+			"backoff":             scalarSchema(),
+			"delay_ms":            scalarSchema(),
+			"max_delay_ms":        scalarSchema(),
+			"jitter":              scalarSchema(),
+			"on_status":           sequenceSchema(scalarSchema()),
+			"on_error":            sequenceSchema(scalarSchema()),
+			"respect_retry_after": scalarSchema(),
+		},
+	}
+
+	authSchema := &schemaNode{children: map[string]*schemaNode{}}
+	for _, field := range []string{"type", "username", "password", "token", "name", "value", "in", "access_key_id", "secret_access_key", "session_token", "region", "service", "grant", "token_url", "client_id", "client_secret", "client_auth", "refresh_token"} {
+		authSchema.children[field] = scalarSchema()
+	}
+	authSchema.children["scopes"] = sequenceSchema(scalarSchema())
+
 	requestSchema := &schemaNode{
 		children: map[string]*schemaNode{
 			"method":             scalarSchema(),
@@ -255,6 +543,19 @@ func requestFileSchema() *schemaNode {
 			"connect_timeout_ms": scalarSchema(),
 			"ping_interval_ms":   scalarSchema(),
 			"messages":           sequenceSchema(messageSchema),
+			"tls":                tlsSchema,
+			"proxy":              scalarSchema(),
+			"retry":              retrySchema,
+			"auth":               authSchema,
+			"cookies":            scalarSchema(),
+			"array_format":       scalarSchema(),
+			"http_version":       scalarSchema(),
+			"service":            scalarSchema(),
+			"message":            anyMap,
+			"metadata":           anyMap,
+			"proto":              &schemaNode{allowAny: true},
+			"import_paths":       sequenceSchema(scalarSchema()),
+			"descriptor_set":     scalarSchema(),
 		},
 	}
 
@@ -268,6 +569,20 @@ func requestFileSchema() *schemaNode {
 			"request":     requestSchema,
 			"expect":      anyMap,
 			"hooks":       anyMap,
+			"data":        scalarSchema(),
+			"mock": {
+				children: map[string]*schemaNode{
+					"status":         scalarSchema(),
+					"headers":        anyMap,
+					"json":           anyMap,
+					"body":           scalarSchema(),
+					"body_path":      scalarSchema(),
+					"latency_ms":     scalarSchema(),
+					"latency_max_ms": scalarSchema(),
+					"error_rate":     scalarSchema(),
+					"error_status":   scalarSchema(),
+				},
+			},
 		},
 	}
 }
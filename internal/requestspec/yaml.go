@@ -0,0 +1,1114 @@
+package requestspec
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// tabWidth is the column a tab in indentation advances to the next multiple
+// of. YAML forbids tabs there, but editors insert them.
+const tabWidth = 4
+
+// maxAliasValues caps the values aliases may copy into one document, so a
+// chain of anchors doubling each other cannot exhaust memory.
+const maxAliasValues = 100_000
+
+var (
+	yamlInt   = regexp.MustCompile(`^[-+]?[0-9]+$`)
+	yamlFloat = regexp.MustCompile(`^[-+]?(\.[0-9]+|[0-9]+(\.[0-9]*)?)([eE][-+]?[0-9]+)?$`)
+)
+
+type yamlLine struct {
+	number int
+	indent int
+	// raw is the line after its indentation; text also drops trailing space.
+	raw  string
+	text string
+}
+
+// blank reports whether the line is empty or only a comment.
+func (l yamlLine) blank() bool {
+	return l.text == "" || l.text[0] == '#'
+}
+
+func (l yamlLine) marker(m string) bool {
+	return l.indent == 0 && (l.text == m || strings.HasPrefix(l.text, m+" ") || strings.HasPrefix(l.text, m+"\t"))
+}
+
+// yamlParser reads the block and flow styles of a single YAML document:
+// mappings, sequences, plain, quoted and block scalars, anchors, aliases and
+// merge keys. Tags and complex keys are not supported.
+type yamlParser struct {
+	lines   []yamlLine
+	i       int
+	anchors map[string]any
+	// aliased counts the values copied by aliases so far.
+	aliased int
+	// order, when set, records mapping keys in document order by key path.
+	order map[string][]string
+}
+
+func newYAMLParser(input string) *yamlParser {
+	input = strings.TrimPrefix(input, "\ufeff")
+	p := &yamlParser{anchors: make(map[string]any)}
+	for n, line := range strings.Split(input, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		indent, width := 0, 0
+		for ; width < len(line); width++ {
+			if line[width] == ' ' {
+				indent++
+			} else if line[width] == '\t' {
+				indent += tabWidth - indent%tabWidth
+			} else {
+				break
+			}
+		}
+		raw := line[width:]
+		p.lines = append(p.lines, yamlLine{number: n + 1, indent: indent, raw: raw, text: strings.TrimRight(raw, " \t")})
+	}
+	return p
+}
+
+// parseYAMLObject parses input into a mapping. Plain scalars under one of
+// textFields (dotted key paths where "*" matches any key or sequence item)
+// keep their source text instead of being typed, so a header value of 1.0 is
+// sent as "1.0".
+func parseYAMLObject(input string, textFields ...string) (map[string]any, error) {
+	root, err := newYAMLParser(input).document()
+	if err != nil {
+		return nil, err
+	}
+	return settleScalars(root, nil, textFields).(map[string]any), nil
+}
+
+// mappingKeys returns the keys of the mapping at path in the order they
+// appear in input, since decoded maps lose it.
+func mappingKeys(input string, path ...string) []string {
+	p := newYAMLParser(input)
+	p.order = make(map[string][]string)
+	if _, err := p.document(); err != nil {
+		return nil
+	}
+	return p.order[strings.Join(path, "\x00")]
+}
+
+func (p *yamlParser) document() (map[string]any, error) {
+	p.skipBlank()
+	for p.i < len(p.lines) && (strings.HasPrefix(p.lines[p.i].text, "%YAML") || strings.HasPrefix(p.lines[p.i].text, "%TAG")) {
+		p.i++
+		p.skipBlank()
+	}
+	if p.i < len(p.lines) && p.lines[p.i].marker("---") {
+		if rest := strings.TrimSpace(p.lines[p.i].text[3:]); rest != "" && rest[0] != '#' {
+			return nil, fmt.Errorf("line %d: content after --- is not supported", p.lines[p.i].number)
+		}
+		p.i++
+		p.skipBlank()
+	}
+	if p.i >= len(p.lines) || p.lines[p.i].marker("...") {
+		return nil, fmt.Errorf("empty document")
+	}
+
+	value, err := p.block(-1, []string{})
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipBlank()
+	if p.i < len(p.lines) && p.lines[p.i].marker("...") {
+		p.i++
+		p.skipBlank()
+	}
+	if p.i < len(p.lines) {
+		if p.lines[p.i].marker("---") {
+			return nil, fmt.Errorf("line %d: multiple documents are not supported", p.lines[p.i].number)
+		}
+		return nil, fmt.Errorf("unexpected trailing content at line %d", p.lines[p.i].number)
+	}
+
+	root, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("root must be a mapping")
+	}
+	return root, nil
+}
+
+func (p *yamlParser) skipBlank() {
+	for p.i < len(p.lines) && p.lines[p.i].blank() {
+		p.i++
+	}
+}
+
+// atEnd reports whether no block content is left at the current line.
+func (p *yamlParser) atEnd() bool {
+	return p.i >= len(p.lines) || p.lines[p.i].marker("---") || p.lines[p.i].marker("...")
+}
+
+// block parses the node starting on the current line, which is indented more
+// than parent.
+func (p *yamlParser) block(parent int, path []string) (any, error) {
+	line := p.lines[p.i]
+	if isSequenceItem(line.text) {
+		return p.sequence(line.indent)
+	}
+	if _, _, ok := splitKey(line.text); ok {
+		return p.mapping(line.indent, path)
+	}
+	p.i++
+	return p.value(line, line.text, parent, false, path)
+}
+
+func (p *yamlParser) mapping(indent int, path []string) (map[string]any, error) {
+	out := make(map[string]any)
+	var merges []any
+
+	for {
+		p.skipBlank()
+		if p.atEnd() {
+			break
+		}
+		line := p.lines[p.i]
+		if line.indent < indent {
+			break
+		}
+		if line.indent > indent {
+			return nil, fmt.Errorf("line %d: unexpected indentation", line.number)
+		}
+		if isSequenceItem(line.text) {
+			break
+		}
+
+		key, rest, ok := splitKey(line.text)
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key: value pair", line.number)
+		}
+		p.i++
+
+		value, err := p.value(line, rest, indent, true, childPath(path, key))
+		if err != nil {
+			return nil, err
+		}
+		if key == "<<" {
+			merges = append(merges, value)
+			continue
+		}
+		if _, dup := out[key]; !dup {
+			p.record(path, key)
+		}
+		out[key] = value
+	}
+
+	if err := mergeKeys(out, merges); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (p *yamlParser) sequence(indent int) ([]any, error) {
+	out := []any{}
+
+	for {
+		p.skipBlank()
+		if p.atEnd() {
+			break
+		}
+		line := p.lines[p.i]
+		if line.indent < indent {
+			break
+		}
+		if line.indent > indent {
+			return nil, fmt.Errorf("line %d: unexpected indentation", line.number)
+		}
+		if !isSequenceItem(line.text) {
+			break
+		}
+
+		content := strings.TrimLeft(line.text[1:], " \t")
+		if content == "" || content[0] == '#' {
+			p.i++
+			value, err := p.nested(indent, false, nil)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, value)
+			continue
+		}
+
+		if _, _, ok := splitKey(content); ok || isSequenceItem(content) {
+			// A compact collection such as "- name: x" continues on the
+			// following lines at the column of its first entry.
+			col := line.indent + len(line.text) - len(content)
+			p.lines[p.i].indent, p.lines[p.i].raw, p.lines[p.i].text = col, content, content
+			value, err := p.block(indent, nil)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, value)
+			continue
+		}
+
+		p.i++
+		value, err := p.value(line, content, indent, false, nil)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, value)
+	}
+
+	return out, nil
+}
+
+// nested parses a value written on the lines after its key or dash: a block
+// indented more than parent or, for mapping values, a sequence at parent.
+func (p *yamlParser) nested(parent int, sequenceAtParent bool, path []string) (any, error) {
+	p.skipBlank()
+	if p.atEnd() {
+		return nil, nil
+	}
+	line := p.lines[p.i]
+	switch {
+	case line.indent > parent:
+		return p.block(parent, path)
+	case sequenceAtParent && line.indent == parent && isSequenceItem(line.text):
+		return p.sequence(parent)
+	}
+	return nil, nil
+}
+
+// value parses a node that starts inside line at text. Scalars may continue
+// on following lines indented more than parent.
+func (p *yamlParser) value(line yamlLine, text string, parent int, sequenceAtParent bool, path []string) (any, error) {
+	anchor := ""
+	if strings.HasPrefix(text, "&") {
+		anchor, text = cutToken(text[1:])
+		if anchor == "" {
+			return nil, fmt.Errorf("line %d: anchor needs a name", line.number)
+		}
+	}
+
+	var value any
+	var err error
+	switch {
+	case text == "" || text[0] == '#':
+		value, err = p.nested(parent, sequenceAtParent, path)
+	case text[0] == '*':
+		var name, rest string
+		name, rest = cutToken(text[1:])
+		if rest != "" && rest[0] != '#' {
+			return nil, fmt.Errorf("line %d: unexpected content after alias *%s", line.number, name)
+		}
+		value, err = p.alias(name, line.number)
+	case text[0] == '|' || text[0] == '>':
+		value, err = p.blockScalar(line, text, parent)
+	case strings.HasPrefix(text, "{{"):
+		// An unquoted {{var}} is a template, not a flow mapping.
+		value, err = p.plain(text, parent)
+	case text[0] == '[' || text[0] == '{':
+		value, err = p.flow(line, text, path)
+	case text[0] == '"' || text[0] == '\'':
+		value, err = p.quoted(line, text)
+	default:
+		value, err = p.plain(text, parent)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if anchor != "" {
+		p.anchors[anchor] = value
+	}
+	return value, nil
+}
+
+func (p *yamlParser) alias(name string, number int) (any, error) {
+	value, ok := p.anchors[name]
+	if !ok {
+		return nil, fmt.Errorf("line %d: unknown alias *%s", number, name)
+	}
+	p.aliased += countYAML(value)
+	if p.aliased > maxAliasValues {
+		return nil, fmt.Errorf("line %d: aliases expand to more than %d values", number, maxAliasValues)
+	}
+	return copyYAML(value), nil
+}
+
+// plain reads a plain scalar, folding continuation lines into spaces.
+func (p *yamlParser) plain(text string, parent int) (any, error) {
+	parts := []string{stripPlainComment(text)}
+	for p.i < len(p.lines) {
+		empty := 0
+		for p.i+empty < len(p.lines) && p.lines[p.i+empty].text == "" {
+			empty++
+		}
+		if p.i+empty >= len(p.lines) {
+			break
+		}
+		next := p.lines[p.i+empty]
+		if next.indent <= parent || next.blank() || next.marker("---") || next.marker("...") {
+			break
+		}
+		if _, _, ok := splitKey(next.text); ok {
+			return nil, fmt.Errorf("line %d: unexpected indentation", next.number)
+		}
+		if empty > 0 {
+			parts = append(parts, strings.Repeat("\n", empty))
+		} else {
+			parts = append(parts, " ")
+		}
+		parts = append(parts, stripPlainComment(next.text))
+		p.i += empty + 1
+	}
+	if len(parts) > 1 {
+		return strings.Join(parts, ""), nil
+	}
+	return typedPlain(parts[0]), nil
+}
+
+// quoted reads a single- or double-quoted scalar, which may span lines.
+func (p *yamlParser) quoted(line yamlLine, text string) (any, error) {
+	quote := text[0]
+	var b strings.Builder
+	s := text[1:]
+	for {
+		if end := closingQuote(s, quote); end >= 0 {
+			b.WriteString(s[:end])
+			if rest := strings.TrimSpace(s[end+1:]); rest != "" && rest[0] != '#' {
+				return nil, fmt.Errorf("line %d: unexpected content after quoted string", line.number)
+			}
+			break
+		}
+
+		segment := strings.TrimRight(s, " \t")
+		escapedBreak := quote == '"' && trailingBackslashes(segment)%2 == 1
+		if escapedBreak {
+			segment = segment[:len(segment)-1]
+		}
+		b.WriteString(segment)
+
+		empty := 0
+		for p.i < len(p.lines) && p.lines[p.i].text == "" {
+			empty++
+			p.i++
+		}
+		if p.i >= len(p.lines) {
+			return nil, fmt.Errorf("line %d: unterminated quoted string", line.number)
+		}
+		switch {
+		case empty > 0:
+			b.WriteString(strings.Repeat("\n", empty))
+		case !escapedBreak:
+			b.WriteByte(' ')
+		}
+		s = p.lines[p.i].raw
+		p.i++
+	}
+
+	if quote == '\'' {
+		return strings.ReplaceAll(b.String(), "''", "'"), nil
+	}
+	value, err := unescapeDouble(b.String())
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %w", line.number, err)
+	}
+	return value, nil
+}
+
+// blockScalar reads a literal (|) or folded (>) scalar from the lines
+// indented more than parent.
+func (p *yamlParser) blockScalar(line yamlLine, text string, parent int) (string, error) {
+	header := strings.TrimSpace(stripPlainComment(text))
+	literal := header[0] == '|'
+	var chomp byte
+	explicit := 0
+	for _, c := range []byte(header[1:]) {
+		switch {
+		case (c == '-' || c == '+') && chomp == 0:
+			chomp = c
+		case c >= '1' && c <= '9' && explicit == 0:
+			explicit = int(c - '0')
+		default:
+			return "", fmt.Errorf("line %d: invalid block scalar header %q", line.number, header)
+		}
+	}
+
+	indent := 0
+	if explicit > 0 {
+		indent = max(parent, 0) + explicit
+	}
+	var lines []string
+	for p.i < len(p.lines) {
+		next := p.lines[p.i]
+		if next.text == "" {
+			lines = append(lines, "")
+			p.i++
+			continue
+		}
+		if indent == 0 {
+			if next.indent <= parent {
+				break
+			}
+			indent = next.indent
+		}
+		if next.indent < indent {
+			break
+		}
+		lines = append(lines, strings.Repeat(" ", next.indent-indent)+next.raw)
+		p.i++
+	}
+
+	trailing := 0
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+		trailing++
+	}
+	if len(lines) == 0 {
+		if chomp == '+' {
+			return strings.Repeat("\n", trailing), nil
+		}
+		return "", nil
+	}
+
+	body := strings.Join(lines, "\n")
+	if !literal {
+		body = foldLines(lines)
+	}
+	switch chomp {
+	case '-':
+		return body, nil
+	case '+':
+		return body + strings.Repeat("\n", trailing+1), nil
+	}
+	return body + "\n", nil
+}
+
+// foldLines joins the lines of a folded scalar: a single line break becomes a
+// space, except around more-indented lines, and empty lines are kept.
+func foldLines(lines []string) string {
+	var b strings.Builder
+	started, prevMore := false, false
+	empty := 0
+	for _, line := range lines {
+		if line == "" {
+			empty++
+			continue
+		}
+		more := line[0] == ' ' || line[0] == '\t'
+		switch {
+		case !started:
+			b.WriteString(strings.Repeat("\n", empty))
+		case more || prevMore:
+			b.WriteString(strings.Repeat("\n", empty+1))
+		case empty > 0:
+			b.WriteString(strings.Repeat("\n", empty))
+		default:
+			b.WriteByte(' ')
+		}
+		b.WriteString(line)
+		started, prevMore, empty = true, more, 0
+	}
+	return b.String()
+}
+
+// flow reads a [...] or {...} collection, joining lines until it closes.
+func (p *yamlParser) flow(line yamlLine, text string, path []string) (any, error) {
+	src := stripFlowComment(text)
+	for !flowClosed(src) {
+		if p.i >= len(p.lines) || p.lines[p.i].marker("---") {
+			return nil, fmt.Errorf("line %d: unterminated flow collection", line.number)
+		}
+		src += " " + stripFlowComment(p.lines[p.i].text)
+		p.i++
+	}
+
+	f := &flowParser{p: p, src: src, line: line.number}
+	value, err := f.value(path)
+	if err != nil {
+		return nil, err
+	}
+	f.skipSpace()
+	if f.pos < len(f.src) {
+		return nil, f.errorf("unexpected content after flow collection")
+	}
+	return value, nil
+}
+
+func (p *yamlParser) record(path []string, key string) {
+	if p.order == nil || path == nil {
+		return
+	}
+	id := strings.Join(path, "\x00")
+	p.order[id] = append(p.order[id], key)
+}
+
+// childPath extends a key path; nil paths are not tracked.
+func childPath(path []string, key string) []string {
+	if path == nil {
+		return nil
+	}
+	return append(slices.Clip(path), key)
+}
+
+type flowParser struct {
+	p    *yamlParser
+	src  string
+	pos  int
+	line int
+}
+
+func (f *flowParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("line %d: "+format, append([]any{f.line}, args...)...)
+}
+
+func (f *flowParser) skipSpace() {
+	for f.pos < len(f.src) && (f.src[f.pos] == ' ' || f.src[f.pos] == '\t') {
+		f.pos++
+	}
+}
+
+func (f *flowParser) value(path []string) (any, error) {
+	f.skipSpace()
+	if f.pos >= len(f.src) {
+		return nil, f.errorf("unterminated flow collection")
+	}
+
+	anchor := ""
+	if f.src[f.pos] == '&' {
+		f.pos++
+		anchor = f.token()
+		if anchor == "" {
+			return nil, f.errorf("anchor needs a name")
+		}
+		f.skipSpace()
+	}
+
+	var value any
+	var err error
+	switch c := f.src[f.pos]; {
+	case c == '*':
+		f.pos++
+		value, err = f.p.alias(f.token(), f.line)
+	case strings.HasPrefix(f.src[f.pos:], "{{"):
+		value = typedPlain(f.plain())
+	case c == '[':
+		value, err = f.sequence()
+	case c == '{':
+		value, err = f.mapping(path)
+	case c == '"' || c == '\'':
+		value, err = f.quoted()
+	default:
+		value = typedPlain(f.plain())
+	}
+	if err != nil {
+		return nil, err
+	}
+	if anchor != "" {
+		f.p.anchors[anchor] = value
+	}
+	return value, nil
+}
+
+func (f *flowParser) sequence() ([]any, error) {
+	f.pos++
+	out := []any{}
+	for {
+		f.skipSpace()
+		if f.pos >= len(f.src) {
+			return nil, f.errorf("unterminated flow sequence")
+		}
+		if f.src[f.pos] == ']' {
+			f.pos++
+			return out, nil
+		}
+		if f.src[f.pos] == ',' {
+			return nil, f.errorf("empty entry in flow sequence")
+		}
+
+		start := f.pos
+		item, err := f.value(nil)
+		if err != nil {
+			return nil, err
+		}
+		f.skipSpace()
+		if f.colon() {
+			// [key: value] is a sequence holding a single-pair mapping.
+			key := strings.TrimSpace(f.src[start : f.pos-1])
+			if s, ok := item.(string); ok && key != "" && (key[0] == '"' || key[0] == '\'') {
+				key = s
+			}
+			value, err := f.entryValue(nil)
+			if err != nil {
+				return nil, err
+			}
+			item = map[string]any{key: value}
+		}
+		out = append(out, item)
+
+		if err := f.separator(']'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (f *flowParser) mapping(path []string) (map[string]any, error) {
+	f.pos++
+	out := make(map[string]any)
+	var merges []any
+	for {
+		f.skipSpace()
+		if f.pos >= len(f.src) {
+			return nil, f.errorf("unterminated flow mapping")
+		}
+		if f.src[f.pos] == ',' {
+			return nil, f.errorf("empty entry in flow mapping")
+		}
+		if f.src[f.pos] == '}' {
+			f.pos++
+			if err := mergeKeys(out, merges); err != nil {
+				return nil, f.errorf("%v", err)
+			}
+			return out, nil
+		}
+
+		key, err := f.key()
+		if err != nil {
+			return nil, err
+		}
+		var value any
+		f.skipSpace()
+		if f.colon() {
+			if value, err = f.entryValue(childPath(path, key)); err != nil {
+				return nil, err
+			}
+		}
+		if key == "<<" {
+			merges = append(merges, value)
+		} else {
+			if _, dup := out[key]; !dup {
+				f.p.record(path, key)
+			}
+			out[key] = value
+		}
+
+		if err := f.separator('}'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// colon consumes the ':' separating a key from its value.
+func (f *flowParser) colon() bool {
+	if f.pos < len(f.src) && f.src[f.pos] == ':' {
+		f.pos++
+		return true
+	}
+	return false
+}
+
+// entryValue reads the value after a colon, which may be left empty.
+func (f *flowParser) entryValue(path []string) (any, error) {
+	f.skipSpace()
+	if f.pos < len(f.src) && (f.src[f.pos] == ',' || f.src[f.pos] == '}' || f.src[f.pos] == ']') {
+		return nil, nil
+	}
+	return f.value(path)
+}
+
+func (f *flowParser) separator(closing byte) error {
+	f.skipSpace()
+	if f.pos >= len(f.src) {
+		return f.errorf("unterminated flow collection")
+	}
+	switch f.src[f.pos] {
+	case ',':
+		f.pos++
+		return nil
+	case closing:
+		return nil
+	}
+	return f.errorf("expected , or %c in flow collection", closing)
+}
+
+func (f *flowParser) key() (string, error) {
+	f.skipSpace()
+	switch c := f.src[f.pos]; {
+	case c == '"' || c == '\'':
+		value, err := f.quoted()
+		if err != nil {
+			return "", err
+		}
+		return value.(string), nil
+	case (c == '[' || c == '{') && !strings.HasPrefix(f.src[f.pos:], "{{"):
+		return "", f.errorf("collections cannot be mapping keys")
+	}
+	key := f.plain()
+	if key == "" {
+		return "", f.errorf("expected a mapping key")
+	}
+	return key, nil
+}
+
+// plain reads a plain scalar up to a flow indicator. {{var}} templates are
+// kept whole.
+func (f *flowParser) plain() string {
+	start := f.pos
+	for f.pos < len(f.src) {
+		if strings.HasPrefix(f.src[f.pos:], "{{") {
+			if end := strings.Index(f.src[f.pos:], "}}"); end >= 0 {
+				f.pos += end + 2
+				continue
+			}
+		}
+		c := f.src[f.pos]
+		if c == ',' || c == '[' || c == ']' || c == '{' || c == '}' {
+			break
+		}
+		if c == ':' && (f.pos+1 == len(f.src) || strings.IndexByte(" \t,[]{}", f.src[f.pos+1]) >= 0) {
+			break
+		}
+		f.pos++
+	}
+	return strings.TrimSpace(f.src[start:f.pos])
+}
+
+func (f *flowParser) quoted() (any, error) {
+	quote := f.src[f.pos]
+	end := closingQuote(f.src[f.pos+1:], quote)
+	if end < 0 {
+		return nil, f.errorf("unterminated quoted string")
+	}
+	content := f.src[f.pos+1 : f.pos+1+end]
+	f.pos += end + 2
+	if quote == '\'' {
+		return strings.ReplaceAll(content, "''", "'"), nil
+	}
+	value, err := unescapeDouble(content)
+	if err != nil {
+		return nil, f.errorf("%v", err)
+	}
+	return value, nil
+}
+
+// token reads an anchor or alias name.
+func (f *flowParser) token() string {
+	start := f.pos
+	for f.pos < len(f.src) && strings.IndexByte(" \t,[]{}", f.src[f.pos]) < 0 {
+		f.pos++
+	}
+	return f.src[start:f.pos]
+}
+
+// mergeKeys applies "<<" values: keys already in out win, then earlier
+// merges win over later ones.
+func mergeKeys(out map[string]any, merges []any) error {
+	for _, merge := range merges {
+		sources := []any{merge}
+		if list, ok := merge.([]any); ok {
+			sources = list
+		}
+		for _, source := range sources {
+			m, ok := source.(map[string]any)
+			if !ok {
+				return fmt.Errorf("<< must merge a mapping or a list of mappings")
+			}
+			for key, value := range m {
+				if _, exists := out[key]; !exists {
+					out[key] = value
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// plainScalar is a typed plain scalar that still holds its source text until
+// settleScalars knows which field it belongs to.
+type plainScalar struct {
+	text  string
+	value any
+}
+
+// typedPlain resolves text, keeping the source text of non-string values.
+// "null" and "~" stay null even in text fields, as they always have.
+func typedPlain(text string) any {
+	value := resolvePlain(text)
+	if _, ok := value.(string); ok || text == "" || text == "~" || text == "null" {
+		return value
+	}
+	return plainScalar{text: text, value: value}
+}
+
+// settleScalars returns value with each plainScalar replaced by its typed
+// value or, under one of textFields, its source text. Collections are copied
+// since merges and aliases may share them between fields.
+func settleScalars(value any, path []string, textFields []string) any {
+	switch v := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, item := range v {
+			out[key] = settleScalars(item, append(slices.Clip(path), key), textFields)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = settleScalars(item, append(slices.Clip(path), "*"), textFields)
+		}
+		return out
+	case plainScalar:
+		for _, field := range textFields {
+			if underField(path, strings.Split(field, ".")) {
+				return v.text
+			}
+		}
+		return v.value
+	}
+	return value
+}
+
+func underField(path, field []string) bool {
+	if len(path) < len(field) {
+		return false
+	}
+	for i, part := range field {
+		if part != "*" && part != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// resolvePlain types a plain scalar per the YAML 1.2 core schema. Keys are
+// not resolved, so "1: x" has the key "1".
+func resolvePlain(text string) any {
+	switch text {
+	case "", "~", "null", "Null", "NULL":
+		return nil
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	}
+	if yamlInt.MatchString(text) {
+		if i, err := strconv.Atoi(text); err == nil {
+			return i
+		}
+	}
+	if len(text) > 2 && text[0] == '0' && (text[1] == 'x' || text[1] == 'o') {
+		base := 16
+		if text[1] == 'o' {
+			base = 8
+		}
+		if i, err := strconv.ParseInt(text[2:], base, 0); err == nil {
+			return int(i)
+		}
+	}
+	if yamlFloat.MatchString(text) {
+		if f, err := strconv.ParseFloat(text, 64); err == nil {
+			return f
+		}
+	}
+	return text
+}
+
+// splitKey splits a "key: value" line. The colon must be followed by a space
+// or end the line, so "http://host" is not split.
+func splitKey(text string) (string, string, bool) {
+	if text == "" {
+		return "", "", false
+	}
+	switch text[0] {
+	case '"', '\'':
+		end := closingQuote(text[1:], text[0])
+		if end < 0 {
+			return "", "", false
+		}
+		after := strings.TrimLeft(text[end+2:], " \t")
+		if !strings.HasPrefix(after, ":") || (len(after) > 1 && after[1] != ' ' && after[1] != '\t') {
+			return "", "", false
+		}
+		key := text[1 : end+1]
+		if text[0] == '\'' {
+			key = strings.ReplaceAll(key, "''", "'")
+		} else if unquoted, err := unescapeDouble(key); err == nil {
+			key = unquoted
+		}
+		return key, strings.TrimLeft(after[1:], " \t"), true
+	case '[', '{':
+		if !strings.HasPrefix(text, "{{") {
+			return "", "", false
+		}
+	case '&', '*', '|', '>', '#':
+		return "", "", false
+	}
+
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '#':
+			if text[i-1] == ' ' || text[i-1] == '\t' {
+				return "", "", false
+			}
+		case ':':
+			if i+1 == len(text) || text[i+1] == ' ' || text[i+1] == '\t' {
+				key := strings.TrimRight(text[:i], " \t")
+				if key == "" {
+					return "", "", false
+				}
+				return key, strings.TrimLeft(text[i+1:], " \t"), true
+			}
+		}
+	}
+	return "", "", false
+}
+
+func isSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ") || strings.HasPrefix(text, "-\t")
+}
+
+// closingQuote returns the index in s of the quote ending a scalar whose
+// opening quote was just before s, or -1.
+func closingQuote(s string, quote byte) int {
+	for i := 0; i < len(s); i++ {
+		switch {
+		case quote == '"' && s[i] == '\\':
+			i++
+		case s[i] == quote && quote == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++
+		case s[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+func trailingBackslashes(s string) int {
+	n := 0
+	for n < len(s) && s[len(s)-1-n] == '\\' {
+		n++
+	}
+	return n
+}
+
+// yamlEscapes are the double-quoted escapes YAML has beyond Go's.
+var yamlEscapes = map[byte]rune{
+	'0': 0, 'e': 0x1b, ' ': ' ', '/': '/', '\t': '\t',
+	'N': 0x85, '_': 0xa0, 'L': 0x2028, 'P': 0x2029,
+}
+
+func unescapeDouble(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+	var b strings.Builder
+	for len(s) > 0 {
+		if s[0] == '\\' && len(s) > 1 {
+			if r, ok := yamlEscapes[s[1]]; ok {
+				b.WriteRune(r)
+				s = s[2:]
+				continue
+			}
+		}
+		r, _, tail, err := strconv.UnquoteChar(s, '"')
+		if err != nil {
+			return "", fmt.Errorf("invalid escape in quoted string")
+		}
+		b.WriteRune(r)
+		s = tail
+	}
+	return b.String(), nil
+}
+
+func stripPlainComment(text string) string {
+	for i := 1; i < len(text); i++ {
+		if text[i] == '#' && (text[i-1] == ' ' || text[i-1] == '\t') {
+			return strings.TrimRight(text[:i], " \t")
+		}
+	}
+	return text
+}
+
+// stripFlowComment drops a trailing comment outside quotes.
+func stripFlowComment(text string) string {
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case c == '"' || c == '\'':
+			end := closingQuote(text[i+1:], c)
+			if end < 0 {
+				return text
+			}
+			i += end + 1
+		case c == '#' && (i == 0 || text[i-1] == ' ' || text[i-1] == '\t'):
+			return strings.TrimRight(text[:i], " \t")
+		}
+	}
+	return text
+}
+
+// flowClosed reports whether the brackets opened in src are all closed.
+func flowClosed(src string) bool {
+	depth := 0
+	for i := 0; i < len(src); i++ {
+		switch c := src[i]; c {
+		case '"', '\'':
+			end := closingQuote(src[i+1:], c)
+			if end < 0 {
+				return false
+			}
+			i += end + 1
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+			if depth == 0 {
+				return true
+			}
+		}
+	}
+	return depth <= 0
+}
+
+// cutToken splits an anchor or alias name from the text after it.
+func cutToken(text string) (string, string) {
+	end := strings.IndexAny(text, " \t")
+	if end < 0 {
+		return text, ""
+	}
+	return text[:end], strings.TrimLeft(text[end:], " \t")
+}
+
+func countYAML(value any) int {
+	n := 1
+	switch v := value.(type) {
+	case map[string]any:
+		for _, item := range v {
+			n += countYAML(item)
+		}
+	case []any:
+		for _, item := range v {
+			n += countYAML(item)
+		}
+	}
+	return n
+}
+
+// copyYAML copies the collections of an anchored value so an alias can be
+// changed without changing the anchor.
+func copyYAML(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		out := maps.Clone(v)
+		for key, item := range out {
+			out[key] = copyYAML(item)
+		}
+		return out
+	case []any:
+		out := slices.Clone(v)
+		for i, item := range out {
+			out[i] = copyYAML(item)
+		}
+		return out
+	}
+	return value
+}
@@ -0,0 +1,444 @@
+package requestspec
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// The line-based parser that preceded yaml.go, kept as an oracle for
+// FuzzParseYAML: whatever it accepted must still parse the same way.
+
+type legacyParsedLine struct {
+	number int
+	indent int
+	text   string
+}
+
+func legacyParseYAMLObject(input string) (map[string]any, error) {
+	lines, err := legacyPreprocessLines(input)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("empty document")
+	}
+
+	value, next, err := legacyParseBlock(lines, 0, lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if next != len(lines) {
+		return nil, fmt.Errorf("unexpected trailing content at line %d", lines[next].number)
+	}
+
+	root, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("root must be a mapping")
+	}
+
+	return root, nil
+}
+
+// legacyMappingKeys returns the keys of the block mapping at path in the
+// order they appear in input, since decoded maps lose it.
+func legacyMappingKeys(input string, path ...string) []string {
+	lines, err := legacyPreprocessLines(input)
+	if err != nil {
+		return nil
+	}
+
+	type frame struct {
+		indent int
+		key    string
+	}
+	var stack []frame
+	var keys []string
+	for _, line := range lines {
+		for len(stack) > 0 && stack[len(stack)-1].indent >= line.indent {
+			stack = stack[:len(stack)-1]
+		}
+		if strings.HasPrefix(line.text, "- ") {
+			continue
+		}
+		key, _, ok := legacySplitKeyValue(line.text)
+		if !ok {
+			continue
+		}
+		if len(stack) == len(path) {
+			matched := true
+			for i, segment := range path {
+				if stack[i].key != segment {
+					matched = false
+					break
+				}
+			}
+			if matched {
+				keys = append(keys, key)
+			}
+		}
+		stack = append(stack, frame{indent: line.indent, key: key})
+	}
+	return keys
+}
+
+func legacyPreprocessLines(input string) ([]legacyParsedLine, error) {
+	rawLines := strings.Split(input, "\n")
+	lines := make([]legacyParsedLine, 0, len(rawLines))
+	for i, raw := range rawLines {
+		lineNo := i + 1
+		line := legacyStripComment(raw)
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		indent := legacyCountIndent(line)
+		if indent%2 != 0 {
+			return nil, fmt.Errorf("line %d: indentation must use multiples of 2 spaces", lineNo)
+		}
+
+		lines = append(lines, legacyParsedLine{
+			number: lineNo,
+			indent: indent,
+			text:   strings.TrimSpace(line),
+		})
+	}
+
+	return lines, nil
+}
+
+func legacyStripComment(line string) string {
+	inSingle := false
+	inDouble := false
+	escaped := false
+	for i, r := range line {
+		switch {
+		case r == '\\' && inDouble && !escaped:
+			escaped = true
+			continue
+		case r == '\'' && !inDouble:
+			inSingle = !inSingle
+		case r == '"' && !inSingle && !escaped:
+			inDouble = !inDouble
+		case r == '#' && !inSingle && !inDouble:
+			return line[:i]
+		}
+		escaped = false
+	}
+	return line
+}
+
+func legacyCountIndent(line string) int {
+	indent := 0
+	for _, r := range line {
+		if r != ' ' {
+			break
+		}
+		indent++
+	}
+	return indent
+}
+
+func legacyParseBlock(lines []legacyParsedLine, idx, indent int) (any, int, error) {
+	if idx >= len(lines) {
+		return nil, idx, fmt.Errorf("unexpected end of file")
+	}
+
+	if lines[idx].indent != indent {
+		return nil, idx, fmt.Errorf("line %d: expected indent %d, got %d", lines[idx].number, indent, lines[idx].indent)
+	}
+
+	if strings.HasPrefix(lines[idx].text, "- ") {
+		return legacyParseSequence(lines, idx, indent)
+	}
+	return legacyParseMapping(lines, idx, indent)
+}
+
+func legacyParseMapping(lines []legacyParsedLine, idx, indent int) (map[string]any, int, error) {
+	out := make(map[string]any)
+
+	for idx < len(lines) {
+		line := lines[idx]
+		if line.indent < indent {
+			break
+		}
+		if line.indent > indent {
+			return nil, idx, fmt.Errorf("line %d: unexpected indentation", line.number)
+		}
+		if strings.HasPrefix(line.text, "- ") {
+			break
+		}
+
+		key, rest, ok := legacySplitKeyValue(line.text)
+		if !ok {
+			return nil, idx, fmt.Errorf("line %d: expected key: value pair", line.number)
+		}
+		idx++
+
+		if rest == "" {
+			if idx >= len(lines) || lines[idx].indent <= indent {
+				out[key] = nil
+				continue
+			}
+			child, next, err := legacyParseBlock(lines, idx, lines[idx].indent)
+			if err != nil {
+				return nil, idx, err
+			}
+			out[key] = child
+			idx = next
+			continue
+		}
+
+		value, err := legacyParseScalar(rest)
+		if err != nil {
+			return nil, idx, fmt.Errorf("line %d: %w", line.number, err)
+		}
+		out[key] = value
+	}
+
+	return out, idx, nil
+}
+
+func legacyParseSequence(lines []legacyParsedLine, idx, indent int) ([]any, int, error) {
+	var out []any
+
+	for idx < len(lines) {
+		line := lines[idx]
+		if line.indent < indent {
+			break
+		}
+		if line.indent > indent {
+			return nil, idx, fmt.Errorf("line %d: unexpected indentation", line.number)
+		}
+		if !strings.HasPrefix(line.text, "- ") {
+			break
+		}
+
+		itemText := strings.TrimSpace(strings.TrimPrefix(line.text, "- "))
+		idx++
+
+		if itemText == "" {
+			if idx >= len(lines) || lines[idx].indent <= indent {
+				out = append(out, nil)
+				continue
+			}
+			child, next, err := legacyParseBlock(lines, idx, lines[idx].indent)
+			if err != nil {
+				return nil, idx, err
+			}
+			out = append(out, child)
+			idx = next
+			continue
+		}
+
+		if key, rest, ok := legacySplitKeyValue(itemText); ok {
+			itemMap := map[string]any{}
+			if rest == "" {
+				if idx >= len(lines) || lines[idx].indent <= indent {
+					itemMap[key] = nil
+				} else {
+					child, next, err := legacyParseBlock(lines, idx, lines[idx].indent)
+					if err != nil {
+						return nil, idx, err
+					}
+					itemMap[key] = child
+					idx = next
+				}
+			} else {
+				value, err := legacyParseScalar(rest)
+				if err != nil {
+					return nil, idx, fmt.Errorf("line %d: %w", line.number, err)
+				}
+				itemMap[key] = value
+			}
+
+			var err error
+			itemMap, idx, err = legacyParseSequenceMapTail(lines, idx, indent+2, itemMap)
+			if err != nil {
+				return nil, idx, err
+			}
+
+			out = append(out, itemMap)
+			continue
+		}
+
+		value, err := legacyParseScalar(itemText)
+		if err != nil {
+			return nil, idx, fmt.Errorf("line %d: %w", line.number, err)
+		}
+		out = append(out, value)
+	}
+
+	return out, idx, nil
+}
+
+func legacyParseSequenceMapTail(lines []legacyParsedLine, idx, indent int, itemMap map[string]any) (map[string]any, int, error) {
+	for idx < len(lines) {
+		line := lines[idx]
+		if line.indent < indent {
+			break
+		}
+		if line.indent > indent {
+			return nil, idx, fmt.Errorf("line %d: unexpected indentation", line.number)
+		}
+		if strings.HasPrefix(line.text, "- ") {
+			break
+		}
+
+		key, rest, ok := legacySplitKeyValue(line.text)
+		if !ok {
+			return nil, idx, fmt.Errorf("line %d: expected key: value pair", line.number)
+		}
+		idx++
+
+		if rest == "" {
+			if idx >= len(lines) || lines[idx].indent <= indent {
+				itemMap[key] = nil
+				continue
+			}
+			child, next, err := legacyParseBlock(lines, idx, lines[idx].indent)
+			if err != nil {
+				return nil, idx, err
+			}
+			itemMap[key] = child
+			idx = next
+			continue
+		}
+
+		value, err := legacyParseScalar(rest)
+		if err != nil {
+			return nil, idx, fmt.Errorf("line %d: %w", line.number, err)
+		}
+		itemMap[key] = value
+	}
+
+	return itemMap, idx, nil
+}
+
+func legacySplitKeyValue(text string) (string, string, bool) {
+	inSingle := false
+	inDouble := false
+	escaped := false
+	for i, r := range text {
+		switch {
+		case r == '\\' && inDouble && !escaped:
+			escaped = true
+			continue
+		case r == '\'' && !inDouble:
+			inSingle = !inSingle
+		case r == '"' && !inSingle && !escaped:
+			inDouble = !inDouble
+		case r == ':' && !inSingle && !inDouble:
+			key := strings.TrimSpace(text[:i])
+			if key == "" {
+				return "", "", false
+			}
+			return legacyUnquoteKey(key), strings.TrimSpace(text[i+1:]), true
+		}
+		escaped = false
+	}
+	return "", "", false
+}
+
+// legacyUnquoteKey strips YAML quotes so keys such as "$.id" can hold
+// special characters.
+func legacyUnquoteKey(key string) string {
+	if len(key) < 2 {
+		return key
+	}
+	switch {
+	case key[0] == '"' && key[len(key)-1] == '"':
+		if unquoted, err := strconv.Unquote(key); err == nil {
+			return unquoted
+		}
+	case key[0] == '\'' && key[len(key)-1] == '\'':
+		return strings.ReplaceAll(key[1:len(key)-1], "''", "'")
+	}
+	return key
+}
+
+func legacyParseScalar(text string) (any, error) {
+	if text == "" {
+		return "", nil
+	}
+
+	if strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]") {
+		return legacyParseInlineList(text[1 : len(text)-1])
+	}
+
+	if len(text) >= 2 {
+		if strings.HasPrefix(text, "\"") && strings.HasSuffix(text, "\"") {
+			return strconv.Unquote(text)
+		}
+		if strings.HasPrefix(text, "'") && strings.HasSuffix(text, "'") {
+			return strings.ReplaceAll(text[1:len(text)-1], "''", "'"), nil
+		}
+	}
+
+	switch text {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null", "~":
+		return nil, nil
+	}
+
+	if i, err := strconv.Atoi(text); err == nil {
+		return i, nil
+	}
+
+	return text, nil
+}
+
+func legacyParseInlineList(text string) ([]any, error) {
+	var out []any
+	var part strings.Builder
+	inSingle := false
+	inDouble := false
+	escaped := false
+
+	flush := func() error {
+		item := strings.TrimSpace(part.String())
+		part.Reset()
+		if item == "" {
+			return nil
+		}
+		value, err := legacyParseScalar(item)
+		if err != nil {
+			return err
+		}
+		out = append(out, value)
+		return nil
+	}
+
+	for len(text) > 0 {
+		r, size := utf8.DecodeRuneInString(text)
+		text = text[size:]
+
+		switch {
+		case r == '\\' && inDouble && !escaped:
+			escaped = true
+			part.WriteRune(r)
+			continue
+		case r == '\'' && !inDouble:
+			inSingle = !inSingle
+		case r == '"' && !inSingle && !escaped:
+			inDouble = !inDouble
+		case r == ',' && !inSingle && !inDouble:
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		escaped = false
+		part.WriteRune(r)
+	}
+
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,515 @@
+package requestspec
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func parseYAMLForTest(t *testing.T, input string) map[string]any {
+	t.Helper()
+	doc, err := parseYAMLObject(input)
+	if err != nil {
+		t.Fatalf("parseYAMLObject returned error: %v", err)
+	}
+	return doc
+}
+
+func TestParseYAML_BlockScalars(t *testing.T) {
+	doc := parseYAMLForTest(t, `
+literal: |
+  query GetUser($id: ID!) {
+    user(id: $id) { name } # not a comment
+  }
+
+folded: >
+  one
+  two
+
+  three
+    indented
+  four
+strip: |-
+  trimmed
+keep: |+
+  kept
+
+explicit: |2
+    two extra
+next: 1
+`)
+
+	want := map[string]any{
+		"literal":  "query GetUser($id: ID!) {\n  user(id: $id) { name } # not a comment\n}\n",
+		"folded":   "one two\nthree\n  indented\nfour\n",
+		"strip":    "trimmed",
+		"keep":     "kept\n\n",
+		"explicit": "  two extra\n",
+		"next":     1,
+	}
+	if !reflect.DeepEqual(doc, want) {
+		t.Fatalf("unexpected block scalars:\n got %#v\nwant %#v", doc, want)
+	}
+}
+
+func TestParseYAML_FlowCollections(t *testing.T) {
+	doc := parseYAMLForTest(t, `
+hooks:
+  - set: { now_iso: "{{timestamp_iso}}", count: 2 }
+empty_map: {}
+empty_list: []
+nested: [a, [1, 2.5], {k: v}, "x, y", pair: value]
+json: {"id": 7, "tags": ["a"], "ok": true, "none": null}
+multi: {
+  a: 1, # first
+  b: [x,
+      y],
+}
+template: [{{id}}, "{{name}}"]
+`)
+
+	want := map[string]any{
+		"hooks":      []any{map[string]any{"set": map[string]any{"now_iso": "{{timestamp_iso}}", "count": 2}}},
+		"empty_map":  map[string]any{},
+		"empty_list": []any{},
+		"nested":     []any{"a", []any{1, 2.5}, map[string]any{"k": "v"}, "x, y", map[string]any{"pair": "value"}},
+		"json":       map[string]any{"id": 7, "tags": []any{"a"}, "ok": true, "none": nil},
+		"multi":      map[string]any{"a": 1, "b": []any{"x", "y"}},
+		"template":   []any{"{{id}}", "{{name}}"},
+	}
+	if !reflect.DeepEqual(doc, want) {
+		t.Fatalf("unexpected flow collections:\n got %#v\nwant %#v", doc, want)
+	}
+}
+
+func TestParseYAML_Scalars(t *testing.T) {
+	doc := parseYAMLForTest(t, `
+int: 42
+negative: -7
+float: 1.5
+exponent: 1e3
+hex: 0x1F
+quoted_float: "1.5"
+bools: [true, True, FALSE]
+nulls: [null, ~, Null]
+version: 1.2.3
+url: http://example.com:8080/a#frag # comment
+plain: a: b
+multi_line: first
+  second
+
+  third
+quoted: "line one
+  line two \
+  joined \t tab"
+single: 'it''s
+  folded'
+"$.id": { exists: true }
+`)
+
+	want := map[string]any{
+		"int":          42,
+		"negative":     -7,
+		"float":        1.5,
+		"exponent":     1000.0,
+		"hex":          31,
+		"quoted_float": "1.5",
+		"bools":        []any{true, true, false},
+		"nulls":        []any{nil, nil, nil},
+		"version":      "1.2.3",
+		"url":          "http://example.com:8080/a#frag",
+		"plain":        "a: b",
+		"multi_line":   "first second\nthird",
+		"quoted":       "line one line two joined \t tab",
+		"single":       "it's folded",
+		"$.id":         map[string]any{"exists": true},
+	}
+	if !reflect.DeepEqual(doc, want) {
+		t.Fatalf("unexpected scalars:\n got %#v\nwant %#v", doc, want)
+	}
+}
+
+func TestParseYAML_AnchorsAliasesAndMerges(t *testing.T) {
+	doc := parseYAMLForTest(t, `
+defaults: &defaults
+  timeout_ms: 1000
+  headers: &headers
+    Accept: application/json
+first:
+  <<: *defaults
+  timeout_ms: 50
+second:
+  <<: [*defaults]
+  headers: *headers
+tags: &tags [a, b]
+again: *tags
+`)
+
+	first := doc["first"].(map[string]any)
+	if first["timeout_ms"] != 50 || !reflect.DeepEqual(first["headers"], map[string]any{"Accept": "application/json"}) {
+		t.Fatalf("unexpected merge: %#v", first)
+	}
+	second := doc["second"].(map[string]any)
+	if second["timeout_ms"] != 1000 {
+		t.Fatalf("unexpected list merge: %#v", second)
+	}
+	second["headers"].(map[string]any)["Accept"] = "text/plain"
+	if doc["defaults"].(map[string]any)["headers"].(map[string]any)["Accept"] != "application/json" {
+		t.Fatal("changing an alias changed its anchor")
+	}
+	if !reflect.DeepEqual(doc["again"], []any{"a", "b"}) {
+		t.Fatalf("unexpected alias: %#v", doc["again"])
+	}
+}
+
+func TestParseYAML_IndentationStyles(t *testing.T) {
+	doc := parseYAMLForTest(t, "---\nrequest:\n\tmethod: GET\n\theaders:\n\t\tAccept: \"*/*\"\nitems:\n- a\n-   b: 1\n    c: 2\n- - x\n  - y\nodd:\n   three: 3\n...\n")
+
+	want := map[string]any{
+		"request": map[string]any{"method": "GET", "headers": map[string]any{"Accept": "*/*"}},
+		"items":   []any{"a", map[string]any{"b": 1, "c": 2}, []any{"x", "y"}},
+		"odd":     map[string]any{"three": 3},
+	}
+	if !reflect.DeepEqual(doc, want) {
+		t.Fatalf("unexpected document:\n got %#v\nwant %#v", doc, want)
+	}
+}
+
+func TestParseYAML_ErrorsNameTheLine(t *testing.T) {
+	for input, want := range map[string]string{
+		"a: 1\n  b: 2\n":             "line 2: unexpected indentation",
+		"a: 1\nb: *missing\n":        "line 2: unknown alias *missing",
+		"a:\n  b: [1, 2\n":           "line 2: unterminated flow collection",
+		"a: \"open\nb: 1\n":          "line 1: unterminated quoted string",
+		"a: {x: 1 y: 2}\n":           "line 1: expected , or }",
+		"a: 1\n---\nb: 2\n":          "line 2: multiple documents are not supported",
+		"a: |x\n  text\n":            "line 1: invalid block scalar header",
+		"a: 1\nnot a pair\n":         "line 2: expected key: value pair",
+		"- a\n":                      "root must be a mapping",
+		"# only a comment\n":         "empty document",
+		"a:\n  - 1\n  b: 2\n":        "line 3:",
+		"a: \"bad \\q escape\"\n":    "line 1: invalid escape",
+		"<<: 1\n":                    "<< must merge a mapping",
+		"a: {<<: [1]}\n":             "line 1: << must merge a mapping",
+		"a: [\"x\" y]\n":             "line 1: expected , or ]",
+		"a: \"x\" y\n":               "line 1: unexpected content after quoted string",
+		"a: *x y\n":                  "line 1: unexpected content after alias",
+		"a: &\n  b: 1\n":             "line 1: anchor needs a name",
+		"a: 1\nb: [1, 2]] \n":        "line 2: unexpected content after flow collection",
+		"a:\n  b: 1\n c: 2\n":        "line 3: unexpected indentation",
+		"a: 1\n- b\n":                "unexpected trailing content at line 2",
+		"a: [1, {b: 2}, {[c]: 3}]\n": "line 1: collections cannot be mapping keys",
+		"a: [1,, 2]\n":               "line 1: empty entry in flow sequence",
+		billionLaughs(30):            "aliases expand to more than",
+	} {
+		_, err := parseYAMLObject(input)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("parseYAMLObject(%q) error = %v, want %q", input, err, want)
+		}
+	}
+}
+
+// billionLaughs returns n anchors that each alias the previous one twice.
+func billionLaughs(n int) string {
+	var b strings.Builder
+	b.WriteString("a0: &a0 [x, x]\n")
+	for i := 1; i < n; i++ {
+		fmt.Fprintf(&b, "a%d: &a%d [*a%d, *a%d]\n", i, i, i-1, i-1)
+	}
+	return b.String()
+}
+
+func TestMappingKeys_KeepsDocumentOrder(t *testing.T) {
+	input := "request:\n  query: {z: 1, a: 2}\nexpect:\n  b: 1\n  a: 2\n  b: 3\n"
+	if got := mappingKeys(input, "request", "query"); !reflect.DeepEqual(got, []string{"z", "a"}) {
+		t.Fatalf("unexpected flow keys %v", got)
+	}
+	if got := mappingKeys(input, "expect"); !reflect.DeepEqual(got, []string{"b", "a"}) {
+		t.Fatalf("unexpected block keys %v", got)
+	}
+}
+
+func TestParse_DocumentedYAML(t *testing.T) {
+	spec, _, err := Parse([]byte(`
+version: 1
+kind: http
+name: users.graphql
+request:
+  url: "{{base_url}}/graphql"
+  http_version: 1.1
+  body:
+    mode: graphql
+    query: |
+      query GetUser($id: ID!) {
+        user(id: $id) { name }
+      }
+    variables: { id: "{{user_id}}", limit: 2.5 }
+hooks:
+  pre_send:
+    - set: { now_iso: "{{timestamp_iso}}" }
+`))
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if spec.Request.HTTPVersion != "1.1" || spec.Request.Method != "POST" {
+		t.Fatalf("unexpected request %+v", spec.Request)
+	}
+	if !strings.HasPrefix(spec.Request.Body.Query, "query GetUser($id: ID!) {\n  user") {
+		t.Fatalf("unexpected query %q", spec.Request.Body.Query)
+	}
+	if spec.Request.Body.Variables.(map[string]any)["limit"] != 2.5 {
+		t.Fatalf("unexpected variables %#v", spec.Request.Body.Variables)
+	}
+	set := spec.Hooks["pre_send"].([]any)[0].(map[string]any)["set"]
+	if !reflect.DeepEqual(set, map[string]any{"now_iso": "{{timestamp_iso}}"}) {
+		t.Fatalf("unexpected hook %#v", set)
+	}
+}
+
+func TestParse_TextFieldsKeepPlainScalars(t *testing.T) {
+	spec, _, err := Parse([]byte(`
+version: 1
+kind: http
+request:
+  method: POST
+  url: "{{base_url}}/items"
+  timeout_ms: 0x3E8
+  headers:
+    Accept-Version: 1.0
+    X-Flags: [True, 0o17]
+    X-Empty: ~
+  query: {v: 1.10, id: 12345678901234567890, hex: 0x1F}
+  body:
+    mode: form
+    form:
+      zip: 01234
+mock:
+  status: 200
+  headers:
+    X-Ratio: .5
+`))
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	want := map[string]any{"Accept-Version": "1.0", "X-Flags": []any{"True", "0o17"}, "X-Empty": nil}
+	if !reflect.DeepEqual(spec.Request.Headers, want) {
+		t.Fatalf("unexpected headers %#v", spec.Request.Headers)
+	}
+	if want := map[string]any{"v": "1.10", "id": "12345678901234567890", "hex": "0x1F"}; !reflect.DeepEqual(spec.Request.Query, want) {
+		t.Fatalf("unexpected query %#v", spec.Request.Query)
+	}
+	if spec.Request.Body.Form["zip"] != "01234" || spec.Mock.Headers["X-Ratio"] != ".5" {
+		t.Fatalf("unexpected form %#v or mock headers %#v", spec.Request.Body.Form, spec.Mock.Headers)
+	}
+	if spec.Request.TimeoutMS != 1000 {
+		t.Fatalf("expected timeout_ms to stay typed, got %d", spec.Request.TimeoutMS)
+	}
+}
+
+// legacyChanges are the inputs whose meaning changed on purpose when the
+// parser was replaced. legacy is nil where the old parser failed, and current
+// is nil where the new one does.
+var legacyChanges = []struct {
+	name    string
+	input   string
+	legacy  map[string]any
+	current map[string]any
+}{
+	{"floats are typed", "v: 1.10", map[string]any{"v": "1.10"}, map[string]any{"v": 1.1}},
+	{"hex and octal are typed", "h: 0x1F\no: 0o17", map[string]any{"h": "0x1F", "o": "0o17"}, map[string]any{"h": 31, "o": 15}},
+	{"capitalised booleans and nulls are typed", "b: True\nn: NULL", map[string]any{"b": "True", "n": "NULL"}, map[string]any{"b": true, "n": nil}},
+	{"empty flow sequences are empty lists", "tags: []", map[string]any{"tags": []any(nil)}, map[string]any{"tags": []any{}}},
+	{"flow mappings are mappings", "q: {a: 1}", map[string]any{"q": "{a: 1}"}, map[string]any{"q": map[string]any{"a": 1}}},
+	{"block scalars keep their lines", "s: |\n  a\n  b", nil, map[string]any{"s": "a\nb\n"}},
+	{"anchors and aliases", "a: &x 1\nb: *x", map[string]any{"a": "&x 1", "b": "*x"}, map[string]any{"a": 1, "b": 1}},
+	{"YAML escapes in double quotes", `s: "a\/b\e"`, nil, map[string]any{"s": "a/b\x1b"}},
+	{"nested block sequences", "l:\n  - - a", map[string]any{"l": []any{"- a"}}, map[string]any{"l": []any{[]any{"a"}}}},
+	{"a key after an empty item key is its sibling", "l:\n  - a:\n    b: 1", map[string]any{"l": []any{map[string]any{"a": map[string]any{"b": 1}}}}, map[string]any{"l": []any{map[string]any{"a": nil, "b": 1}}}},
+	{"empty flow entries are rejected", "l: [,0]", map[string]any{"l": []any{0}}, nil},
+	{"text after a closing quote is rejected", `a: ""0`, map[string]any{"a": `""0`}, nil},
+	{"a colon in a sequence item needs a space after it", "l:\n  - http://host/x", map[string]any{"l": []any{map[string]any{"http": "//host/x"}}}, map[string]any{"l": []any{"http://host/x"}}},
+	{"document markers", "---\na: 1\n...", nil, map[string]any{"a": 1}},
+	{"comments need a space before #", "a: b#c # d", map[string]any{"a": "b"}, map[string]any{"a": "b#c"}},
+}
+
+func TestParseYAML_LegacyChanges(t *testing.T) {
+	for _, tc := range legacyChanges {
+		legacy, err := legacyParseYAMLObject(tc.input)
+		if (err != nil) != (tc.legacy == nil) || (err == nil && !reflect.DeepEqual(legacy, tc.legacy)) {
+			t.Errorf("%s: old parser gave %#v (err=%v), want %#v", tc.name, legacy, err, tc.legacy)
+		}
+		current, err := parseYAMLObject(tc.input)
+		if (err != nil) != (tc.current == nil) || (err == nil && !reflect.DeepEqual(current, tc.current)) {
+			t.Errorf("%s: parser gave %#v (err=%v), want %#v", tc.name, current, err, tc.current)
+		}
+	}
+}
+
+// sameAsLegacy compares a legacy value to one parsed with every plain scalar
+// kept as text: strings must match exactly, and the booleans and integers the
+// old parser typed must come from the same text.
+func sameAsLegacy(legacy, current any) bool {
+	switch l := legacy.(type) {
+	case map[string]any:
+		c, ok := current.(map[string]any)
+		if !ok || len(l) != len(c) {
+			return false
+		}
+		for key, value := range l {
+			if other, ok := c[key]; !ok || !sameAsLegacy(value, other) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		c, ok := current.([]any)
+		if !ok || len(l) != len(c) {
+			return false
+		}
+		for i := range l {
+			if !sameAsLegacy(l[i], c[i]) {
+				return false
+			}
+		}
+		return true
+	case bool:
+		return current == strconv.FormatBool(l)
+	case int:
+		text, ok := current.(string)
+		if !ok {
+			return false
+		}
+		n, err := strconv.Atoi(text)
+		return err == nil && n == l
+	}
+	return reflect.DeepEqual(legacy, current)
+}
+
+// legacyDoc builds a request file from fuzz input using only the syntax both
+// parsers read the same way, so every generated document can be compared.
+type legacyDoc struct {
+	choices []byte
+	out     strings.Builder
+}
+
+var legacyScalars = []string{
+	"0", "42", "-7", "+3", "007", "1.0", "1.10", ".5", "1e3", "0x1F", "0o17",
+	"12345678901234567890", "true", "True", "FALSE", "null", "Null", "~",
+	"users", "a b", "v1.2", "user_id", "{{base_url}}/users", "$.id", "x-y",
+}
+
+var legacyKeys = []string{"0", "1.0", "true", "null", "name", "X-Api-Version", "$.id", "user_id", "a b"}
+
+func (d *legacyDoc) choose(n int) int {
+	if len(d.choices) == 0 {
+		return 0
+	}
+	c := int(d.choices[0]) % n
+	d.choices = d.choices[1:]
+	return c
+}
+
+func (d *legacyDoc) scalar() string {
+	text := legacyScalars[d.choose(len(legacyScalars))]
+	switch d.choose(6) {
+	case 0:
+		return `"` + text + `"`
+	case 1:
+		return "'" + text + "'"
+	case 2:
+		return text + " # note"
+	}
+	return text
+}
+
+func (d *legacyDoc) list() string {
+	items := make([]string, 1+d.choose(3))
+	for i := range items {
+		items[i] = legacyScalars[d.choose(len(legacyScalars))]
+	}
+	return "[" + strings.Join(items, ", ") + "]"
+}
+
+func (d *legacyDoc) mapping(indent string, depth int) {
+	for i := range 1 + d.choose(3) {
+		d.out.WriteString(indent + legacyKeys[d.choose(len(legacyKeys))] + "_" + strconv.Itoa(i) + ":")
+		d.value(indent, depth)
+	}
+}
+
+func (d *legacyDoc) value(indent string, depth int) {
+	switch choice := d.choose(5); {
+	case choice == 0 && depth < 3:
+		d.out.WriteString("\n")
+		d.mapping(indent+"  ", depth+1)
+	case choice == 1 && depth < 3:
+		d.out.WriteString("\n")
+		d.sequence(indent+"  ", depth+1)
+	case choice == 2:
+		d.out.WriteString(" " + d.list() + "\n")
+	default:
+		d.out.WriteString(" " + d.scalar() + "\n")
+	}
+}
+
+func (d *legacyDoc) sequence(indent string, depth int) {
+	for range 1 + d.choose(3) {
+		if d.choose(3) > 0 {
+			d.out.WriteString(indent + "- " + d.scalar() + "\n")
+			continue
+		}
+		for i := range 1 + d.choose(3) {
+			prefix := indent + "  "
+			if i == 0 {
+				prefix = indent + "- "
+			}
+			d.out.WriteString(prefix + "k_" + strconv.Itoa(i) + ":")
+			d.value(indent+"  ", depth)
+		}
+	}
+}
+
+func FuzzParseYAML(f *testing.F) {
+	for _, seed := range []string{
+		"version: 1\nkind: http\nname: users.create\ntags: [users, create]\n",
+		"request:\n  method: POST\n  url: \"{{base_url}}/users\"\n  query:\n    invite: \"true\"\n    page: 2\n",
+		"steps:\n  - request: users/create\n    export:\n      user_id: \"$.id\"\n  - request: users/get\n    continue_on_error: true\n",
+		"a: &x {b: [1, *x]}\nc: |\n  text\n<<: *x\n",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		doc, err := parseYAMLObject(input, "request.query")
+		_ = mappingKeys(input, "request", "query")
+		if err == nil && strings.Contains(fmt.Sprintf("%#v", doc), "plainScalar") {
+			t.Fatalf("unsettled scalar in %#v\n%q", doc, input)
+		}
+	})
+}
+
+func FuzzParseYAML_MatchesLegacy(f *testing.F) {
+	for _, seed := range []string{"", "\x01\x02\x03", "\x00\x01\x01\x02\x02\x00\x03", "\x10\x21\x32\x43\x54\x65\x76\x87\x98"} {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, choices []byte) {
+		doc := &legacyDoc{choices: choices}
+		doc.mapping("", 0)
+		input := doc.out.String()
+
+		legacy, err := legacyParseYAMLObject(input)
+		if err != nil {
+			t.Fatalf("old parser rejected a generated document: %v\n%s", err, input)
+		}
+		current, err := parseYAMLObject(input, "*")
+		if err != nil {
+			t.Fatalf("parser rejected a document the old parser accepted: %v\n%s", err, input)
+		}
+		if !sameAsLegacy(legacy, current) {
+			t.Fatalf("parse changed:\n old %#v\n new %#v\n%s", legacy, current, input)
+		}
+	})
+}